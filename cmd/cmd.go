@@ -57,6 +57,10 @@ func Prepare() *cobra.Command {
 	getCmd := getCMDs()
 	rootCmd.AddCommand(getCmd)
 
+	// Add replay command
+	replayCmdFlags(replayCmd)
+	rootCmd.AddCommand(replayCmd)
+
 	return rootCmd
 }
 func Run() error {
@@ -20,10 +20,12 @@ func getCMDs() *cobra.Command {
 
 	getCMDsFlags(getCmd)
 	getHQCmdFlags(getHQCmd)
+	getStreamCmdFlags(getStreamCmd)
 
 	getCmd.AddCommand(getURLCmd)
 	getCmd.AddCommand(getListCmd)
 	getCmd.AddCommand(getHQCmd)
+	getCmd.AddCommand(getStreamCmd)
 
 	return getCmd
 }
@@ -45,20 +47,33 @@ func addBasicFlags(getCmd *cobra.Command) {
 	getCmd.PersistentFlags().String("user-agent", "", "User agent to use when requesting URLs.")
 	getCmd.PersistentFlags().String("job", "", "Job name to use, will determine the path for the persistent queue, seencheck database, and WARC files.")
 	getCmd.PersistentFlags().IntP("workers", "w", 1, "Number of concurrent workers to run.")
+	getCmd.PersistentFlags().Bool("autoscale", false, "Grow and shrink the number of archiver workers and of seeds processed at the same time between --autoscale-min-workers and --autoscale-max-workers, based on the backpressure of the pipeline. --workers is the starting point.")
+	getCmd.PersistentFlags().Int("autoscale-min-workers", 1, "Minimum number of workers when --autoscale is enabled.")
+	getCmd.PersistentFlags().Int("autoscale-max-workers", 0, "Maximum number of workers when --autoscale is enabled. 0 means 4 times --workers.")
+	getCmd.PersistentFlags().Duration("autoscale-interval", 10*time.Second, "Interval at which the pipeline backpressure is checked and the workers are scaled when --autoscale is enabled.")
 	getCmd.PersistentFlags().Int("max-concurrent-assets", 1, "Max number of concurrent assets to fetch PER worker. E.g. if you have 100 workers and this setting at 8, Zeno could do up to 800 concurrent requests at any time.")
 	getCmd.PersistentFlags().Int("max-hops", 0, "Maximum number of hops to execute.")
 	getCmd.PersistentFlags().Int("max-outlinks", 0, "Maximum number of outlinks per seed")
+	getCmd.PersistentFlags().Int("seed-max-items", 0, "Maximum number of items (assets, redirections...) crawled per seed, the items past it are marked as failed. 0 means no limit.")
+	getCmd.PersistentFlags().Int("seed-max-size", 0, "Maximum size in MB downloaded per seed (the seed and its items), the items left when it is reached are marked as failed. 0 means no limit.")
+	getCmd.PersistentFlags().Duration("seed-max-duration", 0, "Maximum time spent crawling a seed and its items, the items left when it is reached are marked as failed. 0 means no limit.")
 	getCmd.PersistentFlags().String("cookies", "", "File containing cookies that will be used for requests.")
+	getCmd.PersistentFlags().Bool("cookies-persist", false, "Save the cookie jar, including the cookies received during the crawl, as cookies.txt in the job directory when the crawl stops.")
+	getCmd.PersistentFlags().String("robots-policy", "ignore", "What to do with robots.txt: \"obey\" fetches and archives robots.txt, drops disallowed URLs and honours Crawl-delay, \"archive-only\" fetches and archives robots.txt but archives disallowed URLs anyway, tagged with \"robots: disallowed\" in their WARC metadata record, \"ignore\" doesn't fetch robots.txt.")
 	getCmd.PersistentFlags().Bool("disable-seencheck", false, "Disable the (remote or local) seencheck that avoid re-crawling of URIs.")
 	getCmd.PersistentFlags().Bool("api", false, "Enable API")
 	getCmd.PersistentFlags().Int("api-port", 9090, "Port to listen on for the API.")
+	getCmd.PersistentFlags().String("api-token", "", "Token the API endpoints changing the state of the crawl (pause, resume, seeds insertion, dump, scope and rate limit reloads) require as \"Authorization: Bearer <token>\". Without it, these endpoints only accept requests from a loopback address.")
 	getCmd.PersistentFlags().Int("max-redirect", 20, "Specifies the maximum number of redirections to follow for a resource.")
 	getCmd.PersistentFlags().Int("max-css-jump", 10, "Specifies the maximum number of CSS @import jumps to follow for a resource.")
 	getCmd.PersistentFlags().Int("max-retry", 5, "Number of retry if error happen when executing HTTP request.")
-	getCmd.PersistentFlags().Duration("http-timeout", 0, "Time to wait before timing out a request. Note: this will CANCEL large files download.")
+	getCmd.PersistentFlags().Duration("max-retry-after", 5*time.Minute, "Maximum delay to honour from the Retry-After and RateLimit-Reset response headers, before retrying a request and before sending more requests to its host. 0 means no limit.")
+	getCmd.PersistentFlags().Duration("http-timeout", 0, "Time to wait before timing out a request. Note: this will CANCEL large files download, unless they are resumed (see --range-resume-min-size).")
+	getCmd.PersistentFlags().Int("range-resume-min-size", 0, "Minimum size in MB of the responses served with Accept-Ranges: bytes whose interrupted transfers are resumed with Range requests, up to --max-retry times. The body is spooled and written to the WARC as a single reassembled response. 0 disables resuming.")
 	getCmd.PersistentFlags().Duration("conn-read-deadline", 60*time.Second, "Time to wait before timing out a (blocking) TCP connection read.")
 	getCmd.PersistentFlags().StringSlice("domains-crawl", []string{}, "Naive domains, full URLs or regexp to match against any URL to determine hop behaviour for outlinks. If an outlink URL is matched it will be queued to crawl with a hop of 0. This flag helps crawling entire domains while doing non-focused crawls.")
 	getCmd.PersistentFlags().StringSlice("domains-crawl-file", []string{}, "File(s) containing domains, full URLs or regexp to match against any URL to determine hop behaviour for outlinks. If an outlink URL is matched it will be queued to crawl with a hop of 0. This flag helps crawling entire domains while doing non-focused crawls.")
+	getCmd.PersistentFlags().Bool("disable-sitemap-discovery", false, "Don't fetch the robots.txt Sitemap: entries and /sitemap.xml of the hosts matched by --domains-crawl. By default, their sitemaps (indexes and .xml.gz included) are walked once per host and their pages are queued at hop 0.")
 	getCmd.PersistentFlags().StringSlice("disable-html-tag", []string{}, "Specify HTML tag to not extract assets from")
 	getCmd.PersistentFlags().Bool("capture-alternate-pages", false, "If turned on, <link> HTML tags with \"alternate\" values for their \"rel\" attribute will be archived.")
 	getCmd.PersistentFlags().StringSlice("exclude-host", []string{}, "Exclude a specific host from the crawl, note that it will not exclude the domain if it is encountered as an asset for another web page.")
@@ -68,8 +83,9 @@ func addBasicFlags(getCmd *cobra.Command) {
 	getCmd.PersistentFlags().Int("crawl-max-time-limit", 0, "Number of seconds until the crawl will automatically panic itself. Default to crawl-time-limit + (crawl-time-limit / 10)")
 	getCmd.PersistentFlags().StringSlice("exclude-string", []string{}, "Discard any (discovered) URLs containing this string.")
 	getCmd.PersistentFlags().StringSlice("exclusion-file", []string{}, "File containing regex to apply on URLs for exclusion. If the path start with http or https, it will be treated as a URL of a file to download.")
-	getCmd.PersistentFlags().Bool("exclusion-file-live-reload", false, "If turned on, the exclusion file will be reloaded every X seconds. X is defined by the --exclusion-file-live-reload-interval flag. (60 seconds by default)")
-	getCmd.PersistentFlags().Duration("exclusion-file-live-reload-interval", time.Minute, "Interval at which to reload the exclusion file.")
+	getCmd.PersistentFlags().StringSlice("scope-file", []string{}, "JSON file(s) with additional scope rules, reloaded with the rest of the scope. Keys: include-host, exclude-host, include-string, exclude-string, exclusion-regex and domains-crawl, each a list of strings.")
+	getCmd.PersistentFlags().Bool("scope-live-reload", false, "If turned on, the scope (exclusion files, domains crawl files and scope files) will be reloaded every X seconds. X is defined by the --scope-live-reload-interval flag. (60 seconds by default) The scope is also reloaded on SIGHUP or with POST /api/scope/reload.")
+	getCmd.PersistentFlags().Duration("scope-live-reload-interval", time.Minute, "Interval at which to reload the scope.")
 	getCmd.PersistentFlags().Int("max-content-length", 0, "Max content length in MB to download for a single resource.")
 	getCmd.PersistentFlags().Float64("min-space-required", 0, "Minimum space required in GB to continue the crawl. Default will be 50GB * (total disk space / 256GB) if total disk space is less than 256GB, else 50GB.")
 	getCmd.PersistentFlags().Bool("strict-regex", false, "If turned on, the xurls `strict` regex setting will be used. Otherwise a looser regex will be used.")
@@ -102,6 +118,7 @@ func addHeadlessFlags(getCmd *cobra.Command) {
 
 func addNetworkFlags(getCmd *cobra.Command) {
 	getCmd.PersistentFlags().String("proxy", "", "Proxy to use when requesting pages.")
+	getCmd.PersistentFlags().String("proxy-pool-file", "", "JSON file listing proxies to rotate between, with a selection strategy (round-robin, sticky-per-host, least-failures), host routing rules and health checks. Each proxy writes its own WARC files and every capture made through it gets a metadata record naming it. Can't be used with --proxy.")
 	getCmd.PersistentFlags().StringSlice("dns-server", []string{}, "DNS server(s) to use for requests. Can be specified multiple times.")
 	getCmd.PersistentFlags().Bool("random-local-ip", false, "Use random local IP for requests. (will be ignored if a proxy is set)")
 	getCmd.PersistentFlags().Bool("disable-ipv4", false, "Disable IPv4 for requests.")
@@ -114,6 +131,9 @@ func addRateLimitFlags(getCmd *cobra.Command) {
 	getCmd.PersistentFlags().Float64("rate-limit-capacity", 150, "Bucket capacity for each host.")
 	getCmd.PersistentFlags().Float64("rate-limit-refill-rate", 50, "Ideal requests per second for each host.")
 	getCmd.PersistentFlags().Duration("rate-limit-cleanup-frequency", time.Duration(5*time.Minute), "How often to run cleanup of stale buckets that are not accessed in the duration.")
+	getCmd.PersistentFlags().String("rate-limit-rules-file", "", "JSON file of per-host rate limit rules (host glob or regex, capacity, refill rate and max concurrency), reloaded on SIGHUP and with POST /api/rate-limit/reload.")
+	getCmd.PersistentFlags().Int("lq-host-max-in-flight", 0, "Maximum number of URLs of the same host that the local queue sends to the workers at the same time, 0 means no limit. Hosts are always served round-robin.")
+	getCmd.PersistentFlags().Duration("lq-host-delay", 0, "Minimum time between two URLs of the same host being sent to the workers by the local queue.")
 }
 func addWARCFlags(getCmd *cobra.Command) {
 	getCmd.PersistentFlags().String("warc-prefix", "ZENO", "Prefix to use when naming the WARC files.")
@@ -132,7 +152,12 @@ func addWARCFlags(getCmd *cobra.Command) {
 	getCmd.PersistentFlags().Int("warc-dedupe-cache-size", 1000000, "Maximum number of records to store in the local dedupe cache.")
 	getCmd.PersistentFlags().String("warc-cdx-cookie", "", "Pass custom cookie during CDX requests. Example: 'cdx_auth_token=test_value'")
 	getCmd.PersistentFlags().Int("warc-size", 1024, "Size of the WARC files in MB.")
+	getCmd.PersistentFlags().Bool("warc-metadata-records", false, "If turned on, a WARC metadata record is written for every archived item, listing its via URL, hop path, outlinks, embedded assets and discard reason. It points to the response record of the item with WARC-Concurrent-To, except with --async-warc-write.")
+	getCmd.PersistentFlags().String("warc-index", "", "Write an index of each WARC file in <job>/indexes once it is closed. Possible values are: cdxj, cdx (CDX11). Empty to disable.")
+	getCmd.PersistentFlags().Bool("warc-index-merge", false, "If turned on along with --warc-index, a merged and sorted index of the whole job is written in <job>/indexes when the crawl stops.")
 	getCmd.PersistentFlags().IntSlice("warc-discard-status", []int{429}, "HTTP status codes to discard from WARC files. By default, 429 is always discarded.")
+	getCmd.PersistentFlags().String("quarantine-rules-file", "", "Path to a JSON file of heuristics (title/body fingerprints, redirections to login URLs, tiny bodies compared to their host) classifying archived soft-404 and login pages. Quarantined captures are tagged with a WARC metadata record and their outlinks aren't crawled.")
+	getCmd.PersistentFlags().String("discard-rules-file", "", "Path to a JSON file of discard rules matching on status, headers, content type, URL and body signature, each with its reason code and whether the URL is retried. Reloaded on SIGHUP. See internal/pkg/archiver/discard/README.md.")
 	getCmd.PersistentFlags().Bool("async-warc-write", false, "Write WARC records asynchronously. EXPERIMENTAL - may cause OOMs, lost data, or other unknown/unpredicted issues. No support will be provided for this feature.")
 }
 
@@ -144,17 +169,21 @@ func addLoggingFlags(getCmd *cobra.Command) {
 	getCmd.PersistentFlags().String("log-file-prefix", "ZENO", "Prefix to use when naming the log files. Default is : `ZENO`, without '-'")
 	getCmd.PersistentFlags().String("log-file-level", "info", "Log level for the log file.")
 	getCmd.PersistentFlags().String("log-file-rotation", "1h", "Log file rotation period. Default is : `1h`. Valid time units are 'ns', 'us' (or 'µs'), 'ms', 's', 'm', 'h'.")
+	getCmd.PersistentFlags().Bool("crawl-log", false, "Write a Heritrix-style crawl log, one line per fetched URL, next to the log files. It is rotated along with them.")
 }
 
 func addProfilingFlags(getCmd *cobra.Command) {
 	getCmd.PersistentFlags().String("pyroscope-address", "", "Pyroscope server address. Setting this flag will enable profiling.")
 	getCmd.PersistentFlags().Duration("pyroscope-upload-rate", 15*time.Second, "Pyroscope upload/capture rate. Default is 15s.")
+	getCmd.PersistentFlags().String("otel-endpoint", "", "OTLP/HTTP endpoint to export OpenTelemetry traces of the seeds going through the pipeline to, e.g. http://localhost:4318. Setting this flag will enable tracing.")
+	getCmd.PersistentFlags().Float64("otel-sample-ratio", 1, "Ratio of the seeds that are traced, between 0 and 1.")
 	getCmd.PersistentFlags().String("sentry-dsn", "", "Sentry Data Source Name (URL) allows Sentry to send errors and performance data to a sentry server. Setting this flag will enable the main Sentry agent.")
 }
 
 func addPrometheusFlags(getCmd *cobra.Command) {
 	getCmd.PersistentFlags().Bool("prometheus", false, "Export metrics in Prometheus format. (implies --api)")
 	getCmd.PersistentFlags().String("prometheus-prefix", "zeno_", "String used as a prefix for the exported Prometheus metrics.")
+	getCmd.PersistentFlags().Int("prometheus-host-metrics", 0, "Export per-host request, status class, response size and response time metrics for the N most requested hosts, the others are aggregated under the 'other' host. The series of a host that leaves the top N are deleted, so its counters restart from zero if it comes back, which rate() sees as a counter reset. 0 disables them.")
 }
 
 func addConsulFlags(getCmd *cobra.Command) {
@@ -179,4 +208,12 @@ func addAliasFlags(getCmd *cobra.Command) {
 	getCmd.PersistentFlags().Uint("ca", 8, "Max number of concurrent assets to fetch PER worker. E.g. if you have 100 workers and this setting at 8, Zeno could do up to 800 concurrent requests at any time.")
 	getCmd.PersistentFlags().MarkDeprecated("ca", "use --max-concurrent-assets")
 	getCmd.PersistentFlags().MarkHidden("ca")
+
+	getCmd.PersistentFlags().Bool("exclusion-file-live-reload", false, "If turned on, the exclusion file will be reloaded every X seconds.")
+	getCmd.PersistentFlags().MarkDeprecated("exclusion-file-live-reload", "use --scope-live-reload")
+	getCmd.PersistentFlags().MarkHidden("exclusion-file-live-reload")
+
+	getCmd.PersistentFlags().Duration("exclusion-file-live-reload-interval", time.Minute, "Interval at which to reload the exclusion file.")
+	getCmd.PersistentFlags().MarkDeprecated("exclusion-file-live-reload-interval", "use --scope-live-reload-interval")
+	getCmd.PersistentFlags().MarkHidden("exclusion-file-live-reload-interval")
 }
@@ -3,6 +3,7 @@ package cmd
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"strings"
@@ -11,14 +12,24 @@ import (
 	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/controler"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/ui"
+	"github.com/internetarchive/Zeno/v2/pkg/models"
 	"github.com/spf13/cobra"
 )
 
+// Maximum length of a line in a seed list
+const maxSeedLineSize = 16 * 1024 * 1024
+
 var getListCmd = &cobra.Command{
 	Use:   "list [FILE|URL...]",
 	Short: "Archive URLs from text file(s)",
 	Long: `Archive URLs from one or more text files or URLs.
-Each file should contain one URL per line.
+Each file should contain one URL per line, or one JSON seed per line to tune
+individual seeds. JSON seeds accept the url, via, hops, method, headers, body,
+max_hops (overrides --max-hops) and include_hosts (overrides --include-host)
+fields, the scope overrides apply to every URL crawled from the seed, e.g.:
+{"url": "https://example.com/search", "method": "POST", "headers": {"Content-Type": "application/x-www-form-urlencoded"}, "body": "q=zeno"}
+{"url": "https://example.com/", "via": "curator", "max_hops": 2, "include_hosts": ["example.com"]}
+In headless mode, the seeds with a method, headers or a body are fetched without the browser.
 Remote files (starting with http:// or https://) are supported.
 Empty lines and lines starting with # are ignored.`,
 	Args: cobra.MinimumNArgs(1),
@@ -32,21 +43,21 @@ Empty lines and lines starting with # are ignored.`,
 	RunE: func(_ *cobra.Command, args []string) error {
 		// Read URLs from all provided files
 		for _, file := range args {
-			var urls []string
+			var seeds []models.Seed
 			var err error
 
 			if strings.HasPrefix(file, "http://") || strings.HasPrefix(file, "https://") {
-				urls, err = readRemoteURLList(file)
+				seeds, err = readRemoteURLList(file)
 			} else {
-				urls, err = readLocalURLList(file)
+				seeds, err = readLocalURLList(file)
 			}
 
 			if err != nil {
 				return fmt.Errorf("error reading file %s: %w", file, err)
 			}
 
-			// Add URLs to config
-			config.Get().InputSeeds = append(config.Get().InputSeeds, urls...)
+			// Add seeds to config
+			config.Get().InputSeeds = append(config.Get().InputSeeds, seeds...)
 		}
 
 		if len(config.Get().InputSeeds) == 0 {
@@ -86,35 +97,26 @@ Empty lines and lines starting with # are ignored.`,
 	},
 }
 
-// readLocalURLList reads URLs from a local file
-func readLocalURLList(file string) (urls []string, err error) {
+// readLocalURLList reads seeds from a local file
+func readLocalURLList(file string) (seeds []models.Seed, err error) {
 	f, err := os.Open(file)
 	if err != nil {
-		return urls, err
+		return seeds, err
 	}
 	defer f.Close()
 
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-		urls = append(urls, line)
-	}
-
-	return urls, scanner.Err()
+	return readSeedList(f)
 }
 
-// readRemoteURLList reads URLs from a remote file (http/https)
-func readRemoteURLList(URL string) (urls []string, err error) {
+// readRemoteURLList reads seeds from a remote file (http/https)
+func readRemoteURLList(URL string) (seeds []models.Seed, err error) {
 	httpClient := &http.Client{
 		Timeout: time.Second * 30,
 	}
 
 	req, err := http.NewRequest(http.MethodGet, URL, nil)
 	if err != nil {
-		return urls, err
+		return seeds, err
 	}
 
 	// Set user agent, use default if not configured
@@ -126,23 +128,38 @@ func readRemoteURLList(URL string) (urls []string, err error) {
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		return urls, err
+		return seeds, err
 	}
 
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return urls, fmt.Errorf("failed to download URL list: %s", resp.Status)
+		return seeds, fmt.Errorf("failed to download URL list: %s", resp.Status)
 	}
 
-	// Read file line by line
-	scanner := bufio.NewScanner(resp.Body)
+	return readSeedList(resp.Body)
+}
+
+// readSeedList reads one seed per line, skipping empty lines and comments
+func readSeedList(r io.Reader) (seeds []models.Seed, err error) {
+	scanner := bufio.NewScanner(r)
+	// JSON seeds carry request bodies and can be much longer than a URL
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxSeedLineSize)
+
+	lineNumber := 0
 	for scanner.Scan() {
+		lineNumber++
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
-		urls = append(urls, line)
+
+		seed, err := models.ParseSeed(line)
+		if err != nil {
+			return seeds, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		seeds = append(seeds, seed)
 	}
-	return urls, scanner.Err()
+
+	return seeds, scanner.Err()
 }
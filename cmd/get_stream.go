@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/controler"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+var getStreamCmd = &cobra.Command{
+	Use:   "stream",
+	Short: "Start crawling seeds received as newline-delimited JSON from a socket or a named pipe.",
+	Long: `Start crawling seeds received as newline-delimited JSON from a socket or a named pipe.
+
+Each line received must be a JSON object like {"url": "https://example.com", "via": "anything", "hops": 0}.
+Zeno sends back a line for each seed finished ({"type": "finished", ...}), each outlink discovered
+({"type": "produced", ...}) and, on stop, each seed that was still being crawled ({"type": "reset", ...}).`,
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		if cfg == nil {
+			return fmt.Errorf("viper config is nil")
+		}
+
+		err := config.GenerateCrawlConfig()
+		if err != nil {
+			return err
+		}
+
+		cfg.UseStream = true
+
+		if cfg.PyroscopeAddress != "" {
+			err = startPyroscope()
+			if err != nil {
+				return err
+			}
+		}
+
+		if cfg.SentryDSN != "" {
+			err = startSentry()
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+	RunE: func(_ *cobra.Command, _ []string) error {
+		controler.Start()
+		if config.Get().TUI {
+			tui := ui.New()
+			err := tui.Start()
+			if err != nil {
+				return fmt.Errorf("error starting TUI: %w", err)
+			}
+		} else {
+			controler.WatchSignals()
+		}
+		return nil
+	},
+}
+
+func getStreamCmdFlags(getStreamCmd *cobra.Command) {
+	getStreamCmd.PersistentFlags().String("stream-address", "", "Address to read seeds from: tcp://host:port or unix:///path/to.sock (notifications are sent back on the same socket), or fifo:///path/to/fifo.")
+	getStreamCmd.PersistentFlags().String("stream-finish-fifo", "", "Named pipe to write notifications to when --stream-address is a FIFO. If not set, notifications are not sent.")
+
+	getStreamCmd.MarkPersistentFlagRequired("stream-address")
+}
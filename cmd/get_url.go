@@ -6,11 +6,12 @@ import (
 	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/controler"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/ui"
+	"github.com/internetarchive/Zeno/v2/pkg/models"
 	"github.com/spf13/cobra"
 )
 
 var getURLCmd = &cobra.Command{
-	Use:   "url [URL...]",
+	Use:   "url [URL|JSON seed...]",
 	Short: "Archive given URLs",
 	Args:  cobra.MinimumNArgs(1),
 	PreRunE: func(_ *cobra.Command, args []string) error {
@@ -25,8 +26,12 @@ var getURLCmd = &cobra.Command{
 		return nil
 	},
 	RunE: func(_ *cobra.Command, args []string) error {
-		for _, URL := range args {
-			config.Get().InputSeeds = append(config.Get().InputSeeds, URL)
+		for _, arg := range args {
+			seed, err := models.ParseSeed(arg)
+			if err != nil {
+				return fmt.Errorf("invalid seed %s: %w", arg, err)
+			}
+			config.Get().InputSeeds = append(config.Get().InputSeeds, seed)
 		}
 
 		err := config.GenerateCrawlConfig()
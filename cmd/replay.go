@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"syscall"
+	"time"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/replay"
+	"github.com/spf13/cobra"
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <job>",
+	Short: "Replay the WARC files of a job",
+	Long: `Index the WARC files of a job and serve the archived captures over HTTP, with URLs rewritten to stay in the archive.
+The job can be given by name (looked up in the jobs directory) or as a path to the job directory.`,
+	Args: cobra.ExactArgs(1),
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		if cfg == nil {
+			return fmt.Errorf("viper config is nil")
+		}
+		return nil
+	},
+	RunE: func(_ *cobra.Command, args []string) error {
+		jobPath := args[0]
+		if info, err := os.Stat(jobPath); err != nil || !info.IsDir() {
+			jobPath = path.Join("jobs", args[0])
+		}
+
+		// Replaying a job must not write logs into it
+		cfg.NoFileLogging = true
+		if err := log.Start(); err != nil {
+			return fmt.Errorf("error starting logger: %w", err)
+		}
+		defer log.Stop()
+
+		archive, err := replay.Load(path.Join(jobPath, "warcs"))
+		if err != nil {
+			return fmt.Errorf("error loading job %s: %w", jobPath, err)
+		}
+
+		server := &http.Server{
+			Addr:    cfg.ReplayAddress,
+			Handler: replay.NewServer(archive),
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			server.Shutdown(shutdownCtx)
+		}()
+
+		log.Info("replay server started", "address", "http://"+cfg.ReplayAddress, "job", jobPath, "captures", archive.Len())
+
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("error starting replay server: %w", err)
+		}
+
+		return nil
+	},
+}
+
+func replayCmdFlags(replayCmd *cobra.Command) {
+	replayCmd.Flags().String("replay-address", "localhost:8080", "Address to listen on for the replay server.")
+}
@@ -30,6 +30,8 @@ func Start() error {
 			http.DefaultServeMux.Handle("/metrics", stats.PrometheusHandler())
 		}
 
+		registerControlHandlers(http.DefaultServeMux)
+
 		server = &http.Server{
 			Addr:    ":" + strconv.Itoa(config.Get().APIPort),
 			Handler: http.DefaultServeMux, // includes registers pprof handlers
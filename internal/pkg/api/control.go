@@ -0,0 +1,299 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/ratelimiter"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/controler/pause"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/log/dumper"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/reactor"
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+)
+
+// Maximum size of a request body accepted by the control endpoints
+const maxRequestBodySize = 10 * 1024 * 1024
+
+// pauseMu serializes the pause and resume requests, a resume checked against a concurrent
+// one would wait forever for the subscribers that the other resume already unblocked
+var pauseMu sync.Mutex
+
+// registerControlHandlers registers the JSON endpoints used to steer a running crawl,
+// the ones changing its state are guarded by requireControl
+func registerControlHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/pause", getPauseHandler)
+	mux.HandleFunc("POST /api/pause", requireControl(pauseHandler))
+	mux.HandleFunc("POST /api/resume", requireControl(resumeHandler))
+	mux.HandleFunc("GET /api/seeds", listSeedsHandler)
+	mux.HandleFunc("POST /api/seeds", requireControl(insertSeedsHandler))
+	mux.HandleFunc("POST /api/dump", requireControl(dumpHandler))
+	mux.HandleFunc("GET /api/scope", getScopeHandler)
+	mux.HandleFunc("POST /api/scope/reload", requireControl(reloadScopeHandler))
+	mux.HandleFunc("GET /api/rate-limit", getRateLimitHandler)
+	mux.HandleFunc("POST /api/rate-limit/reload", requireControl(reloadRateLimitHandler))
+}
+
+// requireControl guards the endpoints changing the state of the crawl, the API listens on every
+// interface for the metrics to be scraped. With --api-token, requests need an "Authorization: Bearer <token>"
+// header, without it only the requests from a loopback address are accepted.
+func requireControl(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var token string
+		if config.Get() != nil {
+			token = config.Get().APIToken
+		}
+
+		if token != "" {
+			bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, http.StatusUnauthorized, errors.New("invalid or missing API token"))
+				return
+			}
+		} else if !isLoopback(r.RemoteAddr) {
+			writeError(w, http.StatusForbidden, errors.New("control endpoints only accept loopback clients without --api-token"))
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+// isLoopback returns true if the remote address of a request is a loopback address
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+type pauseResponse struct {
+	Paused  bool   `json:"paused"`
+	Message string `json:"message,omitempty"`
+}
+
+type pauseRequest struct {
+	Message string `json:"message"`
+}
+
+// SeedRequest is a seed to insert in the crawl with POST /api/seeds
+type SeedRequest struct {
+	URL  string `json:"url"`
+	Via  string `json:"via,omitempty"`
+	Hops int    `json:"hops"`
+}
+
+type insertedSeed struct {
+	ID    string `json:"id,omitempty"`
+	URL   string `json:"url"`
+	Error string `json:"error,omitempty"`
+}
+
+type insertSeedsResponse struct {
+	Inserted int            `json:"inserted"`
+	Seeds    []insertedSeed `json:"seeds"`
+}
+
+// SeedState describes a seed currently in the reactor state table
+type SeedState struct {
+	ID     string `json:"id"`
+	URL    string `json:"url"`
+	Via    string `json:"via,omitempty"`
+	Hops   int    `json:"hops"`
+	Status string `json:"status"`
+	Tree   string `json:"tree"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func getPauseHandler(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, pauseResponse{Paused: pause.IsPaused(), Message: pause.GetMessage()})
+}
+
+// pauseHandler pauses the crawl, the body can optionally contain {"message": "..."}
+func pauseHandler(w http.ResponseWriter, r *http.Request) {
+	var request pauseRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestBodySize)).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if request.Message == "" {
+		request.Message = "Paused from the API"
+	}
+
+	pauseMu.Lock()
+	defer pauseMu.Unlock()
+
+	pause.Pause(request.Message)
+
+	writeJSON(w, http.StatusOK, pauseResponse{Paused: pause.IsPaused(), Message: pause.GetMessage()})
+}
+
+func resumeHandler(w http.ResponseWriter, _ *http.Request) {
+	pauseMu.Lock()
+	defer pauseMu.Unlock()
+
+	// Resume waits for every subscriber to acknowledge, it would block forever if not paused
+	if !pause.IsPaused() {
+		writeError(w, http.StatusConflict, errors.New("crawl is not paused"))
+		return
+	}
+
+	pause.Resume()
+
+	writeJSON(w, http.StatusOK, pauseResponse{Paused: pause.IsPaused()})
+}
+
+// listSeedsHandler lists the seeds in the reactor state table along with their tree
+func listSeedsHandler(w http.ResponseWriter, _ *http.Request) {
+	items := reactor.GetStateTableItems()
+	seeds := make([]SeedState, 0, len(items))
+
+	for _, item := range items {
+		seed := SeedState{
+			ID:     item.GetID(),
+			Via:    item.GetSeedVia(),
+			Status: item.GetStatus().String(),
+			Tree:   item.DrawTreeWithStatus(),
+		}
+		if item.GetURL() != nil {
+			seed.URL = item.GetURL().String()
+			seed.Hops = item.GetURL().GetHops()
+		}
+		seeds = append(seeds, seed)
+	}
+
+	slices.SortFunc(seeds, func(a, b SeedState) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	writeJSON(w, http.StatusOK, seeds)
+}
+
+// insertSeedsHandler inserts the seeds in the reactor, the body is a JSON array of seeds.
+// It blocks while the reactor is full.
+func insertSeedsHandler(w http.ResponseWriter, r *http.Request) {
+	var requests []SeedRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestBodySize)).Decode(&requests); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	response := insertSeedsResponse{Seeds: make([]insertedSeed, 0, len(requests))}
+
+	for _, request := range requests {
+		parsedURL, err := models.NewURL(request.URL)
+		if err != nil {
+			response.Seeds = append(response.Seeds, insertedSeed{URL: request.URL, Error: err.Error()})
+			continue
+		}
+		parsedURL.SetHops(request.Hops)
+
+		item := models.NewItem(&parsedURL, request.Via)
+		item.SetSource(models.ItemSourceQueue)
+
+		err = reactor.ReceiveInsert(item)
+		if err != nil {
+			if err == reactor.ErrReactorNotInitialized || err == reactor.ErrReactorFrozen || err == reactor.ErrReactorShuttingDown {
+				writeError(w, http.StatusServiceUnavailable, err)
+				return
+			}
+			response.Seeds = append(response.Seeds, insertedSeed{URL: request.URL, Error: err.Error()})
+			continue
+		}
+
+		response.Inserted++
+		response.Seeds = append(response.Seeds, insertedSeed{ID: item.GetID(), URL: request.URL})
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// dumpHandler dumps the reactor state table to a file and returns its path
+func dumpHandler(w http.ResponseWriter, _ *http.Request) {
+	path, err := dumper.WriteDump()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"path": path})
+}
+
+func getScopeHandler(w http.ResponseWriter, _ *http.Request) {
+	if config.Get() == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("config not initialized"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, config.Get().GetScope())
+}
+
+// reloadScopeHandler reloads the scope from its files and returns the new scope,
+// the current scope is kept if the reload fails.
+func reloadScopeHandler(w http.ResponseWriter, _ *http.Request) {
+	if config.Get() == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("config not initialized"))
+		return
+	}
+
+	if err := config.Get().ReloadScope(); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, config.Get().GetScope())
+}
+
+type rateLimitResponse struct {
+	Rules []ratelimiter.Rule `json:"rules"`
+}
+
+func getRateLimitHandler(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, rateLimitResponse{Rules: archiver.GetRateLimitRules()})
+}
+
+// reloadRateLimitHandler reloads the per-host rate limit rules from --rate-limit-rules-file
+// and returns them, the current rules are kept if the reload fails.
+func reloadRateLimitHandler(w http.ResponseWriter, _ *http.Request) {
+	if config.Get() == nil || config.Get().RateLimitRulesFile == "" {
+		writeError(w, http.StatusConflict, errors.New("no rate limit rules file set"))
+		return
+	}
+
+	if err := archiver.ReloadRateLimitRules(); err != nil {
+		if errors.Is(err, archiver.ErrRateLimitDisabled) {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rateLimitResponse{Rules: archiver.GetRateLimitRules()})
+}
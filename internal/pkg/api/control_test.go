@@ -0,0 +1,297 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/controler/pause"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/reactor"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+)
+
+func doRequest(t *testing.T, mux *http.ServeMux, method, path, body string, response any) int {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.RemoteAddr = "127.0.0.1:1234"
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if response != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), response); err != nil {
+			t.Fatalf("invalid JSON response %q: %v", rec.Body.String(), err)
+		}
+	}
+
+	return rec.Code
+}
+
+func TestPauseResume(t *testing.T) {
+	stats.Init()
+
+	mux := http.NewServeMux()
+	registerControlHandlers(mux)
+
+	var state pauseResponse
+	if code := doRequest(t, mux, http.MethodPost, "/api/resume", "", nil); code != http.StatusConflict {
+		t.Fatalf("expected 409 when resuming a running crawl, got %d", code)
+	}
+
+	if code := doRequest(t, mux, http.MethodPost, "/api/pause", `{"message": "maintenance"}`, &state); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if !state.Paused || state.Message != "maintenance" {
+		t.Fatalf("unexpected pause state: %+v", state)
+	}
+
+	if code := doRequest(t, mux, http.MethodGet, "/api/pause", "", &state); code != http.StatusOK || !state.Paused {
+		t.Fatalf("expected the crawl to be paused, got %d %+v", code, state)
+	}
+
+	if code := doRequest(t, mux, http.MethodPost, "/api/resume", "", &state); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if state.Paused || pause.IsPaused() {
+		t.Fatalf("expected the crawl to be resumed, got %+v", state)
+	}
+
+	// Pausing without a body uses a default message
+	if code := doRequest(t, mux, http.MethodPost, "/api/pause", "", &state); code != http.StatusOK || state.Message == "" {
+		t.Fatalf("unexpected response: %d %+v", code, state)
+	}
+	pause.Resume()
+}
+
+func TestConcurrentResume(t *testing.T) {
+	stats.Init()
+
+	mux := http.NewServeMux()
+	registerControlHandlers(mux)
+
+	// A subscriber acknowledges the resume once, like the workers do, late enough
+	// for both requests to find the crawl paused without the handlers being serialized
+	chans := pause.Subscribe()
+	defer pause.Unsubscribe(chans)
+	go func() {
+		<-chans.PauseCh
+		time.Sleep(100 * time.Millisecond)
+		chans.ResumeCh <- struct{}{}
+	}()
+
+	if code := doRequest(t, mux, http.MethodPost, "/api/pause", "", nil); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	codes := make(chan int, 2)
+	for range 2 {
+		go func() {
+			codes <- doRequest(t, mux, http.MethodPost, "/api/resume", "", nil)
+		}()
+	}
+
+	got := map[int]int{}
+	for range 2 {
+		select {
+		case code := <-codes:
+			got[code]++
+		case <-time.After(5 * time.Second):
+			t.Fatal("concurrent resume requests didn't return")
+		}
+	}
+	if got[http.StatusOK] != 1 || got[http.StatusConflict] != 1 {
+		t.Errorf("expected one 200 and one 409, got %v", got)
+	}
+}
+
+func TestInsertAndListSeeds(t *testing.T) {
+	mux := http.NewServeMux()
+	registerControlHandlers(mux)
+
+	// Reactor not started yet
+	if code := doRequest(t, mux, http.MethodPost, "/api/seeds", `[{"url": "https://example.com"}]`, nil); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without reactor, got %d", code)
+	}
+
+	reactorOutput := make(chan *models.Item, 10)
+	if err := reactor.Start(10, reactorOutput); err != nil {
+		t.Fatalf("unable to start reactor: %v", err)
+	}
+	defer reactor.Stop()
+
+	if code := doRequest(t, mux, http.MethodPost, "/api/seeds", `not json`, nil); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid body, got %d", code)
+	}
+
+	var inserted insertSeedsResponse
+	code := doRequest(t, mux, http.MethodPost, "/api/seeds", `[
+		{"url": "https://example.com/a", "via": "operator", "hops": 1},
+		{"url": "not a url"},
+		{"url": "https://example.com/b"}
+	]`, &inserted)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if inserted.Inserted != 2 || len(inserted.Seeds) != 3 || inserted.Seeds[1].Error == "" {
+		t.Fatalf("unexpected response: %+v", inserted)
+	}
+
+	for range 2 {
+		<-reactorOutput
+	}
+
+	var seeds []SeedState
+	if code := doRequest(t, mux, http.MethodGet, "/api/seeds", "", &seeds); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if len(seeds) != 2 {
+		t.Fatalf("expected 2 seeds in the state table, got %d", len(seeds))
+	}
+
+	for _, seed := range seeds {
+		if seed.Status != models.ItemFresh.String() || !strings.Contains(seed.Tree, seed.ID) {
+			t.Errorf("unexpected seed state: %+v", seed)
+		}
+		if seed.URL == "https://example.com/a" && (seed.Hops != 1 || seed.Via != "operator") {
+			t.Errorf("unexpected seed state: %+v", seed)
+		}
+	}
+}
+
+func TestScopeReload(t *testing.T) {
+	mux := http.NewServeMux()
+	registerControlHandlers(mux)
+
+	scopeFile := filepath.Join(t.TempDir(), "scope.json")
+	if err := os.WriteFile(scopeFile, []byte(`{"exclude-host": ["example.net"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	previous := config.Get()
+	defer config.Set(previous)
+	config.Set(&config.Config{ExcludeHosts: []string{"archive.org"}, ScopeFile: []string{scopeFile}})
+
+	var scope config.Scope
+	if code := doRequest(t, mux, http.MethodGet, "/api/scope", "", &scope); code != http.StatusOK || len(scope.ExcludeHosts) != 0 {
+		t.Fatalf("expected an empty scope before loading, got %d %+v", code, scope)
+	}
+
+	if code := doRequest(t, mux, http.MethodPost, "/api/scope/reload", "", &scope); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if len(scope.ExcludeHosts) != 2 || scope.ExcludeHosts[1] != "example.net" {
+		t.Fatalf("unexpected scope: %+v", scope)
+	}
+
+	if err := os.WriteFile(scopeFile, []byte(`not json`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var response errorResponse
+	if code := doRequest(t, mux, http.MethodPost, "/api/scope/reload", "", &response); code != http.StatusUnprocessableEntity || response.Error == "" {
+		t.Fatalf("expected 422 for a broken scope file, got %d %+v", code, response)
+	}
+	if len(config.Get().GetScope().ExcludeHosts) != 2 {
+		t.Fatal("expected the scope to be kept after a failed reload")
+	}
+}
+
+func TestRateLimitReload(t *testing.T) {
+	mux := http.NewServeMux()
+	registerControlHandlers(mux)
+
+	previous := config.Get()
+	defer config.Set(previous)
+
+	var response errorResponse
+	config.Set(&config.Config{})
+	if code := doRequest(t, mux, http.MethodPost, "/api/rate-limit/reload", "", &response); code != http.StatusConflict || response.Error == "" {
+		t.Fatalf("expected 409 without rules file, got %d %+v", code, response)
+	}
+
+	// The archiver isn't running, so there is no rate limiter to reload
+	config.Set(&config.Config{RateLimitRulesFile: filepath.Join(t.TempDir(), "rules.json")})
+	if code := doRequest(t, mux, http.MethodPost, "/api/rate-limit/reload", "", &response); code != http.StatusConflict || response.Error == "" {
+		t.Fatalf("expected 409 without rate limiter, got %d %+v", code, response)
+	}
+}
+
+func TestDumpError(t *testing.T) {
+	mux := http.NewServeMux()
+	registerControlHandlers(mux)
+
+	previous := config.Get()
+	defer config.Set(previous)
+
+	var response errorResponse
+	config.Set(&config.Config{LogFileOutputDir: filepath.Join(t.TempDir(), "missing")})
+	if code := doRequest(t, mux, http.MethodPost, "/api/dump", "", &response); code != http.StatusInternalServerError || response.Error == "" {
+		t.Fatalf("expected 500 when the dump can't be written, got %d %+v", code, response)
+	}
+
+	var dump map[string]string
+	config.Set(&config.Config{LogFileOutputDir: t.TempDir()})
+	if code := doRequest(t, mux, http.MethodPost, "/api/dump", "", &dump); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if _, err := os.Stat(dump["path"]); err != nil {
+		t.Errorf("dump file not written: %v", err)
+	}
+}
+
+func TestRequireControl(t *testing.T) {
+	mux := http.NewServeMux()
+	registerControlHandlers(mux)
+
+	previous := config.Get()
+	defer config.Set(previous)
+
+	tests := []struct {
+		name          string
+		token         string
+		remoteAddr    string
+		authorization string
+		want          int
+	}{
+		{name: "loopback without token", remoteAddr: "127.0.0.1:1234", want: http.StatusConflict},
+		{name: "IPv6 loopback without token", remoteAddr: "[::1]:1234", want: http.StatusConflict},
+		{name: "remote without token", remoteAddr: "192.0.2.1:1234", want: http.StatusForbidden},
+		{name: "remote with token", token: "secret", remoteAddr: "192.0.2.1:1234", authorization: "Bearer secret", want: http.StatusConflict},
+		{name: "wrong token", token: "secret", remoteAddr: "192.0.2.1:1234", authorization: "Bearer guess", want: http.StatusUnauthorized},
+		{name: "missing token", token: "secret", remoteAddr: "127.0.0.1:1234", want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Set(&config.Config{APIToken: tt.token})
+
+			// Resuming a running crawl is refused with 409 once the request is let through
+			req := httptest.NewRequest(http.MethodPost, "/api/resume", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("POST /api/resume = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	// Reading the state of the crawl isn't guarded
+	req := httptest.NewRequest(http.MethodGet, "/api/pause", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET /api/pause = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/discard/discarder/akamai"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/discard/discarder/cloudflare"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/discard/discarder/contentlength"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/discard/discarder/rules"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/discard/discarder/warcdiscardstatus"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/discard/reasoncode"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
@@ -29,6 +30,9 @@ func (b *Builder) AddHook(hook warc.DiscardHook) *Builder {
 func (b *Builder) AddDefaultHooks() *Builder {
 	b.AddHook(cloudflare.ChallengePageHook)
 	b.AddHook(akamai.ChallengePageHook)
+	if rules.Loaded() {
+		b.AddHook(rules.Hook)
+	}
 	if len(config.Get().WARCDiscardStatus) > 0 {
 		b.AddHook(warcdiscardstatus.WARCDiscardStatusHook)
 	}
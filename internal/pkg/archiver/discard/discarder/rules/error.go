@@ -0,0 +1,8 @@
+package rules
+
+import "errors"
+
+var (
+	// ErrInvalidRule is returned when a rule of the discard rules file is invalid
+	ErrInvalidRule = errors.New("invalid discard rule")
+)
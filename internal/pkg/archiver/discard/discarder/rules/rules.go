@@ -0,0 +1,320 @@
+// Package rules discards responses matching the declarative rules of --discard-rules-file,
+// so that new bot walls and challenge pages can be handled without a new release.
+//
+// The hook runs both in the WARC writer, on the raw response, and in the archiver. The WARC
+// writer doesn't give the request to the hooks, so the rules with an url-regex only match on
+// the URL and are applied by the preprocessor, before the request is sent (see MatchURL).
+package rules
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// defaultBodyLimitKB is the size of the beginning of the body a body-regex is matched against
+	defaultBodyLimitKB = 32
+	// maxBodyLimitKB bounds the body held in memory while the rules are evaluated
+	maxBodyLimitKB = 1024
+)
+
+// Rule discards the responses matching all its conditions with its reason code, the
+// responses of a rule with retry set are retried like the built-in challenge pages
+type Rule struct {
+	Reason      string            `json:"reason"`
+	Retry       bool              `json:"retry"`
+	Status      []int             `json:"status"`
+	Headers     map[string]string `json:"headers"`       // Header name to regex one of its values must match, "" only requires the header
+	ContentType []string          `json:"content-type"`  // Media types, e.g. text/html, or type wildcards, e.g. text/*
+	URLRegex    string            `json:"url-regex"`     // Can't be combined with the other conditions nor retry
+	BodyRegex   string            `json:"body-regex"`    // Signature searched in the first body-limit-kb KB of the decoded body
+	BodyLimitKB int               `json:"body-limit-kb"` // Defaults to 32
+
+	headers   map[string]*regexp.Regexp
+	urlRegex  *regexp.Regexp
+	bodyRegex *regexp.Regexp
+}
+
+// rulesFile is the JSON document read from --discard-rules-file, the first rule matching a response applies
+type rulesFile struct {
+	Rules []Rule `json:"rules"`
+}
+
+type ruleSet struct {
+	rules     []Rule // Rules matching on the response
+	urlRules  []Rule // Rules only matching on the URL
+	bodyLimit int    // Largest body limit of the rules, in bytes
+	retryable map[string]struct{}
+}
+
+var current atomic.Pointer[ruleSet]
+
+// Load reads and compiles the rules of a discard rules file, it can be called again to reload them.
+// The rules in use are kept if the file is invalid.
+func Load(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	var rf rulesFile
+	if err := json.Unmarshal(data, &rf); err != nil {
+		return err
+	}
+
+	set, err := newRuleSet(rf.Rules)
+	if err != nil {
+		return err
+	}
+
+	current.Store(set)
+
+	return nil
+}
+
+// Loaded returns true if discard rules are loaded
+func Loaded() bool {
+	return current.Load() != nil
+}
+
+// IsRetryable returns true if the reason is the one of a rule asking for its responses to be retried
+func IsRetryable(reason string) bool {
+	set := current.Load()
+	if set == nil {
+		return false
+	}
+
+	_, ok := set.retryable[reason]
+	return ok
+}
+
+// MatchURL returns true and the reason code of the first rule matching on the URL, for the
+// URL to be discarded before its request is sent
+func MatchURL(u *url.URL) (bool, string) {
+	set := current.Load()
+	if set == nil || u == nil {
+		return false, ""
+	}
+
+	URL := u.String()
+	for i := range set.urlRules {
+		if set.urlRules[i].urlRegex.MatchString(URL) {
+			return true, set.urlRules[i].Reason
+		}
+	}
+
+	return false, ""
+}
+
+func newRuleSet(rules []Rule) (*ruleSet, error) {
+	set := &ruleSet{
+		retryable: make(map[string]struct{}),
+	}
+
+	reasons := make(map[string]struct{}, len(rules))
+	for i := range rules {
+		if err := rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+
+		if _, ok := reasons[rules[i].Reason]; ok {
+			return nil, fmt.Errorf("rule %d: %w: duplicate reason %q", i, ErrInvalidRule, rules[i].Reason)
+		}
+		reasons[rules[i].Reason] = struct{}{}
+
+		if rules[i].urlRegex != nil {
+			set.urlRules = append(set.urlRules, rules[i])
+			continue
+		}
+
+		set.rules = append(set.rules, rules[i])
+		if rules[i].Retry {
+			set.retryable[rules[i].Reason] = struct{}{}
+		}
+		if rules[i].bodyRegex != nil {
+			set.bodyLimit = max(set.bodyLimit, rules[i].BodyLimitKB*1024)
+		}
+	}
+
+	return set, nil
+}
+
+func (r *Rule) compile() (err error) {
+	if strings.TrimSpace(r.Reason) == "" {
+		return fmt.Errorf("%w: reason is required", ErrInvalidRule)
+	}
+
+	if len(r.Status) == 0 && len(r.Headers) == 0 && len(r.ContentType) == 0 && r.URLRegex == "" && r.BodyRegex == "" {
+		return fmt.Errorf("%w: %q has no condition", ErrInvalidRule, r.Reason)
+	}
+
+	r.headers = make(map[string]*regexp.Regexp, len(r.Headers))
+	for name, pattern := range r.Headers {
+		if r.headers[http.CanonicalHeaderKey(name)], err = regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("%w: %q header %s: %w", ErrInvalidRule, r.Reason, name, err)
+		}
+	}
+
+	for i, contentType := range r.ContentType {
+		r.ContentType[i] = strings.ToLower(strings.TrimSpace(contentType))
+	}
+
+	if r.URLRegex != "" {
+		// The WARC writer doesn't see the request, the URL is matched before sending it and a retry would match again
+		if len(r.Status) > 0 || len(r.Headers) > 0 || len(r.ContentType) > 0 || r.BodyRegex != "" || r.Retry {
+			return fmt.Errorf("%w: %q url-regex can't be combined with other conditions nor retry", ErrInvalidRule, r.Reason)
+		}
+		if r.urlRegex, err = regexp.Compile(r.URLRegex); err != nil {
+			return fmt.Errorf("%w: %q url-regex: %w", ErrInvalidRule, r.Reason, err)
+		}
+	}
+
+	if r.BodyRegex != "" {
+		if r.bodyRegex, err = regexp.Compile(r.BodyRegex); err != nil {
+			return fmt.Errorf("%w: %q body-regex: %w", ErrInvalidRule, r.Reason, err)
+		}
+		if r.BodyLimitKB <= 0 {
+			r.BodyLimitKB = defaultBodyLimitKB
+		}
+		r.BodyLimitKB = min(r.BodyLimitKB, maxBodyLimitKB)
+	}
+
+	return nil
+}
+
+// Hook discards the responses matching a rule, with the reason code of the first one,
+// the rules only matching on the URL are applied by MatchURL instead
+func Hook(resp *http.Response) (bool, string) {
+	set := current.Load()
+	if set == nil {
+		return false, ""
+	}
+
+	var (
+		body   []byte
+		peeked bool
+	)
+
+	for i := range set.rules {
+		rule := &set.rules[i]
+		if !rule.matchResponse(resp) {
+			continue
+		}
+
+		if rule.bodyRegex != nil {
+			// The body is read once for all the rules, then put back for the readers after the hooks
+			if !peeked {
+				body = peekBody(resp, set.bodyLimit)
+				peeked = true
+			}
+			if !rule.bodyRegex.Match(body[:min(len(body), rule.BodyLimitKB*1024)]) {
+				continue
+			}
+		}
+
+		return true, rule.Reason
+	}
+
+	return false, ""
+}
+
+// matchResponse returns true if the response matches the conditions of the rule, except its body-regex
+func (r *Rule) matchResponse(resp *http.Response) bool {
+	if len(r.Status) > 0 && !slices.Contains(r.Status, resp.StatusCode) {
+		return false
+	}
+
+	for name, regex := range r.headers {
+		values, ok := resp.Header[name]
+		if !ok || !slices.ContainsFunc(values, regex.MatchString) {
+			return false
+		}
+	}
+
+	if len(r.ContentType) > 0 {
+		mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if !slices.ContainsFunc(r.ContentType, func(contentType string) bool {
+			if prefix, ok := strings.CutSuffix(contentType, "/*"); ok {
+				return strings.HasPrefix(mediaType, prefix+"/")
+			}
+			return mediaType == contentType
+		}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// peekedBody is a body whose beginning was read by the hook and is read again first
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// peekBody reads up to limit bytes of the body and puts them back in front of it, the bytes
+// are decoded if the response is the raw one of the WARC writer, that has no request
+func peekBody(resp *http.Response, limit int) []byte {
+	if resp.Body == nil || resp.Body == http.NoBody || limit <= 0 {
+		return nil
+	}
+
+	peeked, _ := io.ReadAll(io.LimitReader(resp.Body, int64(limit)))
+	resp.Body = &peekedBody{
+		Reader: io.MultiReader(bytes.NewReader(peeked), resp.Body),
+		Closer: resp.Body,
+	}
+
+	if resp.Request != nil {
+		return peeked
+	}
+
+	return decode(peeked, resp.Header.Get("Content-Encoding"), limit)
+}
+
+// decode decodes as much of the beginning of an encoded body as possible, up to limit bytes
+func decode(encoded []byte, encoding string, limit int) []byte {
+	var (
+		reader io.Reader
+		err    error
+	)
+
+	switch strings.ToLower(encoding) {
+	case "", "identity":
+		return encoded
+	case "gzip":
+		reader, err = gzip.NewReader(bytes.NewReader(encoded))
+	case "deflate":
+		reader, err = zlib.NewReader(bytes.NewReader(encoded))
+	case "zstd":
+		var decoder *zstd.Decoder
+		decoder, err = zstd.NewReader(bytes.NewReader(encoded), zstd.WithDecoderConcurrency(1))
+		if err == nil {
+			defer decoder.Close()
+			reader = decoder
+		}
+	default:
+		return nil
+	}
+	if err != nil {
+		return nil
+	}
+
+	// The beginning of a stream decodes until the unexpected end of the data
+	decoded, _ := io.ReadAll(io.LimitReader(reader, int64(limit)))
+	return decoded
+}
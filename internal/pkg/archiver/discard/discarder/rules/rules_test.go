@@ -0,0 +1,301 @@
+package rules
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeRules(t *testing.T, content string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "discard-rules.json")
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr error
+	}{
+		{
+			name: "valid rules",
+			content: `{"rules": [
+				{"reason": "DataDome", "retry": true, "status": [403], "headers": {"x-datadome": ""}},
+				{"reason": "Imperva", "body-regex": "_Incapsula_Resource"}
+			]}`,
+		},
+		{
+			name:    "no reason",
+			content: `{"rules": [{"status": [403]}]}`,
+			wantErr: ErrInvalidRule,
+		},
+		{
+			name:    "no condition",
+			content: `{"rules": [{"reason": "everything"}]}`,
+			wantErr: ErrInvalidRule,
+		},
+		{
+			name:    "duplicate reason",
+			content: `{"rules": [{"reason": "wall", "status": [403]}, {"reason": "wall", "status": [401]}]}`,
+			wantErr: ErrInvalidRule,
+		},
+		{
+			name:    "url-regex with response conditions",
+			content: `{"rules": [{"reason": "wall", "url-regex": "/login", "status": [200]}]}`,
+			wantErr: ErrInvalidRule,
+		},
+		{
+			name:    "url-regex with retry",
+			content: `{"rules": [{"reason": "wall", "url-regex": "/login", "retry": true}]}`,
+			wantErr: ErrInvalidRule,
+		},
+		{
+			name:    "invalid header regex",
+			content: `{"rules": [{"reason": "wall", "headers": {"Server": "("}}]}`,
+			wantErr: ErrInvalidRule,
+		},
+		{
+			name:    "invalid body regex",
+			content: `{"rules": [{"reason": "wall", "body-regex": "["}]}`,
+			wantErr: ErrInvalidRule,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { current.Store(nil) })
+
+			err := Load(writeRules(t, tt.content))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+				}
+				if Loaded() {
+					t.Error("invalid rules were loaded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !Loaded() {
+				t.Error("rules weren't loaded")
+			}
+		})
+	}
+}
+
+func gzipped(t *testing.T, data string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestHook(t *testing.T) {
+	t.Cleanup(func() { current.Store(nil) })
+
+	if err := Load(writeRules(t, `{"rules": [
+		{"reason": "DataDome", "retry": true, "status": [403], "headers": {"X-DataDome": ""}, "body-regex": "captcha-delivery\\.com"},
+		{"reason": "PerimeterX", "retry": true, "headers": {"Server": "(?i)perimeterx"}},
+		{"reason": "Imperva", "content-type": ["text/*"], "body-regex": "_Incapsula_Resource", "body-limit-kb": 1},
+		{"reason": "Login wall", "url-regex": "/login\\?next="}
+	]}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	padding := strings.Repeat(" ", 2048)
+
+	tests := []struct {
+		name       string
+		status     int
+		header     http.Header
+		body       []byte
+		url        string
+		wantReason string
+	}{
+		{
+			name:       "status, header and body",
+			status:     403,
+			header:     http.Header{"X-Datadome": {"protected"}},
+			body:       []byte(`<script src="https://ct.captcha-delivery.com/c.js"></script>`),
+			wantReason: "DataDome",
+		},
+		{
+			name:   "body signature missing",
+			status: 403,
+			header: http.Header{"X-Datadome": {"protected"}},
+			body:   []byte(`<html>Forbidden</html>`),
+		},
+		{
+			name:   "status not matching",
+			status: 200,
+			header: http.Header{"X-Datadome": {"protected"}},
+			body:   []byte(`captcha-delivery.com`),
+		},
+		{
+			name:       "header regex",
+			status:     200,
+			header:     http.Header{"Server": {"PerimeterX"}},
+			wantReason: "PerimeterX",
+		},
+		{
+			name:       "content type wildcard",
+			status:     200,
+			header:     http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+			body:       []byte(`<script src="/_Incapsula_Resource?SWJIYLWA=1"></script>`),
+			wantReason: "Imperva",
+		},
+		{
+			name:   "content type not matching",
+			status: 200,
+			header: http.Header{"Content-Type": {"application/json"}},
+			body:   []byte(`_Incapsula_Resource`),
+		},
+		{
+			name:   "signature after the body limit",
+			status: 200,
+			header: http.Header{"Content-Type": {"text/html"}},
+			body:   []byte(padding + `_Incapsula_Resource`),
+		},
+		{
+			name:       "gzip encoded body",
+			status:     200,
+			header:     http.Header{"Content-Type": {"text/html"}, "Content-Encoding": {"gzip"}},
+			body:       gzipped(t, `<script src="/_Incapsula_Resource"></script>`),
+			wantReason: "Imperva",
+		},
+		{
+			name:   "url rules don't match responses",
+			status: 200,
+			url:    "https://example.com/login?next=/article",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: tt.status,
+				Header:     tt.header,
+				Body:       io.NopCloser(bytes.NewReader(tt.body)),
+			}
+			if resp.Header == nil {
+				resp.Header = http.Header{}
+			}
+			if tt.url != "" {
+				u, err := url.Parse(tt.url)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Request = &http.Request{URL: u}
+			}
+
+			discarded, reason := Hook(resp)
+			if discarded != (tt.wantReason != "") || reason != tt.wantReason {
+				t.Errorf("Hook() = %v, %q, want %q", discarded, reason, tt.wantReason)
+			}
+
+			// The readers after the hook still get the whole body
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(body, tt.body) {
+				t.Errorf("body not restored after the hook, got %d bytes, want %d", len(body), len(tt.body))
+			}
+		})
+	}
+
+	if !IsRetryable("DataDome") || IsRetryable("Imperva") {
+		t.Error("IsRetryable() doesn't follow the retry of the rules")
+	}
+}
+
+func TestMatchURL(t *testing.T) {
+	t.Cleanup(func() { current.Store(nil) })
+
+	if err := Load(writeRules(t, `{"rules": [
+		{"reason": "DataDome", "status": [403]},
+		{"reason": "Login wall", "url-regex": "/login\\?next="}
+	]}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		raw        string
+		wantReason string
+	}{
+		{"https://example.com/login?next=/article", "Login wall"},
+		{"https://example.com/article", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			u, err := url.Parse(tt.raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			discarded, reason := MatchURL(u)
+			if discarded != (tt.wantReason != "") || reason != tt.wantReason {
+				t.Errorf("MatchURL() = %v, %q, want %q", discarded, reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	t.Cleanup(func() { current.Store(nil) })
+
+	file := writeRules(t, `{"rules": [{"reason": "old", "status": [403]}]}`)
+	if err := Load(file); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := &http.Response{StatusCode: 403, Header: http.Header{}, Body: http.NoBody}
+	if _, reason := Hook(resp); reason != "old" {
+		t.Fatalf("Hook() reason = %q, want old", reason)
+	}
+
+	if err := os.WriteFile(file, []byte(`{"rules": [{"reason": "new", "status": [403]}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(file); err != nil {
+		t.Fatal(err)
+	}
+	if _, reason := Hook(resp); reason != "new" {
+		t.Errorf("Hook() reason after reload = %q, want new", reason)
+	}
+
+	// An invalid file keeps the rules in use
+	if err := os.WriteFile(file, []byte(`{"rules": [{"reason": "broken"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(file); !errors.Is(err, ErrInvalidRule) {
+		t.Fatalf("Load() error = %v, want %v", err, ErrInvalidRule)
+	}
+	if _, reason := Hook(resp); reason != "new" {
+		t.Errorf("Hook() reason after invalid reload = %q, want new", reason)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"slices"
 
 	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/discard/discarder/cloudflare"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/discard/discarder/rules"
 )
 
 var HookNotSet = "Hook not set"
@@ -17,3 +18,9 @@ func IsChallengePage(reason string) bool {
 	}
 	return slices.Contains(reasons, reason)
 }
+
+// IsRetryable checks if the URL of a discarded response should be retried,
+// either because it is a challenge page or because its discard rule asks for it.
+func IsRetryable(reason string) bool {
+	return IsChallengePage(reason) || rules.IsRetryable(reason)
+}
@@ -5,4 +5,8 @@ import "errors"
 var (
 	// ErrArchiverAlreadyInitialized is the error returned when the preprocess is already initialized
 	ErrArchiverAlreadyInitialized = errors.New("archiver already initialized")
+	// ErrArchiverNotRunning is the error returned when writing a record while the WARC writers are not running
+	ErrArchiverNotRunning = errors.New("archiver not running")
+	// ErrRateLimitDisabled is the error returned when reloading the rate limit rules while rate limiting is disabled
+	ErrRateLimitDisabled = errors.New("rate limiting is disabled")
 )
@@ -10,13 +10,20 @@ import (
 
 	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/connutil"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/discard/reasoncode"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/quarantine"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/ratelimiter"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/recordid"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/cookies"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/crawllog"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/postprocessor/domainscrawl"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/tracing"
 	"github.com/internetarchive/Zeno/v2/pkg/models"
 	warc "github.com/internetarchive/gowarc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 func ArchiveItem(item *models.Item, wg *sync.WaitGroup, guard chan struct{}, globalBucketManager *ratelimiter.BucketManager, client *warc.CustomHTTPClient) {
@@ -39,8 +46,32 @@ func ArchiveItem(item *models.Item, wg *sync.WaitGroup, guard chan struct{}, glo
 		feedbackChan    chan struct{}
 		wrappedConnChan chan *warc.CustomConnection
 		conn            *warc.CustomConnection
+		retries         int
+		body            *countingBody
+		resumable       *resumableBody
 	)
 
+	// Trace the fetch as a child of the archiver stage of the seed
+	ctx, span := tracing.StartItemSpan(item, "archiver.fetch")
+	defer func() {
+		span.SetAttributes(attribute.Int("zeno.fetch.retries", retries))
+		if resp != nil {
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		}
+		if err != nil {
+			tracing.SetError(span, err)
+		} else if item.GetStatus() == models.ItemFailed {
+			span.SetStatus(codes.Error, "item failed")
+		}
+		span.End()
+	}()
+
+	// Log the outcome of the fetch to the crawl log, whatever it is
+	if crawllog.Enabled() {
+		entry := crawllog.NewEntry(item)
+		defer func() { writeCrawlLog(entry, item, resp, err, retries, body) }()
+	}
+
 	// Execute the request
 	req := item.GetURL().GetRequest()
 	if req == nil {
@@ -50,6 +81,7 @@ func ArchiveItem(item *models.Item, wg *sync.WaitGroup, guard chan struct{}, glo
 	// Wait for the rate limiter if enabled
 	if globalBucketManager != nil {
 		elapsed := globalBucketManager.Wait(req.URL.Host)
+		defer globalBucketManager.Release(req.URL.Host)
 		logger.Debug("got token from bucket", "elapsed", elapsed)
 	}
 
@@ -59,6 +91,16 @@ func ArchiveItem(item *models.Item, wg *sync.WaitGroup, guard chan struct{}, glo
 	for retry := 0; retry <= config.Get().MaxRetry; retry++ {
 		// This is unused unless there is an error
 		retrySleepTime := time.Second * time.Duration(retry*2)
+		retries = retry
+
+		// The body of the previous attempt was consumed, rewind it
+		if retry > 0 && req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				logger.Error("unable to rewind request body", "err", err.Error())
+				item.SetStatus(models.ItemFailed)
+				return
+			}
+		}
 
 		// Get and measure request time
 		getStartTime := time.Now()
@@ -74,6 +116,7 @@ func ArchiveItem(item *models.Item, wg *sync.WaitGroup, guard chan struct{}, glo
 
 		resp, err = client.Do(req)
 		if err != nil {
+			stats.HostResponseObserve(req.URL.Host, 0, time.Since(getStartTime))
 			if retry < config.Get().MaxRetry {
 				logger.Warn("retrying request", "err", err.Error(), "retry", retry, "sleep_time", retrySleepTime)
 				time.Sleep(retrySleepTime)
@@ -86,6 +129,12 @@ func ArchiveItem(item *models.Item, wg *sync.WaitGroup, guard chan struct{}, glo
 			return
 		}
 		conn = <-wrappedConnChan
+		stats.HostResponseObserve(req.URL.Host, resp.StatusCode, time.Since(getStartTime))
+
+		// Absorb the Set-Cookie headers, even from responses that will be retried or discarded
+		if jar := cookies.Get(); jar != nil {
+			jar.SetCookies(req.URL, resp.Cookies())
+		}
 
 		discarded := false
 		discardReason := ""
@@ -97,6 +146,7 @@ func ArchiveItem(item *models.Item, wg *sync.WaitGroup, guard chan struct{}, glo
 		isBadStatusCode := resp.StatusCode >= 500 || slices.Contains([]int{408, 425, 429}, resp.StatusCode)
 
 		if discarded {
+			stats.DiscardedResponsesIncr(discardReason)
 			resp.Body.Close()              // First, close the body, to stop downloading data anymore.
 			io.Copy(io.Discard, resp.Body) // Then, consume the buffer.
 		} else if isBadStatusCode {
@@ -110,11 +160,22 @@ func ArchiveItem(item *models.Item, wg *sync.WaitGroup, guard chan struct{}, glo
 
 		// Retries on:
 		// 	- 5XX, 408, 425 and 429
-		// 	- Discarded challenge pages (Cloudflare, Akamai, etc.)
-		isDiscardedChallengePage := discarded && reasoncode.IsChallengePage(discardReason)
+		// 	- Discarded challenge pages (Cloudflare, Akamai, etc.) and responses of discard rules with retry set
+		isDiscardedChallengePage := discarded && reasoncode.IsRetryable(discardReason)
 		if isBadStatusCode || isDiscardedChallengePage {
+			// The server can tell how long to wait with Retry-After or RateLimit-* headers, it replaces our guess
+			delay, hasDelay := serverDelay(resp)
+			if hasDelay {
+				retrySleepTime = delay
+			}
+
 			if globalBucketManager != nil {
-				globalBucketManager.AdjustOnFailure(req.URL.Host, resp.StatusCode)
+				if globalBucketManager.AdjustOnFailure(req.URL.Host, resp.StatusCode) {
+					stats.RateLimiterPenaltiesIncr(req.URL.Host)
+				}
+				if hasDelay {
+					globalBucketManager.PenalizeFor(req.URL.Host, delay)
+				}
 			}
 
 			retryReason := "bad response code"
@@ -128,6 +189,9 @@ func ArchiveItem(item *models.Item, wg *sync.WaitGroup, guard chan struct{}, glo
 				continue
 			} else {
 				logger.Error("retries exceeded", "reason", retryReason, "status_code", resp.StatusCode, "url", req.URL)
+				if isDiscardedChallengePage {
+					item.SetDiscardReason(discardReason)
+				}
 				item.SetStatus(models.ItemFailed)
 				return
 			}
@@ -136,6 +200,7 @@ func ArchiveItem(item *models.Item, wg *sync.WaitGroup, guard chan struct{}, glo
 		// Discarded
 		if discarded {
 			logger.Warn("response was blocked by DiscardHook", "reason", discardReason, "status_code", resp.StatusCode)
+			item.SetDiscardReason(discardReason)
 			item.SetStatus(models.ItemFailed)
 			return
 		}
@@ -143,14 +208,28 @@ func ArchiveItem(item *models.Item, wg *sync.WaitGroup, guard chan struct{}, glo
 		// OK
 		if globalBucketManager != nil {
 			globalBucketManager.OnSuccess(req.URL.Host)
+
+			// A successful response can still announce that the quota of the host is exhausted
+			if delay, ok := serverDelay(resp); ok {
+				globalBucketManager.PenalizeFor(req.URL.Host, delay)
+				logger.Debug("host asked to slow down", "delay", delay)
+			}
 		}
 
 		stats.MeanHTTPRespTimeAdd(time.Since(getStartTime))
 		break
 	}
 
+	// Large responses served with Accept-Ranges: bytes are resumed if their transfer is interrupted
+	if isResumable(resp, int64(config.Get().RangeResumeMinSizeMiB)*1024*1024) {
+		resumable = newResumableBody(resp, client, logger)
+		defer resumable.release()
+		resp.Body = resumable
+	}
+
+	body = newCountingBody(resp.Body, item)
 	resp.Body = &connutil.BodyWithConn{ // Wrap the response body to hold the connection
-		ReadCloser: resp.Body,
+		ReadCloser: body,
 		Conn:       conn,
 	}
 
@@ -159,7 +238,7 @@ func ArchiveItem(item *models.Item, wg *sync.WaitGroup, guard chan struct{}, glo
 
 	// Process the body and measure the time
 	processStartTime := time.Now()
-	err = ProcessBody(item.GetURL(), config.Get().DisableAssetsCapture, domainscrawl.Enabled(), config.Get().MaxHops, config.Get().WARCTempDir, logger)
+	err = ProcessBody(item.GetURL(), config.Get().DisableAssetsCapture, domainscrawl.Enabled(), item.GetSeedScope().GetMaxHops(config.Get().MaxHops), config.Get().WARCTempDir, logger)
 	if err != nil {
 		logger.Error("unable to process body", "err", err.Error())
 		item.SetStatus(models.ItemFailed)
@@ -167,17 +246,86 @@ func ArchiveItem(item *models.Item, wg *sync.WaitGroup, guard chan struct{}, glo
 	}
 
 	stats.MeanProcessBodyTimeAdd(time.Since(processStartTime))
+
+	// Soft-404 and login pages are still archived, but tagged and kept from leading the crawl anywhere
+	if quarantine.Enabled() {
+		if reason := quarantine.Classify(item.GetURL(), body.size); reason != "" {
+			item.SetQuarantineReason(reason)
+			stats.QuarantinedResponsesIncr(reason)
+			logger.Warn("response quarantined", "reason", reason, "status_code", resp.StatusCode)
+		}
+	}
 	stats.HTTPReturnCodesIncr(strconv.Itoa(resp.StatusCode))
+	stats.HostResponseBytesObserve(req.URL.Host, body.size)
+
+	// The record of the interrupted transfer was dropped, the reassembled response replaces it
+	if resumable != nil && resumable.Resumed() {
+		feedbackChan, err = resumable.writeRecord()
+		if err != nil {
+			logger.Error("unable to write reassembled response", "err", err.Error())
+			item.SetStatus(models.ItemFailed)
+			return
+		}
+		retries += len(resumable.resumes)
+		logger.Info("transfer resumed", "resumes", len(resumable.resumes), "size", body.size)
+	}
 
 	// If WARC writing is asynchronous, we don't need to wait for the feedback channel
 	if !config.Get().WARCWriteAsync {
 		feedbackTime := time.Now()
 		// Waiting for WARC writing to finish
+		_, feedbackSpan := tracing.StartSpan(ctx, "archiver.warc_feedback")
 		<-feedbackChan
+		feedbackSpan.End()
 		stats.MeanWaitOnFeedbackTimeAdd(time.Since(feedbackTime))
+
+		// The metadata record of the item points to its response record
+		item.SetRecordID(recordid.Take(feedbackChan))
 	}
 
 	logger.Info("url archived", "status", resp.StatusCode)
 
 	item.SetStatus(models.ItemArchived)
 }
+
+// writeCrawlLog completes the crawl log entry of the item with the outcome of its fetch and writes it
+func writeCrawlLog(entry *crawllog.Entry, item *models.Item, resp *http.Response, err error, retries int, body *countingBody) {
+	entry.End()
+	entry.Retries = retries
+	entry.DiscardReason = item.GetDiscardReason()
+
+	if resp == nil {
+		entry.Status = crawllog.StatusFromError(err)
+		crawllog.Write(entry)
+		return
+	}
+
+	entry.Status = resp.StatusCode
+	entry.MIME = crawllog.MIME(resp.Header.Get("Content-Type"))
+	if entry.MIME == "" && item.GetURL().GetMIMEType() != nil {
+		entry.MIME = crawllog.MIME(item.GetURL().GetMIMEType().String())
+	}
+
+	if body != nil {
+		entry.Size = body.size
+		if body.digester != nil && err == nil {
+			entry.Digest = body.digester.Digest()
+		}
+	}
+
+	crawllog.Write(entry)
+}
+
+// serverDelay returns the delay the response asks to wait before the next request to its host, capped by --max-retry-after
+func serverDelay(resp *http.Response) (time.Duration, bool) {
+	delay, ok := ratelimiter.ServerDelay(resp.Header, time.Now())
+	if !ok {
+		return 0, false
+	}
+
+	if config.Get().MaxRetryAfter > 0 {
+		delay = min(delay, config.Get().MaxRetryAfter)
+	}
+
+	return delay, true
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/gabriel-vasile/mimetype"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/connutil"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/crawllog"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/utils"
 	"github.com/internetarchive/Zeno/v2/pkg/models"
@@ -15,6 +16,36 @@ import (
 	"github.com/internetarchive/gowarc/pkg/spooledtempfile"
 )
 
+// countingBody counts the bytes read from a response body toward the size budget of the item's seed,
+// and digests them for the crawl log if it is enabled
+type countingBody struct {
+	io.ReadCloser
+	item     *models.Item
+	size     int64
+	digester *crawllog.Digester
+}
+
+func newCountingBody(body io.ReadCloser, item *models.Item) *countingBody {
+	b := &countingBody{
+		ReadCloser: body,
+		item:       item,
+	}
+	if crawllog.Enabled() {
+		b.digester = crawllog.NewDigester()
+	}
+	return b
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.item.AddBudgetBytes(int64(n))
+	b.size += int64(n)
+	if b.digester != nil {
+		b.digester.Write(p[:n])
+	}
+	return n, err
+}
+
 // mimeDetectBufPool is a pool of bytes.Buffer used for MIME type detection to avoid allocating a new buffer for each request.
 var mimeDetectBufPool = sync.Pool{
 	New: func() any {
@@ -67,6 +98,7 @@ func processBody(u *models.URL, disableAssetsCapture, domainsCrawl bool, maxHops
 	// Check if the MIME type requires post-processing
 	if (u.GetMIMEType().Parent() != nil && utils.IsMIMETypeInHierarchy(u.GetMIMEType().Parent(), "text/plain")) ||
 		u.GetMIMEType().Is("application/pdf") ||
+		u.GetMIMEType().Is("application/vnd.apple.mpegurl") ||
 		strings.Contains(u.GetMIMEType().String(), "text/") {
 
 		// Create a temp file with a 8MB memory buffer
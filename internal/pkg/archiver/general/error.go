@@ -0,0 +1,10 @@
+package general
+
+import "errors"
+
+var (
+	// ErrResumeFailed is the error returned when an interrupted transfer can't be resumed with a Range request
+	ErrResumeFailed = errors.New("unable to resume transfer")
+	// ErrInvalidContentRange is the error returned when the Content-Range header of a response can't be parsed
+	ErrInvalidContentRange = errors.New("invalid Content-Range header")
+)
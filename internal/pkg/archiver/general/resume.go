@@ -0,0 +1,308 @@
+package general
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/recordid"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
+	warc "github.com/internetarchive/gowarc"
+	"github.com/internetarchive/gowarc/pkg/spooledtempfile"
+)
+
+// resumableBody reads the body of a response served with Accept-Ranges: bytes and resumes the
+// transfer with Range requests from the last byte received when it is interrupted.
+// The WARC writer drops the record of an interrupted transfer, so the body is spooled as it
+// is read and written to the WARC as a reassembled response once complete.
+type resumableBody struct {
+	body      io.ReadCloser // Body being read, of the original response or of the last Range request
+	resp      *http.Response
+	client    *warc.CustomHTTPClient
+	spool     spooledtempfile.ReadWriteSeekCloser
+	validator string // Strong ETag or Last-Modified of the original response, sent as If-Range
+	received  int64
+	total     int64
+	resumes   []int64       // Offsets the transfer was resumed from
+	feedback  chan struct{} // Feedback channel of the last Range request
+	logger    *log.FieldedLogger
+}
+
+// isResumable returns true if the transfer of the response can be resumed with Range requests
+func isResumable(resp *http.Response, minSize int64) bool {
+	if minSize <= 0 || resp.StatusCode != http.StatusOK || resp.ContentLength < minSize {
+		return false
+	}
+
+	if resp.Request != nil && resp.Request.Method != "" && resp.Request.Method != http.MethodGet {
+		return false
+	}
+
+	// Ranges apply to the encoded body, not to the decompressed one that is read
+	if resp.Uncompressed || (resp.Header.Get("Content-Encoding") != "" && !strings.EqualFold(resp.Header.Get("Content-Encoding"), "identity")) {
+		return false
+	}
+
+	return strings.EqualFold(strings.TrimSpace(resp.Header.Get("Accept-Ranges")), "bytes")
+}
+
+// rangeValidator returns the value of the If-Range header of the Range requests, a weak ETag can't be used
+func rangeValidator(header http.Header) string {
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return header.Get("Last-Modified")
+}
+
+// parseContentRange parses a Content-Range header of the form "bytes first-last/complete"
+func parseContentRange(value string) (first, last, complete int64, err error) {
+	rangeSpec, ok := strings.CutPrefix(value, "bytes ")
+	if !ok {
+		return 0, 0, 0, fmt.Errorf("%w: %q", ErrInvalidContentRange, value)
+	}
+
+	byteRange, completeLength, ok := strings.Cut(rangeSpec, "/")
+	if !ok {
+		return 0, 0, 0, fmt.Errorf("%w: %q", ErrInvalidContentRange, value)
+	}
+
+	firstPos, lastPos, ok := strings.Cut(byteRange, "-")
+	if !ok {
+		return 0, 0, 0, fmt.Errorf("%w: %q", ErrInvalidContentRange, value)
+	}
+
+	if first, err = strconv.ParseInt(firstPos, 10, 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("%w: %q", ErrInvalidContentRange, value)
+	}
+	if last, err = strconv.ParseInt(lastPos, 10, 64); err != nil || last < first {
+		return 0, 0, 0, fmt.Errorf("%w: %q", ErrInvalidContentRange, value)
+	}
+
+	// The complete length is unknown
+	if completeLength == "*" {
+		return first, last, -1, nil
+	}
+	if complete, err = strconv.ParseInt(completeLength, 10, 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("%w: %q", ErrInvalidContentRange, value)
+	}
+
+	return first, last, complete, nil
+}
+
+func newResumableBody(resp *http.Response, client *warc.CustomHTTPClient, logger *log.FieldedLogger) *resumableBody {
+	return &resumableBody{
+		body:      resp.Body,
+		resp:      resp,
+		client:    client,
+		spool:     spooledtempfile.NewSpooledTempFile("zeno", config.Get().WARCTempDir, 8000000, false, -1),
+		validator: rangeValidator(resp.Header),
+		total:     resp.ContentLength,
+		logger:    logger,
+	}
+}
+
+func (b *resumableBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if n > 0 {
+		if _, writeErr := b.spool.Write(p[:n]); writeErr != nil {
+			return n, writeErr
+		}
+		b.received += int64(n)
+	}
+
+	if err == nil || (err == io.EOF && b.received == b.total) {
+		return n, err
+	}
+
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	if len(b.resumes) >= config.Get().MaxRetry {
+		return n, err
+	}
+
+	b.logger.Warn("transfer interrupted, resuming", "err", err.Error(), "received", b.received, "total", b.total, "resume", len(b.resumes)+1)
+	if resumeErr := b.resume(); resumeErr != nil {
+		return n, errors.Join(err, resumeErr)
+	}
+
+	return n, nil
+}
+
+// resume sends a Range request for the bytes that weren't received yet
+func (b *resumableBody) resume() error {
+	b.body.Close()
+	time.Sleep(time.Second * time.Duration(len(b.resumes)*2))
+	b.resumes = append(b.resumes, b.received)
+
+	req := b.resp.Request.Clone(b.resp.Request.Context())
+	req.Header.Set("Range", "bytes="+strconv.FormatInt(b.received, 10)+"-")
+	if b.validator != "" {
+		req.Header.Set("If-Range", b.validator)
+	}
+
+	// The request context holds the channels of the original request, the dialer of the WARC writer
+	// sends the wrapped connection of each request on its own channel
+	req = req.WithContext(warc.WithWrappedConnection(req.Context(), make(chan *warc.CustomConnection, 1)))
+
+	// The record of the Range request is written if the rest of the body is received in one go
+	if !config.Get().WARCWriteAsync {
+		b.feedback = make(chan struct{}, 1)
+		req = req.WithContext(warc.WithFeedbackChannel(req.Context(), b.feedback))
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	b.body = resp.Body
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("%w: status code %d", ErrResumeFailed, resp.StatusCode)
+	}
+
+	first, _, complete, err := parseContentRange(resp.Header.Get("Content-Range"))
+	if err != nil {
+		return err
+	}
+	if first != b.received || (complete != -1 && complete != b.total) {
+		return fmt.Errorf("%w: got range %q for %d-%d", ErrResumeFailed, resp.Header.Get("Content-Range"), b.received, b.total-1)
+	}
+
+	return nil
+}
+
+func (b *resumableBody) Close() error {
+	return b.body.Close()
+}
+
+// Resumed returns true if the transfer was interrupted and resumed
+func (b *resumableBody) Resumed() bool {
+	return len(b.resumes) > 0
+}
+
+// writeRecord writes the reassembled response to the WARC, with the request record it is concurrent to
+// and a metadata record marking the record of the last Range request as a fragment of it.
+// It returns the channel that gets notified once they are written, nil if WARC writing is asynchronous.
+func (b *resumableBody) writeRecord() (chan struct{}, error) {
+	var (
+		targetURI  = b.resp.Request.URL.String()
+		requestID  = "<urn:uuid:" + uuid.NewString() + ">"
+		responseID = "<urn:uuid:" + uuid.NewString() + ">"
+		records    []*warc.Record
+	)
+
+	closeRecords := func() {
+		for _, record := range records {
+			record.Content.Close()
+		}
+	}
+
+	requestRecord := warc.NewRecord(b.client.TempDir, b.client.FullOnDisk)
+	records = append(records, requestRecord)
+	requestRecord.Header.Set("WARC-Type", "request")
+	requestRecord.Header.Set("WARC-Record-ID", requestID)
+	requestRecord.Header.Set("WARC-Concurrent-To", responseID)
+	requestRecord.Header.Set("WARC-Target-URI", targetURI)
+	requestRecord.Header.Set("Content-Type", "application/http; msgtype=request")
+
+	// The request of the original response, without the context and body it was sent with
+	req := b.resp.Request.Clone(context.Background())
+	req.Body = nil
+	req.ContentLength = 0
+	if err := req.Write(requestRecord.Content); err != nil {
+		closeRecords()
+		return nil, err
+	}
+
+	responseRecord := warc.NewRecord(b.client.TempDir, b.client.FullOnDisk)
+	records = append(records, responseRecord)
+	responseRecord.Header.Set("WARC-Type", "response")
+	responseRecord.Header.Set("WARC-Record-ID", responseID)
+	responseRecord.Header.Set("WARC-Concurrent-To", requestID)
+	responseRecord.Header.Set("WARC-Target-URI", targetURI)
+	responseRecord.Header.Set("Content-Type", "application/http; msgtype=response")
+
+	if _, err := b.spool.Seek(0, io.SeekStart); err != nil {
+		closeRecords()
+		return nil, err
+	}
+	payloadDigest, err := warc.GetDigest(b.spool, b.client.DigestAlgorithm)
+	if err != nil {
+		closeRecords()
+		return nil, err
+	}
+	responseRecord.Header.Set("WARC-Payload-Digest", payloadDigest)
+
+	// The status line and headers of the original response, followed by the reassembled body
+	if _, err := fmt.Fprintf(responseRecord.Content, "%s %s\r\n", b.resp.Proto, b.resp.Status); err != nil {
+		closeRecords()
+		return nil, err
+	}
+	if err := b.resp.Header.Write(responseRecord.Content); err != nil {
+		closeRecords()
+		return nil, err
+	}
+	if _, err := io.WriteString(responseRecord.Content, "\r\n"); err != nil {
+		closeRecords()
+		return nil, err
+	}
+	if _, err := b.spool.Seek(0, io.SeekStart); err != nil {
+		closeRecords()
+		return nil, err
+	}
+	if _, err := io.Copy(responseRecord.Content, b.spool); err != nil {
+		closeRecords()
+		return nil, err
+	}
+
+	// The 206 response of the last Range request is archived by the WARC writer on its own,
+	// replay tools are told it is only a fragment of the reassembled response
+	metadataRecord := warc.NewRecord(b.client.TempDir, b.client.FullOnDisk)
+	records = append(records, metadataRecord)
+	metadataRecord.Header.Set("WARC-Type", "metadata")
+	metadataRecord.Header.Set("WARC-Concurrent-To", responseID)
+	metadataRecord.Header.Set("WARC-Target-URI", targetURI)
+	metadataRecord.Header.Set("Content-Type", "application/warc-fields")
+
+	var fields strings.Builder
+	for _, offset := range b.resumes {
+		fmt.Fprintf(&fields, "resumedFrom: %d\r\n", offset)
+	}
+	fmt.Fprintf(&fields, "rangeFragment: bytes %d-%d/%d\r\n", b.resumes[len(b.resumes)-1], b.total-1, b.total)
+	if _, err := io.WriteString(metadataRecord.Content, fields.String()); err != nil {
+		closeRecords()
+		return nil, err
+	}
+
+	// Waiting for the record of the last Range request, the ones before it were interrupted
+	if b.feedback != nil {
+		<-b.feedback
+		recordid.Take(b.feedback)
+	}
+
+	var feedback chan struct{}
+	if !config.Get().WARCWriteAsync {
+		feedback = make(chan struct{}, 1)
+	}
+	batch := warc.NewRecordBatch(feedback)
+	batch.Records = append(batch.Records, records...)
+	b.client.WARCWriter <- batch
+
+	return feedback, nil
+}
+
+// release closes the spooled body
+func (b *resumableBody) release() {
+	if err := b.spool.Close(); err != nil {
+		b.logger.Error("unable to close spooled body", "err", err.Error())
+	}
+}
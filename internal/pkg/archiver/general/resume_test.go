@@ -0,0 +1,339 @@
+package general
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
+	warc "github.com/internetarchive/gowarc"
+)
+
+func TestIsResumable(t *testing.T) {
+	tests := []struct {
+		name          string
+		statusCode    int
+		method        string
+		contentLength int64
+		acceptRanges  string
+		encoding      string
+		minSize       int64
+		want          bool
+	}{
+		{name: "resumable", statusCode: 200, method: "GET", contentLength: 2048, acceptRanges: "bytes", minSize: 1024, want: true},
+		{name: "case insensitive", statusCode: 200, method: "GET", contentLength: 2048, acceptRanges: " Bytes", minSize: 1024, want: true},
+		{name: "default method", statusCode: 200, contentLength: 2048, acceptRanges: "bytes", minSize: 1024, want: true},
+		{name: "disabled", statusCode: 200, method: "GET", contentLength: 2048, acceptRanges: "bytes", minSize: 0, want: false},
+		{name: "too small", statusCode: 200, method: "GET", contentLength: 512, acceptRanges: "bytes", minSize: 1024, want: false},
+		{name: "unknown length", statusCode: 200, method: "GET", contentLength: -1, acceptRanges: "bytes", minSize: 1024, want: false},
+		{name: "no ranges", statusCode: 200, method: "GET", contentLength: 2048, acceptRanges: "none", minSize: 1024, want: false},
+		{name: "no header", statusCode: 200, method: "GET", contentLength: 2048, minSize: 1024, want: false},
+		{name: "partial content", statusCode: 206, method: "GET", contentLength: 2048, acceptRanges: "bytes", minSize: 1024, want: false},
+		{name: "identity encoding", statusCode: 200, method: "GET", contentLength: 2048, acceptRanges: "bytes", encoding: "identity", minSize: 1024, want: true},
+		{name: "gzip encoding", statusCode: 200, method: "GET", contentLength: 2048, acceptRanges: "bytes", encoding: "gzip", minSize: 1024, want: false},
+		{name: "POST", statusCode: 200, method: "POST", contentLength: 2048, acceptRanges: "bytes", minSize: 1024, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode:    tt.statusCode,
+				ContentLength: tt.contentLength,
+				Header:        http.Header{},
+				Request:       &http.Request{Method: tt.method},
+			}
+			if tt.acceptRanges != "" {
+				resp.Header.Set("Accept-Ranges", tt.acceptRanges)
+			}
+			if tt.encoding != "" {
+				resp.Header.Set("Content-Encoding", tt.encoding)
+			}
+
+			if got := isResumable(resp, tt.minSize); got != tt.want {
+				t.Errorf("isResumable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRangeValidator(t *testing.T) {
+	tests := []struct {
+		name         string
+		etag         string
+		lastModified string
+		want         string
+	}{
+		{name: "strong ETag", etag: `"abc"`, lastModified: "Wed, 21 Oct 2015 07:28:00 GMT", want: `"abc"`},
+		{name: "weak ETag", etag: `W/"abc"`, lastModified: "Wed, 21 Oct 2015 07:28:00 GMT", want: "Wed, 21 Oct 2015 07:28:00 GMT"},
+		{name: "Last-Modified only", lastModified: "Wed, 21 Oct 2015 07:28:00 GMT", want: "Wed, 21 Oct 2015 07:28:00 GMT"},
+		{name: "none", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.etag != "" {
+				header.Set("ETag", tt.etag)
+			}
+			if tt.lastModified != "" {
+				header.Set("Last-Modified", tt.lastModified)
+			}
+
+			if got := rangeValidator(header); got != tt.want {
+				t.Errorf("rangeValidator() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		value        string
+		wantFirst    int64
+		wantLast     int64
+		wantComplete int64
+		wantErr      bool
+	}{
+		{value: "bytes 1024-2047/2048", wantFirst: 1024, wantLast: 2047, wantComplete: 2048},
+		{value: "bytes 0-0/1", wantFirst: 0, wantLast: 0, wantComplete: 1},
+		{value: "bytes 1024-2047/*", wantFirst: 1024, wantLast: 2047, wantComplete: -1},
+		{value: "", wantErr: true},
+		{value: "bytes */2048", wantErr: true},
+		{value: "bytes 2047-1024/2048", wantErr: true},
+		{value: "bytes 1024-2047", wantErr: true},
+		{value: "items 1024-2047/2048", wantErr: true},
+		{value: "bytes a-2047/2048", wantErr: true},
+		{value: "bytes 1024-2047/b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			first, last, complete, err := parseContentRange(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidContentRange) {
+					t.Errorf("expected ErrInvalidContentRange, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if first != tt.wantFirst || last != tt.wantLast || complete != tt.wantComplete {
+				t.Errorf("parseContentRange() = %d, %d, %d, want %d, %d, %d", first, last, complete, tt.wantFirst, tt.wantLast, tt.wantComplete)
+			}
+		})
+	}
+}
+
+// newInterruptingServer serves content with Accept-Ranges: bytes, the connection of the
+// first request without a Range header is cut after half of the body
+func newInterruptingServer(t *testing.T, content []byte) *httptest.Server {
+	t.Helper()
+
+	var interrupted atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"resume-test"`)
+		w.Header().Set("Content-Type", "application/octet-stream")
+
+		if r.Header.Get("Range") != "" || interrupted.Load() {
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+			return
+		}
+		interrupted.Store(true)
+
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(content[:len(content)/2])
+
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("unable to hijack the connection: %v", err)
+			return
+		}
+		conn.Close()
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// readWARCRecords reads the records of the WARC files of a directory, except the warcinfo ones
+func readWARCRecords(t *testing.T, dir string) (records []*warc.Record, contents [][]byte) {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.warc.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range files {
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reader, err := warc.NewReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for {
+			record, err := reader.ReadRecord()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("unable to read record of %s: %v", path, err)
+			}
+
+			content, err := io.ReadAll(record.Content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			record.Content.Close()
+
+			if record.Header.Get("WARC-Type") != "warcinfo" {
+				records = append(records, record)
+				contents = append(contents, content)
+			}
+		}
+
+		reader.Close()
+		file.Close()
+	}
+
+	return records, contents
+}
+
+func TestResumableBodyWriteRecord(t *testing.T) {
+	previous := config.Get()
+	t.Cleanup(func() { config.Set(previous) })
+	config.Set(&config.Config{MaxRetry: 2, WARCTempDir: t.TempDir()})
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64*1024)
+	server := newInterruptingServer(t, content)
+
+	rotatorSettings := warc.NewRotatorSettings()
+	rotatorSettings.Prefix = "TEST"
+	rotatorSettings.OutputDirectory = t.TempDir()
+
+	client, err := warc.NewWARCWritingHTTPClient(warc.HTTPClientSettings{
+		RotatorSettings: rotatorSettings,
+		TempDir:         t.TempDir(),
+		DigestAlgorithm: warc.SHA1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errsDone := make(chan struct{})
+	go func() {
+		for range client.ErrChan {
+		}
+		close(errsDone)
+	}()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/large.bin", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = req.WithContext(warc.WithWrappedConnection(req.Context(), make(chan *warc.CustomConnection, 1)))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isResumable(resp, 1024) {
+		t.Fatal("response isn't resumable")
+	}
+
+	resumable := newResumableBody(resp, client, log.NewFieldedLogger(&log.Fields{"component": "archiver.general.test"}))
+	body, err := io.ReadAll(resumable)
+	resumable.Close()
+	if err != nil {
+		t.Fatalf("unable to read the resumed body: %v", err)
+	}
+	if !bytes.Equal(body, content) || !resumable.Resumed() {
+		t.Fatalf("got %d bytes, resumed %v, want the %d bytes of the content resumed", len(body), resumable.Resumed(), len(content))
+	}
+
+	feedback, err := resumable.writeRecord()
+	if err != nil {
+		t.Fatalf("writeRecord() error = %v", err)
+	}
+	<-feedback
+	resumable.release()
+
+	client.Close()
+	<-errsDone
+
+	wantDigest, err := warc.GetDigest(bytes.NewReader(content), warc.SHA1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, contents := readWARCRecords(t, rotatorSettings.OutputDirectory)
+	ids := make(map[string]*warc.Record, len(records))
+	for _, record := range records {
+		ids[record.Header.Get("WARC-Record-ID")] = record
+	}
+
+	var responses, fragments int
+	for i, record := range records {
+		if record.Header.Get("WARC-Type") != "response" {
+			continue
+		}
+
+		archived, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(contents[i])), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		payload, err := io.ReadAll(archived.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if archived.StatusCode == http.StatusPartialContent {
+			fragments++
+			continue
+		}
+		responses++
+
+		if !bytes.Equal(payload, content) {
+			t.Errorf("archived payload of %d bytes differs from the %d bytes of the content", len(payload), len(content))
+		}
+		if got := record.Header.Get("WARC-Payload-Digest"); got != wantDigest {
+			t.Errorf("WARC-Payload-Digest = %q, want %q", got, wantDigest)
+		}
+
+		request, ok := ids[record.Header.Get("WARC-Concurrent-To")]
+		if !ok || request.Header.Get("WARC-Type") != "request" || request.Header.Get("WARC-Concurrent-To") != record.Header.Get("WARC-Record-ID") {
+			t.Error("reassembled response isn't linked to a request record")
+		}
+
+		var marked bool
+		for j, metadata := range records {
+			if metadata.Header.Get("WARC-Type") == "metadata" && metadata.Header.Get("WARC-Concurrent-To") == record.Header.Get("WARC-Record-ID") {
+				marked = strings.Contains(string(contents[j]), "rangeFragment: bytes ")
+			}
+		}
+		if !marked {
+			t.Error("the 206 fragment isn't marked by a metadata record")
+		}
+	}
+
+	if responses != 1 || fragments != 1 {
+		t.Errorf("got %d reassembled responses and %d fragments, want 1 of each", responses, fragments)
+	}
+}
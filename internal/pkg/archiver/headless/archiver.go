@@ -21,11 +21,16 @@ import (
 	"github.com/go-rod/stealth"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/connutil"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/discard/reasoncode"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/quarantine"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/ratelimiter"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/recordid"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/cookies"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/crawllog"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/preprocessor"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/tracing"
 	"github.com/internetarchive/Zeno/v2/pkg/models"
 	warc "github.com/internetarchive/gowarc"
 	"github.com/internetarchive/gowarc/pkg/spooledtempfile"
@@ -97,8 +102,12 @@ func ArchiveItem(item *models.Item, wg *sync.WaitGroup, guard chan struct{}, buc
 		"item_url":  item.GetURL().String(),
 	})
 
+	_, span := tracing.StartItemSpan(item, "archiver.headless")
+	defer span.End()
+
 	err := archivePage(client, item, item.GetSeed(), bucketManager)
 	if err != nil {
+		tracing.SetError(span, err)
 		item.SetStatus(models.ItemFailed)
 		logger.Error("unable to archive page in headless mode", "err", err.Error())
 		return
@@ -109,6 +118,18 @@ func ArchiveItem(item *models.Item, wg *sync.WaitGroup, guard chan struct{}, buc
 	logger.Info("page archived successfully")
 }
 
+// newCrawlLogEntry returns the crawl log entry of a request made by the page of item,
+// the requests other than the page itself are logged as its assets
+func newCrawlLogEntry(item *models.Item, URL string) *crawllog.Entry {
+	entry := crawllog.NewEntry(item)
+	if URL != item.GetURL().String() {
+		entry.URL = URL
+		entry.HopPath += "E"
+		entry.Via = item.GetURL().String()
+	}
+	return entry
+}
+
 func archivePage(warcClient *warc.CustomHTTPClient, item *models.Item, seed *models.Item, bucketManager *ratelimiter.BucketManager) error {
 	logger := log.NewFieldedLogger(&log.Fields{
 		"component": "archiver.headless.archive.page",
@@ -195,8 +216,31 @@ func archivePage(warcClient *warc.CustomHTTPClient, item *models.Item, seed *mod
 			err             error
 			feedbackChan    chan struct{}
 			wrappedConnChan chan *warc.CustomConnection
+			retries         int
+			discardedReason string
+			size            int64 = -1
+			digest          string
 		)
 
+		// Log the outcome of the request to the crawl log, whatever it is
+		if crawllog.Enabled() {
+			entry := newCrawlLogEntry(item, hijack.Request.URL().String())
+			defer func() {
+				entry.End()
+				entry.Retries = retries
+				entry.DiscardReason = discardedReason
+				entry.Size = size
+				entry.Digest = digest
+				if resp == nil {
+					entry.Status = crawllog.StatusFromError(err)
+				} else {
+					entry.Status = resp.StatusCode
+					entry.MIME = crawllog.MIME(resp.Header.Get("Content-Type"))
+				}
+				crawllog.Write(entry)
+			}()
+		}
+
 		if hijack.Request.URL().String() == item.GetURL().String() {
 			logger.Debug("capturing main page")
 		} else {
@@ -205,15 +249,23 @@ func archivePage(warcClient *warc.CustomHTTPClient, item *models.Item, seed *mod
 
 		// Wait for the rate limiter if enabled
 		if bucketManager != nil {
-			elapsed := bucketManager.Wait(hijack.Request.URL().Host)
+			host := hijack.Request.URL().Host
+			elapsed := bucketManager.Wait(host)
+			defer bucketManager.Release(host)
 			logger.Debug("got token from bucket", "elapsed", elapsed)
-
 		}
 		req = hijack.Request.Req()
 
+		// Attach the cookies from the jar, the ones set by the browser take precedence
+		jar := cookies.Get()
+		if jar != nil {
+			jar.AddToRequest(req)
+		}
+
 		for retry := 0; retry <= config.Get().MaxRetry; retry++ {
 			// This is unused unless there is an error
 			retrySleepTime := time.Second * time.Duration(retry*2)
+			retries = retry
 
 			// // Get and measure request time
 			getStartTime := time.Now()
@@ -235,6 +287,7 @@ func archivePage(warcClient *warc.CustomHTTPClient, item *models.Item, seed *mod
 
 			resp, err = clientDo(&warcClient.Client, req, hijack)
 			if err != nil {
+				stats.HostResponseObserve(req.URL.Host, 0, time.Since(getStartTime))
 				if errors.Is(err, context.Canceled) { // failfast if the request is canceled
 					logger.Debug("request canceled", "err", err.Error())
 					hijack.Response.Fail(proto.NetworkErrorReasonTimedOut)
@@ -251,8 +304,13 @@ func archivePage(warcClient *warc.CustomHTTPClient, item *models.Item, seed *mod
 				hijack.Response.Fail(proto.NetworkErrorReasonAborted)
 				return
 			}
+			if jar != nil {
+				jar.SetCookies(req.URL, resp.Cookies())
+			}
+
 			stats.MeanHTTPRespTimeAdd(time.Since(getStartTime))
 			stats.HTTPReturnCodesIncr(strconv.Itoa(resp.StatusCode))
+			stats.HostResponseObserve(req.URL.Host, resp.StatusCode, time.Since(getStartTime))
 
 			break
 		} // <--- retry loop end
@@ -270,10 +328,12 @@ func archivePage(warcClient *warc.CustomHTTPClient, item *models.Item, seed *mod
 		}
 
 		if discarded {
+			stats.DiscardedResponsesIncr(discardReason)
 			resp.Body.Close()              // First, close the body, to stop downloading data anymore.
 			io.Copy(io.Discard, resp.Body) // Then, consume the buffer.
 
 			logger.Warn("response was blocked by DiscardHook", "reason", discardReason, "status_code", resp.StatusCode)
+			discardedReason = discardReason
 			hijack.Response.Fail(proto.NetworkErrorReasonBlockedByClient)
 			return
 		}
@@ -293,6 +353,21 @@ func archivePage(warcClient *warc.CustomHTTPClient, item *models.Item, seed *mod
 		stats.MeanProcessBodyTimeAdd(time.Since(processStartTime))
 
 		// OK
+		stats.HostResponseBytesObserve(req.URL.Host, int64(len(fullBody)))
+		if crawllog.Enabled() {
+			digester := crawllog.NewDigester()
+			digester.Write(fullBody)
+			size, digest = int64(len(fullBody)), digester.Digest()
+		}
+
+		// Soft-404 and login pages are still archived, but tagged and kept from leading the crawl anywhere
+		if quarantine.Enabled() && hijack.Request.URL().String() == item.GetURL().String() {
+			if reason := classifyPage(item, resp, fullBody); reason != "" {
+				item.SetQuarantineReason(reason)
+				stats.QuarantinedResponsesIncr(reason)
+				logger.Warn("page quarantined", "reason", reason, "status_code", resp.StatusCode)
+			}
+		}
 
 		if len(fullBody) == 0 { // ([]uint8) <nil>
 			// If the response body is empty (e.g., 30X redirects), We have to set it to an empty byte slice
@@ -309,6 +384,11 @@ func archivePage(warcClient *warc.CustomHTTPClient, item *models.Item, seed *mod
 			// Waiting for WARC writing to finish
 			<-feedbackChan
 			stats.MeanWaitOnFeedbackTimeAdd(time.Since(feedbackTime))
+
+			// The metadata record of the item points to the response record of the page itself
+			if recordID := recordid.Take(feedbackChan); req.URL.String() == item.GetURL().String() {
+				item.SetRecordID(recordID)
+			}
 		}
 
 		logger.Debug("processed body", "size", len(hijack.Response.Payload().Body), "status_code", resp.StatusCode)
@@ -322,8 +402,6 @@ func archivePage(warcClient *warc.CustomHTTPClient, item *models.Item, seed *mod
 	logger.Debug("using page behaviors", "initJS", behaviorInitJS())
 	page.MustEvalOnNewDocument(behaviorInitJS())
 
-	// TODO: Set cookies if needed (if no other cookies for this URL are set)
-
 	// Navigate to the URL
 	logger.Debug("navigating to URL")
 
@@ -386,6 +464,27 @@ func archivePage(warcClient *warc.CustomHTTPClient, item *models.Item, seed *mod
 	return nil
 }
 
+// classifyPage returns the reason why the response of the page should be quarantined, empty if it shouldn't.
+// The response as served is classified, like in the general archiver, not the page rendered by the browser.
+func classifyPage(item *models.Item, resp *http.Response, body []byte) string {
+	URL, err := models.NewURL(item.GetURL().String())
+	if err != nil {
+		return ""
+	}
+	URL.SetResponse(resp)
+
+	spooledBuff := spooledtempfile.NewSpooledTempFile("zeno", config.Get().WARCTempDir, 8000000, false, -1)
+	defer spooledBuff.Close()
+	if _, err := spooledBuff.Write(body); err != nil {
+		return ""
+	}
+	URL.SetBody(spooledBuff)
+	URL.RewindBody()
+	URL.SetMIMEType(mimetype.Detect(body))
+
+	return quarantine.Classify(&URL, int64(len(body)))
+}
+
 // Get the Document from the page and store it in the item
 func extractAndStoreHTML(item *models.Item, page *rod.Page) error {
 	logger := log.NewFieldedLogger(&log.Fields{
@@ -0,0 +1,14 @@
+package proxypool
+
+import "errors"
+
+var (
+	// ErrNoProxy is returned when the proxy pool file doesn't list any proxy
+	ErrNoProxy = errors.New("no proxy in the pool")
+	// ErrUnknownStrategy is returned when the proxy pool file sets a strategy that doesn't exist
+	ErrUnknownStrategy = errors.New("unknown proxy selection strategy")
+	// ErrUnknownProxy is returned when a routing rule references a proxy that isn't in the pool
+	ErrUnknownProxy = errors.New("unknown proxy")
+	// ErrNoHealthyProxy is returned when every proxy a host is routed to is unhealthy
+	ErrNoHealthyProxy = errors.New("no healthy proxy")
+)
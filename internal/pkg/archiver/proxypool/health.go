@@ -0,0 +1,95 @@
+package proxypool
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// StartHealthChecks checks every proxy of the pool at each health check interval, the ones
+// failing max-failures checks in a row are removed from the selection and the removed ones
+// are added back as soon as a check succeeds
+func (p *Pool) StartHealthChecks() {
+	p.wg.Go(func() {
+		ticker := time.NewTicker(p.healthCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-ticker.C:
+				p.checkAll()
+			}
+		}
+	})
+}
+
+// Stop stops the health checks
+func (p *Pool) Stop() {
+	close(p.done)
+	p.wg.Wait()
+}
+
+func (p *Pool) checkAll() {
+	var wg sync.WaitGroup
+	for _, proxy := range p.proxies {
+		wg.Go(func() {
+			// Failures get old, so that a proxy that recovered is picked again by the least-failures strategy
+			proxy.recentFailures.Store(proxy.recentFailures.Load() / 2)
+
+			if err := p.check(proxy); err != nil {
+				p.logger.Debug("proxy health check failed", "proxy", proxy.Name, "url", proxy.Redacted(), "err", err.Error())
+				p.ReportFailure(proxy)
+				return
+			}
+
+			p.ReportSuccess(proxy)
+			if proxy.healthy.CompareAndSwap(false, true) {
+				p.logger.Info("proxy added back to the pool", "proxy", proxy.Name, "url", proxy.Redacted())
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// check connects to the proxy, or fetches the health check URL through it if one is set.
+// The health checks don't go through the WARC writing client, they aren't archived.
+func (p *Pool) check(proxy *Proxy) error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.healthCheckTimeout)
+	defer cancel()
+
+	if p.healthCheckURL == "" {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", proxy.URL.Host)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.healthCheckURL, nil)
+	if err != nil {
+		return err
+	}
+
+	transport := &http.Transport{Proxy: http.ProxyURL(proxy.URL)}
+	defer transport.CloseIdleConnections()
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("health check URL returned status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package quarantine
+
+import "sync"
+
+// maxBaselineHosts bounds the memory used by the baselines, the hosts seen after are not compared
+const maxBaselineHosts = 100000
+
+// baseline is the mean size of the HTML pages of a host that weren't quarantined
+type baseline struct {
+	count int
+	mean  float64
+}
+
+type baselines struct {
+	mu         sync.Mutex
+	hosts      map[string]*baseline
+	minSamples int
+}
+
+func newBaselines(minSamples int) *baselines {
+	return &baselines{
+		hosts:      make(map[string]*baseline),
+		minSamples: minSamples,
+	}
+}
+
+// isTiny returns true if the page is smaller than ratio times the mean size of the pages of its host,
+// the pages that aren't tiny are added to the baseline of the host
+func (b *baselines) isTiny(host string, size int64, ratio float64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	hb, ok := b.hosts[host]
+	if !ok {
+		if len(b.hosts) >= maxBaselineHosts {
+			return false
+		}
+		hb = &baseline{}
+		b.hosts[host] = hb
+	}
+
+	if hb.count >= b.minSamples && float64(size) < ratio*hb.mean {
+		return true
+	}
+
+	hb.count++
+	hb.mean += (float64(size) - hb.mean) / float64(hb.count)
+
+	return false
+}
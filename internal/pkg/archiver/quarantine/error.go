@@ -0,0 +1,8 @@
+package quarantine
+
+import "errors"
+
+var (
+	// ErrInvalidRule is returned when the quarantine rules file is invalid
+	ErrInvalidRule = errors.New("invalid quarantine rule")
+)
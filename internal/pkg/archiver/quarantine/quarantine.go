@@ -0,0 +1,231 @@
+// Package quarantine classifies the archived responses that are really error or login pages
+// served with a success status (soft-404, login walls), with the heuristics of --quarantine-rules-file.
+// Quarantined captures stay in the WARC but are tagged with a metadata record and their outlinks aren't crawled.
+package quarantine
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+)
+
+const (
+	// ReasonLoginRedirect is the reason of the redirections to an URL matching login-url-regex
+	ReasonLoginRedirect = "login redirect"
+	// ReasonTinyBody is the reason of the HTML pages much smaller than the usual ones of their host
+	ReasonTinyBody = "tiny body"
+
+	// bodyLimit is the size of the beginning of the body the fingerprints are matched against
+	bodyLimit = 64 * 1024
+	// defaultTinyBodyMinSamples is the number of pages of a host needed before its baseline is used
+	defaultTinyBodyMinSamples = 20
+)
+
+var titleRegex = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// Fingerprint quarantines the pages whose title and beginning of the body match its regexes
+type Fingerprint struct {
+	Reason     string `json:"reason"`
+	TitleRegex string `json:"title-regex"`
+	BodyRegex  string `json:"body-regex"`
+
+	titleRegex *regexp.Regexp
+	bodyRegex  *regexp.Regexp
+}
+
+// rulesFile is the JSON document read from --quarantine-rules-file
+type rulesFile struct {
+	Fingerprints       []Fingerprint `json:"fingerprints"`
+	LoginURLRegex      string        `json:"login-url-regex"`
+	TinyBodyRatio      float64       `json:"tiny-body-ratio"`       // Pages smaller than this ratio of their host's mean size are quarantined, 0 disables it
+	TinyBodyMinSamples int           `json:"tiny-body-min-samples"` // Defaults to 20
+}
+
+type classifier struct {
+	fingerprints  []Fingerprint
+	loginURLRegex *regexp.Regexp
+	tinyBodyRatio float64
+	baselines     *baselines
+}
+
+var current atomic.Pointer[classifier]
+
+// Load reads and compiles the rules of a quarantine rules file
+func Load(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	var rf rulesFile
+	if err := json.Unmarshal(data, &rf); err != nil {
+		return err
+	}
+
+	c, err := newClassifier(rf)
+	if err != nil {
+		return err
+	}
+
+	current.Store(c)
+
+	return nil
+}
+
+// Enabled returns true if quarantine rules are loaded
+func Enabled() bool {
+	return current.Load() != nil
+}
+
+func newClassifier(rf rulesFile) (c *classifier, err error) {
+	c = &classifier{
+		fingerprints:  rf.Fingerprints,
+		tinyBodyRatio: rf.TinyBodyRatio,
+	}
+
+	for i := range c.fingerprints {
+		if err := c.fingerprints[i].compile(); err != nil {
+			return nil, fmt.Errorf("fingerprint %d: %w", i, err)
+		}
+	}
+
+	if rf.LoginURLRegex != "" {
+		if c.loginURLRegex, err = regexp.Compile(rf.LoginURLRegex); err != nil {
+			return nil, fmt.Errorf("%w: login-url-regex: %w", ErrInvalidRule, err)
+		}
+	}
+
+	if rf.TinyBodyRatio < 0 || rf.TinyBodyRatio >= 1 {
+		return nil, fmt.Errorf("%w: tiny-body-ratio must be between 0 and 1", ErrInvalidRule)
+	}
+	if rf.TinyBodyMinSamples < 0 {
+		return nil, fmt.Errorf("%w: tiny-body-min-samples can't be negative", ErrInvalidRule)
+	}
+	if rf.TinyBodyRatio > 0 {
+		minSamples := rf.TinyBodyMinSamples
+		if minSamples == 0 {
+			minSamples = defaultTinyBodyMinSamples
+		}
+		c.baselines = newBaselines(minSamples)
+	}
+
+	if len(c.fingerprints) == 0 && c.loginURLRegex == nil && c.baselines == nil {
+		return nil, fmt.Errorf("%w: no heuristic is configured", ErrInvalidRule)
+	}
+
+	return c, nil
+}
+
+func (f *Fingerprint) compile() (err error) {
+	if strings.TrimSpace(f.Reason) == "" {
+		return fmt.Errorf("%w: reason is required", ErrInvalidRule)
+	}
+
+	if f.TitleRegex == "" && f.BodyRegex == "" {
+		return fmt.Errorf("%w: %q has no title-regex nor body-regex", ErrInvalidRule, f.Reason)
+	}
+
+	if f.TitleRegex != "" {
+		if f.titleRegex, err = regexp.Compile(f.TitleRegex); err != nil {
+			return fmt.Errorf("%w: %q title-regex: %w", ErrInvalidRule, f.Reason, err)
+		}
+	}
+
+	if f.BodyRegex != "" {
+		if f.bodyRegex, err = regexp.Compile(f.BodyRegex); err != nil {
+			return fmt.Errorf("%w: %q body-regex: %w", ErrInvalidRule, f.Reason, err)
+		}
+	}
+
+	return nil
+}
+
+// Classify returns the reason why the archived response of the URL should be quarantined, empty if it shouldn't.
+// It runs once the body was processed, size is the size of the body.
+func Classify(u *models.URL, size int64) string {
+	c := current.Load()
+	if c == nil || u.GetResponse() == nil {
+		return ""
+	}
+
+	resp := u.GetResponse()
+
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		if c.isLoginRedirect(u) {
+			return ReasonLoginRedirect
+		}
+		return ""
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+
+	if reason := c.matchFingerprints(u); reason != "" {
+		return reason
+	}
+
+	if c.baselines != nil && u.GetParsed() != nil && u.GetMIMEType() != nil && strings.Contains(u.GetMIMEType().String(), "html") {
+		if c.baselines.isTiny(strings.ToLower(u.GetParsed().Hostname()), size, c.tinyBodyRatio) {
+			return ReasonTinyBody
+		}
+	}
+
+	return ""
+}
+
+// isLoginRedirect returns true if the response redirects to an URL matching login-url-regex
+func (c *classifier) isLoginRedirect(u *models.URL) bool {
+	location := u.GetResponse().Header.Get("Location")
+	if c.loginURLRegex == nil || location == "" {
+		return false
+	}
+
+	// Locations are often relative, they are resolved later on by the preprocessor
+	if base := u.GetParsed(); base != nil {
+		if resolved, err := base.Parse(location); err == nil {
+			location = resolved.String()
+		}
+	}
+
+	return c.loginURLRegex.MatchString(location)
+}
+
+// matchFingerprints returns the reason of the first fingerprint matching the body, which is rewound afterward
+func (c *classifier) matchFingerprints(u *models.URL) string {
+	if len(c.fingerprints) == 0 || u.GetBody() == nil {
+		return ""
+	}
+
+	head, err := io.ReadAll(io.LimitReader(u.GetBody(), bodyLimit))
+	u.RewindBody()
+	if err != nil {
+		return ""
+	}
+
+	var title string
+	if match := titleRegex.FindSubmatch(head); match != nil {
+		title = strings.TrimSpace(html.UnescapeString(string(match[1])))
+	}
+
+	for i := range c.fingerprints {
+		f := &c.fingerprints[i]
+		if f.titleRegex != nil && !f.titleRegex.MatchString(title) {
+			continue
+		}
+		if f.bodyRegex != nil && !f.bodyRegex.Match(head) {
+			continue
+		}
+		return f.Reason
+	}
+
+	return ""
+}
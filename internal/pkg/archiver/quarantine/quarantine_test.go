@@ -0,0 +1,235 @@
+package quarantine
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+	"github.com/internetarchive/gowarc/pkg/spooledtempfile"
+)
+
+const testRules = `{
+	"fingerprints": [
+		{"reason": "soft-404", "title-regex": "(?i)(page|file) not found"},
+		{"reason": "login wall", "body-regex": "(?i)<form[^>]+action=\"[^\"]*/login"}
+	],
+	"login-url-regex": "(?i)/(login|signin)([/?#]|$)",
+	"tiny-body-ratio": 0.1,
+	"tiny-body-min-samples": 3
+}`
+
+func load(t *testing.T, content string) error {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "quarantine-rules.json")
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return Load(file)
+}
+
+func newTestURL(t *testing.T, raw string, status int, header http.Header, body string) *models.URL {
+	t.Helper()
+
+	URL, err := models.NewURL(raw)
+	if err != nil {
+		t.Fatalf("unable to create URL: %v", err)
+	}
+
+	if header == nil {
+		header = http.Header{}
+	}
+	URL.SetResponse(&http.Response{StatusCode: status, Header: header})
+
+	if body != "" {
+		spooledTempFile := spooledtempfile.NewSpooledTempFile("test", os.TempDir(), 2048, false, -1)
+		t.Cleanup(func() { spooledTempFile.Close() })
+		spooledTempFile.Write([]byte(body))
+		URL.SetBody(spooledTempFile)
+		URL.RewindBody()
+		URL.SetMIMEType(mimetype.Lookup("text/html"))
+	}
+
+	return &URL
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr error
+	}{
+		{
+			name:    "valid rules",
+			content: testRules,
+		},
+		{
+			name:    "no heuristic",
+			content: `{}`,
+			wantErr: ErrInvalidRule,
+		},
+		{
+			name:    "fingerprint without reason",
+			content: `{"fingerprints": [{"title-regex": "404"}]}`,
+			wantErr: ErrInvalidRule,
+		},
+		{
+			name:    "fingerprint without regex",
+			content: `{"fingerprints": [{"reason": "soft-404"}]}`,
+			wantErr: ErrInvalidRule,
+		},
+		{
+			name:    "invalid login regex",
+			content: `{"login-url-regex": "("}`,
+			wantErr: ErrInvalidRule,
+		},
+		{
+			name:    "invalid tiny body ratio",
+			content: `{"tiny-body-ratio": 1.5}`,
+			wantErr: ErrInvalidRule,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { current.Store(nil) })
+
+			err := load(t, tt.content)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !Enabled() {
+				t.Error("rules weren't loaded")
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	t.Cleanup(func() { current.Store(nil) })
+
+	if err := load(t, testRules); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		raw    string
+		status int
+		header http.Header
+		body   string
+		want   string
+	}{
+		{
+			name:   "soft-404 title",
+			raw:    "https://example.com/missing",
+			status: 200,
+			body:   `<html><head><title>Oops! Page Not Found</title></head><body>Try the search</body></html>`,
+			want:   "soft-404",
+		},
+		{
+			name:   "escaped title",
+			raw:    "https://example.com/missing",
+			status: 200,
+			body:   `<title>File&#32;not&#32;found</title>`,
+			want:   "soft-404",
+		},
+		{
+			name:   "login form",
+			raw:    "https://example.com/members",
+			status: 200,
+			body:   `<form method="post" action="https://example.com/account/login">`,
+			want:   "login wall",
+		},
+		{
+			name:   "regular page",
+			raw:    "https://example.com/article",
+			status: 200,
+			body:   `<title>An article</title>`,
+		},
+		{
+			name:   "fingerprints only apply to 200",
+			raw:    "https://example.com/missing",
+			status: 404,
+			body:   `<title>Page not found</title>`,
+		},
+		{
+			name:   "relative redirect to login",
+			raw:    "https://example.com/private",
+			status: 302,
+			header: http.Header{"Location": {"/signin?next=/private"}},
+			want:   ReasonLoginRedirect,
+		},
+		{
+			name:   "regular redirect",
+			raw:    "https://example.com/old",
+			status: 301,
+			header: http.Header{"Location": {"https://example.com/loginless/new"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newTestURL(t, tt.raw, tt.status, tt.header, tt.body)
+
+			if got := Classify(u, int64(len(tt.body))); got != tt.want {
+				t.Errorf("Classify() = %q, want %q", got, tt.want)
+			}
+
+			// The body is rewound for the extractors
+			if u.GetBody() != nil {
+				body, err := io.ReadAll(u.GetBody())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(body) != tt.body {
+					t.Errorf("body not rewound, got %q", body)
+				}
+			}
+		})
+	}
+}
+
+func TestClassifyTinyBody(t *testing.T) {
+	t.Cleanup(func() { current.Store(nil) })
+
+	if err := load(t, `{"tiny-body-ratio": 0.1, "tiny-body-min-samples": 3}`); err != nil {
+		t.Fatal(err)
+	}
+
+	page := "<html>" + strings.Repeat("content ", 1000) + "</html>"
+	tiny := "<html>Nothing here</html>"
+
+	// The baseline isn't used until the host has enough samples
+	if got := Classify(newTestURL(t, "https://example.com/0", 200, nil, tiny), int64(len(tiny))); got != "" {
+		t.Errorf("tiny page classified before the baseline was built: %q", got)
+	}
+	for i := range 3 {
+		u := newTestURL(t, "https://example.com/"+strings.Repeat("a", i+1), 200, nil, page)
+		if got := Classify(u, int64(len(page))); got != "" {
+			t.Fatalf("regular page classified as %q", got)
+		}
+	}
+
+	if got := Classify(newTestURL(t, "https://example.com/empty", 200, nil, tiny), int64(len(tiny))); got != ReasonTinyBody {
+		t.Errorf("Classify() = %q, want %q", got, ReasonTinyBody)
+	}
+
+	// Other hosts have their own baseline
+	if got := Classify(newTestURL(t, "https://example.org/empty", 200, nil, tiny), int64(len(tiny))); got != "" {
+		t.Errorf("Classify() on another host = %q, want none", got)
+	}
+}
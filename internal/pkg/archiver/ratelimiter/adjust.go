@@ -5,8 +5,9 @@ import (
 	"time"
 )
 
-// adjustOnFailure applies real-world adjustments based on the HTTP status code.
-func (tb *tokenBucket) adjustOnFailure(statusCode int) {
+// adjustOnFailure applies real-world adjustments based on the HTTP status code,
+// it returns true if the bucket was penalized.
+func (tb *tokenBucket) adjustOnFailure(statusCode int) bool {
 	tb.mu.Lock()
 	defer tb.mu.Unlock()
 
@@ -17,9 +18,13 @@ func (tb *tokenBucket) adjustOnFailure(statusCode int) {
 	case statusCode == 429 || statusCode == 403 || statusCode == 408 || statusCode == 425:
 		tb.failureCount++
 		penalty := min(time.Duration(float64(basePenaltyDuration)*math.Pow(2, float64(tb.failureCount-1))), maxPenaltyDuration)
-		tb.penaltyUntil = now.Add(penalty)
+		// A longer penalty, e.g. asked by the server with Retry-After, isn't shortened
+		if until := now.Add(penalty); until.After(tb.penaltyUntil) {
+			tb.penaltyUntil = until
+		}
 		// Optionally, clear tokens to prevent immediate further requests.
 		tb.tokens = 0
+		return true
 
 	// For server errors like 503 or 5xx, reduce the refill rate exponentially.
 	case statusCode >= 500:
@@ -27,9 +32,11 @@ func (tb *tokenBucket) adjustOnFailure(statusCode int) {
 		newRefillRate := max(tb.refillRate*math.Pow(0.5, float64(tb.failureCount)), minRefillRate)
 		tb.refillRate = newRefillRate
 		tb.tokens = 0
+		return true
 
 	default:
 		// For non-error status codes, do nothing.
+		return false
 	}
 }
 
@@ -58,3 +65,14 @@ func (tb *tokenBucket) onSuccess() {
 		}
 	}
 }
+
+// penalizeFor stops the refill of the bucket for at least delay, as asked by the server.
+func (tb *tokenBucket) penalizeFor(delay time.Duration) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	if until := tb.nowFunc().Add(delay); until.After(tb.penaltyUntil) {
+		tb.penaltyUntil = until
+	}
+	tb.tokens = 0
+}
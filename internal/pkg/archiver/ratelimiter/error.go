@@ -0,0 +1,8 @@
+package ratelimiter
+
+import "errors"
+
+var (
+	// ErrInvalidRule is returned when a rule of the rate limit rules file is invalid
+	ErrInvalidRule = errors.New("invalid rate limit rule")
+)
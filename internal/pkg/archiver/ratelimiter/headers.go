@@ -0,0 +1,85 @@
+package ratelimiter
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServerDelay returns how long the server asks to wait before sending it another request,
+// from the Retry-After header (delay in seconds or HTTP-date) or, when the quota is
+// exhausted, from the IETF RateLimit-Remaining and RateLimit-Reset headers or their
+// combined RateLimit form (e.g. RateLimit: "default";r=0;t=30).
+// It returns false if the response doesn't ask to wait.
+func ServerDelay(header http.Header, now time.Time) (time.Duration, bool) {
+	if delay, ok := parseRetryAfter(header.Get("Retry-After"), now); ok {
+		return delay, true
+	}
+
+	remaining, reset, ok := parseRateLimit(header)
+	if ok && remaining == 0 {
+		return reset, true
+	}
+
+	return 0, false
+}
+
+// parseRetryAfter parses a Retry-After value, either a number of seconds or an HTTP-date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	// A date in the past means the request can be retried right away
+	return max(date.Sub(now), 0), true
+}
+
+// parseRateLimit returns the remaining quota and the delay until it resets, from the
+// RateLimit-Remaining and RateLimit-Reset headers or from the combined RateLimit header
+func parseRateLimit(header http.Header) (remaining int64, reset time.Duration, ok bool) {
+	remainingValue := header.Get("RateLimit-Remaining")
+	resetValue := header.Get("RateLimit-Reset")
+
+	if remainingValue == "" && resetValue == "" {
+		// Both the "remaining=0, reset=30" and the structured field "policy";r=0;t=30 forms are accepted
+		params := strings.FieldsFunc(header.Get("RateLimit"), func(r rune) bool { return r == ',' || r == ';' })
+		for _, param := range params {
+			key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if !found {
+				continue
+			}
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "remaining", "r":
+				remainingValue = value
+			case "reset", "t":
+				resetValue = value
+			}
+		}
+	}
+
+	remaining, err := strconv.ParseInt(strings.TrimSpace(remainingValue), 10, 64)
+	if err != nil || remaining < 0 {
+		return 0, 0, false
+	}
+
+	seconds, err := strconv.ParseInt(strings.TrimSpace(resetValue), 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, 0, false
+	}
+
+	return remaining, time.Duration(seconds) * time.Second, true
+}
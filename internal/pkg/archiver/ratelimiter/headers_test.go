@@ -0,0 +1,86 @@
+package ratelimiter
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServerDelay(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		header    http.Header
+		wantDelay time.Duration
+		wantOK    bool
+	}{
+		{
+			name:   "no header",
+			header: http.Header{},
+		},
+		{
+			name:      "Retry-After seconds",
+			header:    http.Header{"Retry-After": {"120"}},
+			wantDelay: 2 * time.Minute,
+			wantOK:    true,
+		},
+		{
+			name:      "Retry-After HTTP-date",
+			header:    http.Header{"Retry-After": {"Wed, 01 Jan 2025 12:00:30 GMT"}},
+			wantDelay: 30 * time.Second,
+			wantOK:    true,
+		},
+		{
+			name:      "Retry-After date in the past",
+			header:    http.Header{"Retry-After": {"Wed, 01 Jan 2025 11:00:00 GMT"}},
+			wantDelay: 0,
+			wantOK:    true,
+		},
+		{
+			name:   "invalid Retry-After",
+			header: http.Header{"Retry-After": {"soon"}},
+		},
+		{
+			name:   "negative Retry-After",
+			header: http.Header{"Retry-After": {"-5"}},
+		},
+		{
+			name:      "RateLimit quota exhausted",
+			header:    http.Header{"Ratelimit-Remaining": {"0"}, "Ratelimit-Reset": {"45"}},
+			wantDelay: 45 * time.Second,
+			wantOK:    true,
+		},
+		{
+			name:   "RateLimit quota left",
+			header: http.Header{"Ratelimit-Remaining": {"10"}, "Ratelimit-Reset": {"45"}},
+		},
+		{
+			name:      "combined RateLimit header",
+			header:    http.Header{"Ratelimit": {"limit=100, remaining=0, reset=15"}},
+			wantDelay: 15 * time.Second,
+			wantOK:    true,
+		},
+		{
+			name:      "structured RateLimit header",
+			header:    http.Header{"Ratelimit": {`"default";r=0;t=20`}},
+			wantDelay: 20 * time.Second,
+			wantOK:    true,
+		},
+		{
+			name:      "Retry-After takes precedence",
+			header:    http.Header{"Retry-After": {"5"}, "Ratelimit-Remaining": {"0"}, "Ratelimit-Reset": {"45"}},
+			wantDelay: 5 * time.Second,
+			wantOK:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, ok := ServerDelay(tt.header, now)
+			if ok != tt.wantOK || delay != tt.wantDelay {
+				t.Errorf("ServerDelay() = %v, %v, want %v, %v", delay, ok, tt.wantDelay, tt.wantOK)
+			}
+		})
+	}
+}
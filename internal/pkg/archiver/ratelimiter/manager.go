@@ -18,11 +18,14 @@ type managedBucket struct {
 type BucketManager struct {
 	mu          sync.Mutex
 	buckets     map[string]*managedBucket
-	maxBuckets  int           // maximum number of buckets allowed
-	capacity    float64       // default bucket capacity
-	refillRate  float64       // default refill rate for new buckets
-	cleanupFreq time.Duration // how often to run cleanup of stale buckets
-	done        chan struct{} // signal to close the cleanup loop
+	maxBuckets  int                      // maximum number of buckets allowed
+	capacity    float64                  // default bucket capacity
+	refillRate  float64                  // default refill rate for new buckets
+	cleanupFreq time.Duration            // how often to run cleanup of stale buckets
+	crawlDelays map[string]time.Duration // per-host Crawl-delay, kept across bucket evictions
+	rules       []Rule                   // per-host overrides of the default limits
+	slots       map[string]*hostSlots    // per-host concurrency slots, of the hosts with requests in flight
+	done        chan struct{}            // signal to close the cleanup loop
 	ctx         context.Context
 }
 
@@ -36,6 +39,8 @@ func NewBucketManager(ctx context.Context, maxBuckets int, capacity, refillRate
 		capacity:    capacity,
 		refillRate:  refillRate,
 		cleanupFreq: cleanupFreq,
+		crawlDelays: make(map[string]time.Duration),
+		slots:       make(map[string]*hostSlots),
 		done:        make(chan struct{}),
 		ctx:         ctx,
 	}
@@ -67,7 +72,11 @@ func (bm *BucketManager) getBucket(host string) *managedBucket {
 		bm.evictLFU()
 	}
 
-	tb := newTokenBucket(bm.capacity, bm.refillRate)
+	limits := bm.limitsFor(host)
+	tb := newTokenBucket(limits.capacity, limits.refillRate)
+	if delay, ok := bm.crawlDelays[host]; ok {
+		tb.applyCrawlDelay(delay)
+	}
 	mb := &managedBucket{
 		bucket:     tb,
 		usageCount: 1,
@@ -92,18 +101,80 @@ func (bm *BucketManager) evictLFU() {
 	}
 }
 
-// Wait blocks until a token is available for the given host.
+// Wait blocks until a token is available for the given host, and until a request
+// to the host ends if it has reached its max concurrency. Every call must be
+// followed by a call to Release once the request is done.
 func (bm *BucketManager) Wait(host string) time.Duration {
 	start := time.Now()
+	bm.acquireSlot(host)
 	mb := bm.getBucket(host)
 	mb.bucket.Wait()
 	return time.Since(start)
 }
 
-// AdjustOnFailure applies failure adjustments for the given host's bucket.
-func (bm *BucketManager) AdjustOnFailure(host string, statusCode int) {
+// Release ends a request to the given host started with Wait.
+func (bm *BucketManager) Release(host string) {
+	bm.mu.Lock()
+	hs, ok := bm.slots[host]
+	bm.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	if hs.sem != nil {
+		<-hs.sem
+	}
+
+	bm.mu.Lock()
+	hs.refs--
+	if hs.refs == 0 {
+		delete(bm.slots, host)
+	}
+	bm.mu.Unlock()
+}
+
+// hostSlots limits the number of requests in flight to a host. It lives as long as
+// requests to the host are in flight or waiting, so the limit of a host changed by
+// SetRules applies once its current requests are done.
+type hostSlots struct {
+	sem  chan struct{} // nil if the host has no max concurrency
+	refs int           // requests in flight or waiting for a slot
+}
+
+func (bm *BucketManager) acquireSlot(host string) {
+	bm.mu.Lock()
+	hs, ok := bm.slots[host]
+	if !ok {
+		hs = &hostSlots{}
+		if limits := bm.limitsFor(host); limits.maxConcurrency > 0 {
+			hs.sem = make(chan struct{}, limits.maxConcurrency)
+		}
+		bm.slots[host] = hs
+	}
+	hs.refs++
+	bm.mu.Unlock()
+
+	if hs.sem != nil {
+		hs.sem <- struct{}{}
+	}
+}
+
+// AdjustOnFailure applies failure adjustments for the given host's bucket,
+// it returns true if the host was penalized.
+func (bm *BucketManager) AdjustOnFailure(host string, statusCode int) bool {
+	mb := bm.getBucket(host)
+	return mb.bucket.adjustOnFailure(statusCode)
+}
+
+// PenalizeFor stops sending requests to the given host for delay, e.g. from a
+// Retry-After header, on top of the penalties of AdjustOnFailure.
+func (bm *BucketManager) PenalizeFor(host string, delay time.Duration) {
+	if delay <= 0 {
+		return
+	}
+
 	mb := bm.getBucket(host)
-	mb.bucket.adjustOnFailure(statusCode)
+	mb.bucket.penalizeFor(delay)
 }
 
 // OnSuccess signals success for the given host's bucket.
@@ -112,6 +183,25 @@ func (bm *BucketManager) OnSuccess(host string) {
 	mb.bucket.onSuccess()
 }
 
+// SetCrawlDelay slows down the given host's bucket so that at most one request
+// is made every delay (e.g. from a robots.txt Crawl-delay). Delays above
+// maxCrawlDelay are capped, and delays shorter than the refill rate allows are ignored.
+func (bm *BucketManager) SetCrawlDelay(host string, delay time.Duration) {
+	if delay <= 0 {
+		return
+	}
+	delay = min(delay, maxCrawlDelay)
+
+	bm.mu.Lock()
+	bm.crawlDelays[host] = delay
+	mb, ok := bm.buckets[host]
+	bm.mu.Unlock()
+
+	if ok {
+		mb.bucket.applyCrawlDelay(delay)
+	}
+}
+
 // cleanupLoop runs periodically to remove buckets that haven't been accessed
 // for a period longer than cleanupFreq.
 func (bm *BucketManager) cleanupLoop() {
@@ -136,7 +136,9 @@ func TestAdjustOnFailureAndOnSuccess(t *testing.T) {
 	}
 
 	// Apply a 429 error.
-	bm.AdjustOnFailure(host, 429)
+	if !bm.AdjustOnFailure(host, 429) {
+		t.Error("expected the host to be penalized after 429")
+	}
 	bm.mu.Lock()
 	failureAfter429 := bm.buckets[host].bucket.failureCount
 	refillRateAfter429 := bm.buckets[host].bucket.refillRate
@@ -256,3 +258,43 @@ func TestConcurrentSameHost(t *testing.T) {
 		t.Errorf("expected exactly 1 bucket for host %s, got %d", host, bucketCount)
 	}
 }
+
+func TestSetCrawlDelay(t *testing.T) {
+	ctx := context.Background()
+	bm := NewBucketManager(ctx, 10, 10, 5, 1*time.Second)
+	defer bm.Close()
+
+	// Existing bucket is slowed down
+	bm.Wait("slow.com")
+	bm.SetCrawlDelay("slow.com", 2*time.Second)
+
+	bm.mu.Lock()
+	tb := bm.buckets["slow.com"].bucket
+	bm.mu.Unlock()
+	if tb.capacity != 1 || tb.idealRate != 0.5 || tb.refillRate != 0.5 {
+		t.Fatalf("expected capacity 1 and rate 0.5, got capacity %f, ideal rate %f, refill rate %f", tb.capacity, tb.idealRate, tb.refillRate)
+	}
+
+	// The delay survives eviction
+	bm.mu.Lock()
+	delete(bm.buckets, "slow.com")
+	bm.mu.Unlock()
+	tb = bm.getBucket("slow.com").bucket
+	if tb.idealRate != 0.5 {
+		t.Fatalf("expected crawl delay to be applied to the new bucket, got ideal rate %f", tb.idealRate)
+	}
+
+	// A delay faster than the refill rate is ignored
+	bm.SetCrawlDelay("fast.com", 10*time.Millisecond)
+	tb = bm.getBucket("fast.com").bucket
+	if tb.idealRate != 5 || tb.capacity != 10 {
+		t.Fatalf("expected bucket to be untouched, got capacity %f, ideal rate %f", tb.capacity, tb.idealRate)
+	}
+
+	// Huge delays are capped
+	bm.SetCrawlDelay("capped.com", time.Hour)
+	tb = bm.getBucket("capped.com").bucket
+	if math.Abs(tb.idealRate-1/maxCrawlDelay.Seconds()) > 1e-9 {
+		t.Fatalf("expected crawl delay to be capped to %s, got ideal rate %f", maxCrawlDelay, tb.idealRate)
+	}
+}
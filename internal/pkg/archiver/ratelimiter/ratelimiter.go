@@ -18,6 +18,9 @@ const (
 
 	// Recovery factor controls how fast we restore the refill rate.
 	recoveryFactor = 0.1
+
+	// Maximum delay between two requests we accept from a Crawl-delay.
+	maxCrawlDelay = time.Minute
 )
 
 // tokenBucket implements a token bucket with penalty and recovery.
@@ -49,6 +52,23 @@ func newTokenBucket(capacity, refillRate float64) *tokenBucket {
 	}
 }
 
+// applyCrawlDelay caps the bucket to one request every delay, if that is slower
+// than its current ideal rate.
+func (tb *tokenBucket) applyCrawlDelay(delay time.Duration) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	rate := 1 / delay.Seconds()
+	if rate >= tb.idealRate {
+		return
+	}
+
+	tb.capacity = 1
+	tb.tokens = math.Min(tb.tokens, 1)
+	tb.idealRate = rate
+	tb.refillRate = math.Min(tb.refillRate, rate)
+}
+
 // Wait blocks until a token is available.
 func (tb *tokenBucket) Wait() {
 	for {
@@ -87,3 +107,19 @@ func (tb *tokenBucket) refill() {
 		tb.lastRefill = now
 	}
 }
+
+// setLimits changes the capacity and ideal refill rate of the bucket, a bucket
+// recovering from failures keeps its reduced refill rate if it is lower.
+func (tb *tokenBucket) setLimits(capacity, refillRate float64) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	recovered := tb.refillRate >= tb.idealRate
+
+	tb.capacity = capacity
+	tb.tokens = math.Min(tb.tokens, capacity)
+	tb.idealRate = refillRate
+	if recovered || tb.refillRate > refillRate {
+		tb.refillRate = refillRate
+	}
+}
@@ -225,3 +225,26 @@ func TestOnSuccessDuringPenalty(t *testing.T) {
 		t.Errorf("expected penaltyUntil to remain %v, got %v", penaltyUntil, tb.penaltyUntil)
 	}
 }
+
+// TestPenalizeFor tests that penalizeFor extends the penalty period but never shortens it.
+func TestPenalizeFor(t *testing.T) {
+	tb := newTokenBucket(10, 5)
+
+	currentTime := time.Now()
+	tb.nowFunc = func() time.Time { return currentTime }
+
+	tb.penalizeFor(2 * time.Minute)
+	if tb.tokens != 0 {
+		t.Errorf("expected tokens to be 0 after penalizeFor, got %f", tb.tokens)
+	}
+	if !tb.penaltyUntil.Equal(currentTime.Add(2 * time.Minute)) {
+		t.Errorf("expected penaltyUntil to be in 2 minutes, got %v", tb.penaltyUntil.Sub(currentTime))
+	}
+
+	// A 429 penalty shorter than the server delay doesn't shorten it
+	tb.adjustOnFailure(429)
+	tb.penalizeFor(10 * time.Second)
+	if !tb.penaltyUntil.Equal(currentTime.Add(2 * time.Minute)) {
+		t.Errorf("expected penaltyUntil to stay in 2 minutes, got %v", tb.penaltyUntil.Sub(currentTime))
+	}
+}
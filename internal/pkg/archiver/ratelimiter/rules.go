@@ -0,0 +1,153 @@
+package ratelimiter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Rule overrides the bucket of the hosts it matches, the limits it doesn't set (0)
+// are the default ones. Host is a glob matched with path.Match (e.g. *.example.edu),
+// Regex a regular expression matched against the host, only one of them is set.
+type Rule struct {
+	Host           string  `json:"host,omitempty"`
+	Regex          string  `json:"regex,omitempty"`
+	Capacity       float64 `json:"capacity,omitempty"`
+	RefillRate     float64 `json:"refill-rate,omitempty"`
+	MaxConcurrency int     `json:"max-concurrency,omitempty"`
+
+	regex *regexp.Regexp
+}
+
+// rulesFile is the JSON document read from --rate-limit-rules-file, the first rule
+// matching a host applies
+type rulesFile struct {
+	Rules []Rule `json:"rules"`
+}
+
+// hostLimits are the limits applied to a host
+type hostLimits struct {
+	capacity       float64
+	refillRate     float64
+	maxConcurrency int // 0 means no limit
+}
+
+// LoadRules reads and compiles the rules of a rate limit rules file
+func LoadRules(file string) ([]Rule, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var rf rulesFile
+	if err := json.Unmarshal(data, &rf); err != nil {
+		return nil, err
+	}
+
+	for i := range rf.Rules {
+		if err := rf.Rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+	}
+
+	return rf.Rules, nil
+}
+
+func (r *Rule) compile() error {
+	if (r.Host == "") == (r.Regex == "") {
+		return fmt.Errorf("%w: exactly one of host and regex must be set", ErrInvalidRule)
+	}
+
+	if r.Capacity < 0 || r.RefillRate < 0 || r.MaxConcurrency < 0 {
+		return fmt.Errorf("%w: limits can't be negative", ErrInvalidRule)
+	}
+
+	// A bucket holding less than a token never lets a request through
+	if r.Capacity > 0 && r.Capacity < 1 {
+		return fmt.Errorf("%w: capacity can't be lower than 1", ErrInvalidRule)
+	}
+
+	if r.Host != "" {
+		r.Host = strings.ToLower(r.Host)
+		if _, err := path.Match(r.Host, ""); err != nil {
+			return fmt.Errorf("%w: host %q: %w", ErrInvalidRule, r.Host, err)
+		}
+		return nil
+	}
+
+	regex, err := regexp.Compile(r.Regex)
+	if err != nil {
+		return fmt.Errorf("%w: regex %q: %w", ErrInvalidRule, r.Regex, err)
+	}
+	r.regex = regex
+
+	return nil
+}
+
+func (r *Rule) match(host string) bool {
+	if r.regex != nil {
+		return r.regex.MatchString(host)
+	}
+
+	matched, _ := path.Match(r.Host, host)
+	return matched
+}
+
+// SetRules replaces the per-host rules, the buckets already created are updated
+// in place so that the penalties they are serving are kept
+func (bm *BucketManager) SetRules(rules []Rule) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	bm.rules = rules
+
+	for host, mb := range bm.buckets {
+		limits := bm.limitsFor(host)
+		mb.bucket.setLimits(limits.capacity, limits.refillRate)
+		if delay, ok := bm.crawlDelays[host]; ok {
+			mb.bucket.applyCrawlDelay(delay)
+		}
+	}
+}
+
+// GetRules returns the per-host rules in use
+func (bm *BucketManager) GetRules() []Rule {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	return bm.rules
+}
+
+// limitsFor returns the limits of the host from the first rule matching it, bm.mu must be held
+func (bm *BucketManager) limitsFor(host string) hostLimits {
+	limits := hostLimits{
+		capacity:   bm.capacity,
+		refillRate: bm.refillRate,
+	}
+
+	hostname := strings.ToLower(host)
+	if h, _, err := net.SplitHostPort(hostname); err == nil {
+		hostname = h
+	}
+
+	for i := range bm.rules {
+		if !bm.rules[i].match(hostname) {
+			continue
+		}
+
+		if bm.rules[i].Capacity > 0 {
+			limits.capacity = bm.rules[i].Capacity
+		}
+		if bm.rules[i].RefillRate > 0 {
+			limits.refillRate = bm.rules[i].RefillRate
+		}
+		limits.maxConcurrency = bm.rules[i].MaxConcurrency
+		break
+	}
+
+	return limits
+}
@@ -0,0 +1,185 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+		wantErr error
+	}{
+		{
+			name: "valid rules",
+			content: `{"rules": [
+				{"host": "*.example.edu", "capacity": 2, "refill-rate": 0.5, "max-concurrency": 1},
+				{"regex": "^cdn[0-9]+\\.example\\.com$", "capacity": 500, "refill-rate": 200}
+			]}`,
+			want: 2,
+		},
+		{
+			name:    "host and regex",
+			content: `{"rules": [{"host": "example.com", "regex": "example"}]}`,
+			wantErr: ErrInvalidRule,
+		},
+		{
+			name:    "no host nor regex",
+			content: `{"rules": [{"capacity": 10}]}`,
+			wantErr: ErrInvalidRule,
+		},
+		{
+			name:    "invalid regex",
+			content: `{"rules": [{"regex": "("}]}`,
+			wantErr: ErrInvalidRule,
+		},
+		{
+			name:    "negative limit",
+			content: `{"rules": [{"host": "example.com", "refill-rate": -1}]}`,
+			wantErr: ErrInvalidRule,
+		},
+		{
+			name:    "capacity lower than a token",
+			content: `{"rules": [{"host": "example.com", "capacity": 0.5}]}`,
+			wantErr: ErrInvalidRule,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "rules.json")
+			if err := os.WriteFile(file, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			rules, err := LoadRules(file)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("LoadRules() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadRules() error = %v", err)
+			}
+			if len(rules) != tt.want {
+				t.Errorf("LoadRules() returned %d rules, want %d", len(rules), tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitsFor(t *testing.T) {
+	bm := NewBucketManager(context.Background(), 10, 10, 5, time.Minute)
+	defer bm.Close()
+
+	rules := []Rule{
+		{Host: "*.example.edu", Capacity: 2, RefillRate: 0.5, MaxConcurrency: 1},
+		{Regex: `^cdn[0-9]+\.example\.com$`, RefillRate: 200},
+		{Host: "*.edu", Capacity: 3},
+	}
+	for i := range rules {
+		if err := rules[i].compile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bm.SetRules(rules)
+
+	tests := []struct {
+		host string
+		want hostLimits
+	}{
+		{"www.example.edu", hostLimits{capacity: 2, refillRate: 0.5, maxConcurrency: 1}},
+		{"WWW.Example.EDU:8443", hostLimits{capacity: 2, refillRate: 0.5, maxConcurrency: 1}},
+		{"cdn12.example.com", hostLimits{capacity: 10, refillRate: 200}},
+		{"other.edu", hostLimits{capacity: 3, refillRate: 5}},
+		{"example.com", hostLimits{capacity: 10, refillRate: 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			bm.mu.Lock()
+			got := bm.limitsFor(tt.host)
+			bm.mu.Unlock()
+
+			if got != tt.want {
+				t.Errorf("limitsFor(%q) = %+v, want %+v", tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetRulesUpdatesBuckets(t *testing.T) {
+	bm := NewBucketManager(context.Background(), 10, 10, 5, time.Minute)
+	defer bm.Close()
+
+	bm.Wait("fragile.example.edu")
+	bm.Release("fragile.example.edu")
+
+	rules := []Rule{{Host: "*.example.edu", Capacity: 2, RefillRate: 1}}
+	if err := rules[0].compile(); err != nil {
+		t.Fatal(err)
+	}
+	bm.SetRules(rules)
+
+	bm.mu.Lock()
+	tb := bm.buckets["fragile.example.edu"].bucket
+	bm.mu.Unlock()
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	if tb.capacity != 2 || tb.idealRate != 1 || tb.refillRate != 1 || tb.tokens > 2 {
+		t.Errorf("bucket not updated: capacity %v, ideal rate %v, refill rate %v, tokens %v", tb.capacity, tb.idealRate, tb.refillRate, tb.tokens)
+	}
+}
+
+func TestMaxConcurrency(t *testing.T) {
+	bm := NewBucketManager(context.Background(), 10, 100, 100, time.Minute)
+	defer bm.Close()
+
+	rules := []Rule{{Host: "example.edu", MaxConcurrency: 1}}
+	if err := rules[0].compile(); err != nil {
+		t.Fatal(err)
+	}
+	bm.SetRules(rules)
+
+	bm.Wait("example.edu")
+
+	acquired := make(chan struct{})
+	go func() {
+		bm.Wait("example.edu")
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second request started while the first one is in flight")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Other hosts aren't limited
+	bm.Wait("example.org")
+	bm.Wait("example.org")
+	bm.Release("example.org")
+	bm.Release("example.org")
+
+	bm.Release("example.edu")
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("second request didn't start once the first one was released")
+	}
+	bm.Release("example.edu")
+
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	if len(bm.slots) != 0 {
+		t.Errorf("expected no slots left, got %d", len(bm.slots))
+	}
+}
@@ -0,0 +1,91 @@
+// Package recordid keeps track of the WARC-Record-ID of the response records written by the
+// WARC writing HTTP clients, gowarc generating them without handing them back to the caller.
+package recordid
+
+import (
+	"container/list"
+	"sync"
+
+	warc "github.com/internetarchive/gowarc"
+)
+
+// Maximum number of record IDs waiting to be taken, the oldest ones are dropped past it
+const maxPending = 10000
+
+type pendingID struct {
+	feedbackChan chan struct{}
+	ID           string
+}
+
+var (
+	mu      sync.Mutex
+	pending = make(map[chan struct{}]*list.Element)
+	order   = list.New()
+)
+
+// Relay puts a relay in front of the WARC writers of client, that notes the WARC-Record-ID of the
+// response or revisit record of the batches having a feedback channel. Closing the client closes
+// the WARC writers through the relay.
+func Relay(client *warc.CustomHTTPClient) {
+	writers := client.WARCWriter
+	batches := make(chan *warc.RecordBatch, cap(writers))
+	client.WARCWriter = batches
+
+	go func() {
+		defer close(writers)
+
+		for batch := range batches {
+			note(batch)
+			writers <- batch
+		}
+	}()
+}
+
+// Take returns the WARC-Record-ID of the response or revisit record of the batch that signals
+// feedbackChan once written, and forgets it. It is empty if the batch wasn't relayed yet or had none.
+func Take(feedbackChan chan struct{}) string {
+	if feedbackChan == nil {
+		return ""
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	elem, ok := pending[feedbackChan]
+	if !ok {
+		return ""
+	}
+
+	delete(pending, feedbackChan)
+	order.Remove(elem)
+
+	return elem.Value.(*pendingID).ID
+}
+
+func note(batch *warc.RecordBatch) {
+	if batch.FeedbackChan == nil {
+		return
+	}
+
+	var ID string
+	for _, record := range batch.Records {
+		if recordType := record.Header.Get("WARC-Type"); recordType == "response" || recordType == "revisit" {
+			ID = record.Header.Get("WARC-Record-ID")
+			break
+		}
+	}
+	if ID == "" {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// The batches whose feedback channel nobody waits on are never taken
+	for order.Len() >= maxPending {
+		oldest := order.Remove(order.Front()).(*pendingID)
+		delete(pending, oldest.feedbackChan)
+	}
+
+	pending[batch.FeedbackChan] = order.PushBack(&pendingID{feedbackChan: batch.FeedbackChan, ID: ID})
+}
@@ -0,0 +1,85 @@
+package recordid
+
+import (
+	"testing"
+
+	warc "github.com/internetarchive/gowarc"
+)
+
+func newBatch(feedbackChan chan struct{}, types ...string) *warc.RecordBatch {
+	batch := warc.NewRecordBatch(feedbackChan)
+	for _, recordType := range types {
+		record := warc.NewRecord("", false)
+		record.Header.Set("WARC-Type", recordType)
+		record.Header.Set("WARC-Record-ID", "<urn:uuid:"+recordType+">")
+		batch.Records = append(batch.Records, record)
+	}
+	return batch
+}
+
+func TestRelay(t *testing.T) {
+	writers := make(chan *warc.RecordBatch, 4)
+	client := &warc.CustomHTTPClient{WARCWriter: writers}
+	Relay(client)
+
+	responseFeedback := make(chan struct{}, 1)
+	revisitFeedback := make(chan struct{}, 1)
+	metadataFeedback := make(chan struct{}, 1)
+
+	batches := []*warc.RecordBatch{
+		newBatch(responseFeedback, "request", "response"),
+		newBatch(revisitFeedback, "request", "revisit"),
+		newBatch(metadataFeedback, "metadata"),
+		newBatch(nil, "request", "response"),
+	}
+
+	for _, batch := range batches {
+		client.WARCWriter <- batch
+	}
+	close(client.WARCWriter)
+
+	for i := range batches {
+		if got := <-writers; got != batches[i] {
+			t.Fatalf("batch %d: relayed out of order", i)
+		}
+	}
+	if _, open := <-writers; open {
+		t.Fatal("expected the WARC writers to be closed with the relay")
+	}
+
+	tests := []struct {
+		name         string
+		feedbackChan chan struct{}
+		want         string
+	}{
+		{"response", responseFeedback, "<urn:uuid:response>"},
+		{"revisit", revisitFeedback, "<urn:uuid:revisit>"},
+		{"metadata only", metadataFeedback, ""},
+		{"no feedback channel", nil, ""},
+		{"taken twice", responseFeedback, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Take(tt.feedbackChan); got != tt.want {
+				t.Errorf("Take() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxPending(t *testing.T) {
+	first := make(chan struct{}, 1)
+	note(newBatch(first, "response"))
+
+	for range maxPending {
+		note(newBatch(make(chan struct{}, 1), "response"))
+	}
+
+	if got := Take(first); got != "" {
+		t.Errorf("expected the oldest record ID to be dropped, got %q", got)
+	}
+	if order.Len() != maxPending || len(pending) != maxPending {
+		t.Errorf("expected %d pending record IDs, got %d in the list and %d in the map", maxPending, order.Len(), len(pending))
+	}
+}
@@ -1,30 +1,21 @@
 package archiver
 
 import (
+	"io"
+	"net/http"
 	"path"
+	"sync"
 
 	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/discard"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/discard/discarder/rules"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/proxypool"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/recordid"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/utils"
 	warc "github.com/internetarchive/gowarc"
 )
 
 func startWARCWriter() error {
-	// Configure WARC rotator settings
-	rotatorSettings := warc.NewRotatorSettings()
-	rotatorSettings.Prefix = config.Get().WARCPrefix
-	rotatorSettings.WARCWriterPoolSize = config.Get().WARCPoolSize
-	rotatorSettings.WARCSize = float64(config.Get().WARCSize)
-	rotatorSettings.OutputDirectory = path.Join(config.Get().JobPath, "warcs")
-
-	version := utils.GetVersion()
-	rotatorSettings.WarcinfoContent.Set("software", "Zeno/"+version.Version+" warc/"+version.WarcVersion)
-	if config.Get().WARCOperator != "" {
-		rotatorSettings.WarcinfoContent.Set("operator", config.Get().WARCOperator)
-	}
-	if config.Get().Headless {
-		rotatorSettings.WarcinfoContent.Set("zeno-headless", "true")
-	}
 	// Configure WARC dedupe settings
 	dedupeOptions := warc.DedupeOptions{LocalDedupe: !config.Get().DisableLocalDedupe, SizeThreshold: config.Get().WARCDedupeSize, DedupeCacheSize: config.Get().WARCDedupeCacheSize}
 	if config.Get().CDXDedupeServer != "" {
@@ -39,13 +30,19 @@ func startWARCWriter() error {
 	}
 
 	// Configure WARC discard hook
+	if config.Get().DiscardRulesFile != "" {
+		if err := ReloadDiscardRules(); err != nil {
+			return err
+		}
+	}
+
 	discardBuilder := discard.NewBuilder()
 	discardBuilder.AddDefaultHooks()
 	discardHooksChain := discardBuilder.Build()
 
 	// Configure WARC settings
 	WARCSettings := warc.HTTPClientSettings{
-		RotatorSettings:  rotatorSettings,
+		RotatorSettings:  newRotatorSettings(config.Get().WARCPrefix, nil),
 		DedupeOptions:    dedupeOptions,
 		DecompressBody:   true,
 		DiscardHook:      discardHooksChain,
@@ -63,48 +60,106 @@ func startWARCWriter() error {
 
 	// Instantiate WARC client
 	var err error
-	if config.Get().Proxy != "" {
+	if config.Get().ProxyPoolFile != "" {
+		poolFile, err := proxypool.LoadFile(config.Get().ProxyPoolFile)
+		if err != nil {
+			logger.Error("unable to load proxy pool file", "err", err.Error(), "func", "archiver.startWARCWriter")
+			return err
+		}
+
+		// Each proxy has its own WARC files, their warcinfo record names the proxy the records went through
+		globalArchiver.proxyPool, err = proxypool.New(poolFile, func(proxy *proxypool.Proxy) (*warc.CustomHTTPClient, error) {
+			proxiedWARCSettings := WARCSettings
+			proxiedWARCSettings.Proxy = proxy.URL.String()
+			proxiedWARCSettings.RotatorSettings = newRotatorSettings(config.Get().WARCPrefix+"-"+proxy.Name, proxy)
+			return newWARCWritingHTTPClient(proxiedWARCSettings)
+		})
+		if err != nil {
+			logger.Error("unable to init proxy pool", "err", err.Error(), "func", "archiver.startWARCWriter")
+			return err
+		}
+
+		globalArchiver.proxyPool.StartHealthChecks()
+		logger.Info("proxy pool started", "proxies", len(globalArchiver.proxyPool.Proxies()))
+	} else if config.Get().Proxy != "" {
 		proxiedWARCSettings := WARCSettings
 		proxiedWARCSettings.Proxy = config.Get().Proxy
-		globalArchiver.ClientWithProxy, err = warc.NewWARCWritingHTTPClient(proxiedWARCSettings)
+		globalArchiver.ClientWithProxy, err = newWARCWritingHTTPClient(proxiedWARCSettings)
 		if err != nil {
 			logger.Error("unable to init proxied WARC HTTP client", "err", err.Error(), "func", "archiver.startWARCWriter")
 			return err
 		}
-
-		go func() {
-			for err := range globalArchiver.ClientWithProxy.ErrChan {
-				logger.Error("WARC writer error", "err", err.Err.Error(), "func", err.Func)
-			}
-		}()
 	}
 
-	// Even if a proxied client has been set, we want to create an non-proxied one
-	if config.Get().Proxy == "" {
-		globalArchiver.Client, err = warc.NewWARCWritingHTTPClient(WARCSettings)
+	// The non-proxied client is only needed without proxy, or for the hosts the proxy pool routes directly
+	if (config.Get().Proxy == "" && config.Get().ProxyPoolFile == "") || (globalArchiver.proxyPool != nil && globalArchiver.proxyPool.NeedsDirect()) {
+		globalArchiver.Client, err = newWARCWritingHTTPClient(WARCSettings)
 		if err != nil {
 			logger.Error("unable to init WARC HTTP client", "err", err.Error(), "func", "archiver.startWARCWriter")
 			return err
 		}
+	}
 
-		go func() {
-			for err := range globalArchiver.Client.ErrChan {
-				logger.Error("WARC writer error", "err", err.Err.Error(), "func", err.Func)
-			}
-		}()
+	return nil
+}
+
+// ReloadDiscardRules loads the rules of --discard-rules-file again, the rules in use are kept if the file is invalid
+func ReloadDiscardRules() error {
+	if err := rules.Load(config.Get().DiscardRulesFile); err != nil {
+		logger.Error("unable to load discard rules, keeping the current ones", "err", err.Error(), "file", config.Get().DiscardRulesFile)
+		return err
 	}
 
-	// Set the timeouts
-	if config.Get().HTTPTimeout > 0 {
-		if globalArchiver.Client != nil {
-			globalArchiver.Client.Timeout = config.Get().HTTPTimeout
-		}
+	logger.Info("discard rules loaded", "file", config.Get().DiscardRulesFile)
+
+	return nil
+}
+
+// newRotatorSettings returns the WARC rotator settings, the warcinfo record of the WARC files
+// written through a proxy of the pool names it, without its credentials
+func newRotatorSettings(prefix string, proxy *proxypool.Proxy) *warc.RotatorSettings {
+	rotatorSettings := warc.NewRotatorSettings()
+	rotatorSettings.Prefix = prefix
+	rotatorSettings.WARCWriterPoolSize = config.Get().WARCPoolSize
+	rotatorSettings.WARCSize = float64(config.Get().WARCSize)
+	rotatorSettings.OutputDirectory = path.Join(config.Get().JobPath, "warcs")
+
+	version := utils.GetVersion()
+	rotatorSettings.WarcinfoContent.Set("software", "Zeno/"+version.Version+" warc/"+version.WarcVersion)
+	if config.Get().WARCOperator != "" {
+		rotatorSettings.WarcinfoContent.Set("operator", config.Get().WARCOperator)
+	}
+	if config.Get().Headless {
+		rotatorSettings.WarcinfoContent.Set("zeno-headless", "true")
+	}
+	if proxy != nil {
+		rotatorSettings.WarcinfoContent.Set("zeno-proxy", proxy.Name+" "+proxy.Redacted())
+	}
+
+	return rotatorSettings
+}
+
+// newWARCWritingHTTPClient creates a WARC writing HTTP client, logs its errors and sets its timeout
+func newWARCWritingHTTPClient(settings warc.HTTPClientSettings) (*warc.CustomHTTPClient, error) {
+	client, err := warc.NewWARCWritingHTTPClient(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	// The archivers get the WARC-Record-ID of their captures from the relay
+	recordid.Relay(client)
 
-		if globalArchiver.ClientWithProxy != nil {
-			globalArchiver.ClientWithProxy.Timeout = config.Get().HTTPTimeout
+	go func() {
+		for err := range client.ErrChan {
+			logger.Error("WARC writer error", "err", err.Err.Error(), "func", err.Func)
 		}
+	}()
+
+	if config.Get().HTTPTimeout > 0 {
+		client.Timeout = config.Get().HTTPTimeout
 	}
-	return nil
+
+	return client, nil
 }
 
 func GetClients() (clients []*warc.CustomHTTPClient) {
@@ -114,9 +169,122 @@ func GetClients() (clients []*warc.CustomHTTPClient) {
 		}
 	}
 
+	if globalArchiver.proxyPool != nil {
+		for _, proxy := range globalArchiver.proxyPool.Proxies() {
+			clients = append(clients, proxy.Client)
+		}
+	}
+
 	return clients
 }
 
+// RoutedClient sends each request with the WARC-writing client its host is routed to,
+// through the proxy pool if one is set
+type RoutedClient struct{}
+
+// Do sends the request, the WARC writers are kept open until the body of the response is
+// closed, as its records are only written once it is read
+func (RoutedClient) Do(req *http.Request) (*http.Response, error) {
+	if globalArchiver == nil {
+		return nil, ErrArchiverNotRunning
+	}
+
+	globalArchiver.clientsMu.RLock()
+
+	if globalArchiver.clientsClosed {
+		globalArchiver.clientsMu.RUnlock()
+		return nil, ErrArchiverNotRunning
+	}
+
+	client, _, err := globalArchiver.clientFor(req.URL.Hostname())
+	if err != nil {
+		globalArchiver.clientsMu.RUnlock()
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		globalArchiver.clientsMu.RUnlock()
+		return nil, err
+	}
+
+	resp.Body = &unlockingBody{ReadCloser: resp.Body, unlock: globalArchiver.clientsMu.RUnlock}
+
+	return resp, nil
+}
+
+// unlockingBody releases the lock on the WARC writers when the body is closed
+type unlockingBody struct {
+	io.ReadCloser
+	unlock func()
+	once   sync.Once
+}
+
+func (b *unlockingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.unlock)
+	return err
+}
+
+// WriteMetadataRecord writes a WARC metadata record with the given application/warc-fields
+// payload in the WARC files of the client that fetched the target, proxyName being the proxy
+// of the pool it went through (empty if it didn't). concurrentTo is the WARC-Record-ID of the
+// response or revisit record of the target, left out if empty. It blocks until the record is written.
+func WriteMetadataRecord(targetURI, proxyName, concurrentTo, fields string) error {
+	if globalArchiver == nil {
+		return ErrArchiverNotRunning
+	}
+
+	globalArchiver.clientsMu.RLock()
+	defer globalArchiver.clientsMu.RUnlock()
+
+	if globalArchiver.clientsClosed {
+		return ErrArchiverNotRunning
+	}
+
+	client := globalArchiver.fetchingClient(proxyName)
+	if client == nil {
+		return ErrArchiverNotRunning
+	}
+
+	record := warc.NewRecord(client.TempDir, client.FullOnDisk)
+	record.Header.Set("WARC-Type", "metadata")
+	record.Header.Set("WARC-Target-URI", targetURI)
+	record.Header.Set("Content-Type", "application/warc-fields")
+	if concurrentTo != "" {
+		record.Header.Set("WARC-Concurrent-To", concurrentTo)
+	}
+
+	if _, err := io.WriteString(record.Content, fields); err != nil {
+		record.Content.Close()
+		return err
+	}
+
+	batch := warc.NewRecordBatch(make(chan struct{}, 1))
+	batch.Records = append(batch.Records, record)
+
+	client.WARCWriter <- batch
+	<-batch.FeedbackChan
+
+	return nil
+}
+
+// fetchingClient returns the client the captures made through the proxy of the pool named
+// proxyName were written with, or without proxy of the pool if proxyName is empty
+func (a *archiver) fetchingClient(proxyName string) *warc.CustomHTTPClient {
+	if proxyName != "" && a.proxyPool != nil {
+		if proxy := a.proxyPool.Get(proxyName); proxy != nil {
+			return proxy.Client
+		}
+	}
+
+	if a.Client != nil {
+		return a.Client
+	}
+
+	return a.ClientWithProxy
+}
+
 type WARCStats struct {
 	WARCWritingQueueSize         int64
 	WARCTotalBytesArchived       int64
@@ -131,27 +299,29 @@ type WARCStats struct {
 func GetStats() WARCStats {
 	var stats WARCStats
 
-	for _, c := range []*warc.CustomHTTPClient{globalArchiver.Client, globalArchiver.ClientWithProxy} {
-		if c != nil {
-			stats.WARCWritingQueueSize += int64(c.WaitGroup.Size())
-			stats.WARCTotalBytesArchived += c.DataTotal.Load()
-			stats.CDXDedupeTotalBytes += c.CDXDedupeTotalBytes.Load()
-			stats.DoppelgangerDedupeTotalBytes += c.DoppelgangerDedupeTotalBytes.Load()
-			stats.LocalDedupeTotalBytes += c.LocalDedupeTotalBytes.Load()
-			stats.CDXDedupeTotal += c.CDXDedupeTotal.Load()
-			stats.DoppelgangerDedupeTotal += c.DoppelgangerDedupeTotal.Load()
-			stats.LocalDedupeTotal += c.LocalDedupeTotal.Load()
-		}
+	clients := GetClients()
+	for _, c := range clients {
+		stats.WARCWritingQueueSize += int64(c.WaitGroup.Size())
+	}
+
+	// The byte and dedupe counters are package variables of gowarc, all the clients point to the same ones
+	if len(clients) > 0 {
+		c := clients[0]
+		stats.WARCTotalBytesArchived = c.DataTotal.Load()
+		stats.CDXDedupeTotalBytes = c.CDXDedupeTotalBytes.Load()
+		stats.DoppelgangerDedupeTotalBytes = c.DoppelgangerDedupeTotalBytes.Load()
+		stats.LocalDedupeTotalBytes = c.LocalDedupeTotalBytes.Load()
+		stats.CDXDedupeTotal = c.CDXDedupeTotal.Load()
+		stats.DoppelgangerDedupeTotal = c.DoppelgangerDedupeTotal.Load()
+		stats.LocalDedupeTotal = c.LocalDedupeTotal.Load()
 	}
 	return stats
 }
 
 // This function is used in multiple places so it can't be replaced by GetStats()
 func GetWARCWritingQueueSize() (total int) {
-	for _, c := range []*warc.CustomHTTPClient{globalArchiver.Client, globalArchiver.ClientWithProxy} {
-		if c != nil {
-			total += c.WaitGroup.Size()
-		}
+	for _, c := range GetClients() {
+		total += c.WaitGroup.Size()
 	}
 
 	return total
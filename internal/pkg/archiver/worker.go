@@ -5,17 +5,22 @@ import (
 	"fmt"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dustin/go-humanize"
 	"github.com/gabriel-vasile/mimetype"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/general"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/headless"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/proxypool"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/quarantine"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/ratelimiter"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/controler/pause"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/preprocessor/budget"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/tracing"
 	"github.com/internetarchive/Zeno/v2/pkg/models"
 	warc "github.com/internetarchive/gowarc"
 )
@@ -36,6 +41,19 @@ type archiver struct {
 
 	Client          *warc.CustomHTTPClient
 	ClientWithProxy *warc.CustomHTTPClient
+
+	// proxyPool routes the requests through the proxies of --proxy-pool-file, each with its own client
+	proxyPool *proxypool.Pool
+
+	// clientsMu protects the WARC writers from being closed while a record is written outside of a request
+	clientsMu     sync.RWMutex
+	clientsClosed bool
+
+	// workersMu protects the stop channels of the workers, one per running worker
+	workersMu    sync.Mutex
+	workersStop  []chan struct{}
+	nextWorkerID int
+	busyWorkers  atomic.Int64
 }
 
 var (
@@ -68,12 +86,26 @@ func Start(inputChan, outputChan chan *models.Item) error {
 				config.Get().RateLimitRefillRate,
 				config.Get().RateLimitCleanupFrequency,
 			)
+			if config.Get().RateLimitRulesFile != "" {
+				if err := ReloadRateLimitRules(); err != nil {
+					onceErr = err
+					return
+				}
+			}
 			logger.Info("bucket manager started")
 		}
 		if config.Get().Headless {
 			headless.Start()
 			logger.Info("headless browser started")
 		}
+		if config.Get().QuarantineRulesFile != "" {
+			if err := quarantine.Load(config.Get().QuarantineRulesFile); err != nil {
+				logger.Error("unable to load quarantine rules", "err", err.Error(), "file", config.Get().QuarantineRulesFile)
+				onceErr = err
+				return
+			}
+			logger.Info("quarantine rules loaded", "file", config.Get().QuarantineRulesFile)
+		}
 
 		logger.Debug("initialized")
 
@@ -85,10 +117,7 @@ func Start(inputChan, outputChan chan *models.Item) error {
 
 		logger.Debug("WARC writer started")
 
-		for i := 0; i < config.Get().WorkersCount; i++ {
-			globalArchiver.wg.Add(1)
-			go globalArchiver.worker(strconv.Itoa(i))
-		}
+		globalArchiver.setWorkersCount(config.Get().WorkersCount)
 
 		logger.Info("started")
 	})
@@ -122,14 +151,20 @@ func Stop() {
 				}
 			}
 		}()
-		globalArchiver.Client.WaitGroup.Wait()
+		for _, client := range GetClients() {
+			client.WaitGroup.Wait()
+		}
 		stopLocalWatcher <- struct{}{}
 		logger.Debug("WARC writing finished")
-		globalArchiver.Client.Close()
-		if globalArchiver.ClientWithProxy != nil {
-			globalArchiver.ClientWithProxy.WaitGroup.Wait()
-			globalArchiver.ClientWithProxy.Close()
+		if globalArchiver.proxyPool != nil {
+			globalArchiver.proxyPool.Stop()
 		}
+		globalArchiver.clientsMu.Lock()
+		globalArchiver.clientsClosed = true
+		for _, client := range GetClients() {
+			client.Close()
+		}
+		globalArchiver.clientsMu.Unlock()
 
 		logger.Info("stopped")
 	}
@@ -150,7 +185,91 @@ func Stop() {
 	logger.Info("stopped config related contexts")
 }
 
-func (a *archiver) worker(workerID string) {
+// SetCrawlDelay applies a per-host Crawl-delay to the rate limiter, it is a no-op if rate limiting is disabled
+func SetCrawlDelay(host string, delay time.Duration) {
+	if globalBucketManager != nil {
+		globalBucketManager.SetCrawlDelay(host, delay)
+		logger.Debug("crawl delay set", "host", host, "delay", delay)
+	}
+}
+
+// ReloadRateLimitRules loads the per-host rules of --rate-limit-rules-file in the rate limiter,
+// the rules in use are kept if the file can't be loaded
+func ReloadRateLimitRules() error {
+	if globalBucketManager == nil {
+		return ErrRateLimitDisabled
+	}
+
+	rules, err := ratelimiter.LoadRules(config.Get().RateLimitRulesFile)
+	if err != nil {
+		logger.Error("unable to load rate limit rules, keeping the current ones", "err", err.Error(), "file", config.Get().RateLimitRulesFile)
+		return err
+	}
+
+	globalBucketManager.SetRules(rules)
+	logger.Info("rate limit rules loaded", "rules", len(rules), "file", config.Get().RateLimitRulesFile)
+
+	return nil
+}
+
+// GetRateLimitRules returns the per-host rules of the rate limiter
+func GetRateLimitRules() []ratelimiter.Rule {
+	if globalBucketManager == nil {
+		return nil
+	}
+
+	return globalBucketManager.GetRules()
+}
+
+// SetWorkersCount grows or shrinks the pool of archiver workers to count,
+// the workers that are stopped finish archiving their current seed first
+func SetWorkersCount(count int) {
+	if globalArchiver != nil {
+		globalArchiver.setWorkersCount(count)
+	}
+}
+
+// GetWorkersCount returns the number of archiver workers
+func GetWorkersCount() int {
+	if globalArchiver == nil {
+		return 0
+	}
+
+	globalArchiver.workersMu.Lock()
+	defer globalArchiver.workersMu.Unlock()
+
+	return len(globalArchiver.workersStop)
+}
+
+// GetBusyWorkersCount returns the number of archiver workers archiving a seed
+func GetBusyWorkersCount() int {
+	if globalArchiver == nil {
+		return 0
+	}
+
+	return int(globalArchiver.busyWorkers.Load())
+}
+
+func (a *archiver) setWorkersCount(count int) {
+	a.workersMu.Lock()
+	defer a.workersMu.Unlock()
+
+	for len(a.workersStop) < count {
+		stop := make(chan struct{})
+		a.workersStop = append(a.workersStop, stop)
+		a.wg.Add(1)
+		go a.worker(strconv.Itoa(a.nextWorkerID), stop)
+		a.nextWorkerID++
+	}
+
+	for len(a.workersStop) > count {
+		last := len(a.workersStop) - 1
+		close(a.workersStop[last])
+		a.workersStop = a.workersStop[:last]
+	}
+}
+
+func (a *archiver) worker(workerID string, stop chan struct{}) {
 	defer a.wg.Done()
 
 	logger := log.NewFieldedLogger(&log.Fields{
@@ -172,6 +291,9 @@ func (a *archiver) worker(workerID string) {
 		case <-a.ctx.Done():
 			logger.Debug("shutting down")
 			return
+		case <-stop:
+			logger.Debug("worker removed from the pool")
+			return
 		case <-controlChans.PauseCh:
 			logger.Debug("received pause event")
 			controlChans.ResumeCh <- struct{}{}
@@ -184,12 +306,18 @@ func (a *archiver) worker(workerID string) {
 					panic(fmt.Sprintf("seed consistency check failed with err: %s, seed id %s", err.Error(), seed.GetShortID()))
 				}
 
+				a.busyWorkers.Add(1)
+				tracing.StartStage(seed, tracing.StageArchiver)
+
 				if seed.GetStatus() != models.ItemPreProcessed && seed.GetStatus() != models.ItemGotRedirected && seed.GetStatus() != models.ItemGotChildren {
 					logger.Debug("skipping seed", "seed", seed.GetShortID(), "depth", seed.GetDepth(), "hops", seed.GetURL().GetHops(), "status", seed.GetStatus())
 				} else {
 					archive(workerID, seed)
 				}
 
+				tracing.EndStage(seed, tracing.StageArchiver)
+				a.busyWorkers.Add(-1)
+
 				select {
 				case <-a.ctx.Done():
 					logger.Debug("aborting seed due to stop", "seed", seed.GetShortID(), "depth", seed.GetDepth(), "hops", seed.GetURL().GetHops())
@@ -218,31 +346,92 @@ func archive(workerID string, seed *models.Item) {
 		panic(err)
 	}
 
-	var client *warc.CustomHTTPClient
-	if config.Get().Proxy != "" {
-		client = globalArchiver.ClientWithProxy
-	} else {
-		client = globalArchiver.Client
-	}
-
 	for i := range items {
 		if items[i].GetStatus() != models.ItemPreProcessed {
 			logger.Debug("skipping item", "item_id", items[i].GetShortID(), "status", items[i].GetStatus())
 			continue
 		}
 
+		// Seeds with many assets can go over their size or duration budget while being archived
+		if !items[i].IsSeed() {
+			if reason := budget.Exceeded(items[i]); reason != "" {
+				budget.Fail(items[i], reason)
+				continue
+			}
+		}
+
+		// Assets can be on other hosts than their seed, each item is routed on its own
+		client, proxy, err := globalArchiver.clientFor(items[i].GetURL().GetParsed().Hostname())
+		if err != nil {
+			logger.Error("unable to route item", "err", err.Error(), "item_id", items[i].GetShortID())
+			items[i].SetStatus(models.ItemFailed)
+			continue
+		}
+
+		// The metadata records of the item go to the WARC files of the proxy it is fetched through
+		if proxy != nil {
+			items[i].SetProxy(proxy.Name)
+		}
+
 		guard <- struct{}{}
 
 		wg.Add(1)
 
-		if config.Get().Headless {
-			go headless.ArchiveItem(items[i], &wg, guard, globalBucketManager, client)
-		} else {
-			go general.ArchiveItem(items[i], &wg, guard, globalBucketManager, client)
+		// The browser only navigates with plain GETs, the items with another method, headers or
+		// a body are fetched as they ask without it
+		isHeadless := config.Get().Headless && !items[i].GetURL().HasCustomRequest()
+		if config.Get().Headless && !isHeadless {
+			logger.Info("fetching custom request without the browser", "item_id", items[i].GetShortID(), "method", items[i].GetURL().GetMethod())
+		}
+
+		archiveItem := general.ArchiveItem
+		if isHeadless {
+			archiveItem = headless.ArchiveItem
 		}
 
+		// Headless items have no response to tell a proxy failure apart, only the health checks apply to them
+		if proxy == nil || isHeadless {
+			go archiveItem(items[i], &wg, guard, globalBucketManager, client)
+			continue
+		}
+
+		go func(item *models.Item) {
+			defer wg.Done()
+
+			var itemWg sync.WaitGroup
+			itemWg.Add(1)
+			archiveItem(item, &itemWg, guard, globalBucketManager, client)
+
+			// An item failed without response didn't get through the proxy
+			if item.GetStatus() == models.ItemFailed && item.GetURL().GetResponse() == nil {
+				globalArchiver.proxyPool.ReportFailure(proxy)
+			} else {
+				globalArchiver.proxyPool.ReportSuccess(proxy)
+			}
+		}(items[i])
 	}
 
 	// Wait for all goroutines to finish
 	wg.Wait()
 }
+
+// clientFor returns the client the requests to host are sent with, and the proxy of
+// the pool it goes through if any
+func (a *archiver) clientFor(host string) (*warc.CustomHTTPClient, *proxypool.Proxy, error) {
+	if a.proxyPool != nil {
+		proxy, err := a.proxyPool.Select(host)
+		if err != nil {
+			return nil, nil, err
+		}
+		if proxy != nil {
+			return proxy.Client, proxy, nil
+		}
+		return a.Client, nil, nil
+	}
+
+	if a.ClientWithProxy != nil {
+		return a.ClientWithProxy, nil, nil
+	}
+
+	return a.Client, nil, nil
+}
@@ -0,0 +1,191 @@
+package cdx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Format is the format of the generated indexes
+type Format string
+
+const (
+	// FormatCDXJ is the pywb CDXJ format: "<surt> <timestamp> <json>"
+	FormatCDXJ Format = "cdxj"
+	// FormatCDX11 is the legacy 11 fields CDX format: "N b a m s k r M S V g"
+	FormatCDX11 Format = "cdx"
+)
+
+// CDX11Header is the first line of a CDX11 file
+const CDX11Header = " CDX N b a m s k r M S V g"
+
+// Entry is a single capture in an index
+type Entry struct {
+	SURT      string
+	Timestamp string // 14 digits, UTC
+	URL       string
+	MIME      string
+	Status    string // Empty for records without HTTP status
+	Digest    string
+	Redirect  string // Location header of redirections
+	Length    int64  // Compressed length of the record in the WARC
+	Offset    int64  // Compressed offset of the record in the WARC
+	Filename  string
+}
+
+type cdxjFields struct {
+	URL      string `json:"url"`
+	MIME     string `json:"mime,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Digest   string `json:"digest,omitempty"`
+	Redirect string `json:"redirect,omitempty"`
+	Length   string `json:"length"`
+	Offset   string `json:"offset"`
+	Filename string `json:"filename"`
+}
+
+// ParseFormat validates a --warc-index value
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(value); format {
+	case FormatCDXJ, FormatCDX11:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid index format %q, must be one of: %s, %s", value, FormatCDXJ, FormatCDX11)
+	}
+}
+
+// Extension returns the file extension of the index files
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// Line returns the entry in the given format, without line break
+func (e *Entry) Line(format Format) string {
+	if format == FormatCDX11 {
+		return e.CDX11()
+	}
+	return e.CDXJ()
+}
+
+// CDXJ returns the entry in the CDXJ format
+func (e *Entry) CDXJ() string {
+	fields, _ := json.Marshal(cdxjFields{
+		URL:      e.URL,
+		MIME:     e.MIME,
+		Status:   e.Status,
+		Digest:   e.Digest,
+		Redirect: e.Redirect,
+		Length:   strconv.FormatInt(e.Length, 10),
+		Offset:   strconv.FormatInt(e.Offset, 10),
+		Filename: e.Filename,
+	})
+
+	return e.SURT + " " + e.Timestamp + " " + string(fields)
+}
+
+// CDX11 returns the entry in the CDX11 format, empty fields are replaced by "-"
+func (e *Entry) CDX11() string {
+	return strings.Join([]string{
+		e.SURT,
+		e.Timestamp,
+		e.URL,
+		orDash(e.MIME),
+		orDash(e.Status),
+		orDash(e.Digest),
+		orDash(e.Redirect),
+		"-",
+		strconv.FormatInt(e.Length, 10),
+		strconv.FormatInt(e.Offset, 10),
+		e.Filename,
+	}, " ")
+}
+
+// ParseLine parses a CDXJ or CDX11 line
+func ParseLine(line string) (*Entry, error) {
+	fields := strings.SplitN(line, " ", 3)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("invalid index line: %q", line)
+	}
+
+	if strings.HasPrefix(fields[2], "{") {
+		return parseCDXJ(fields[0], fields[1], fields[2])
+	}
+
+	return parseCDX11(line)
+}
+
+func parseCDXJ(surt, timestamp, rawFields string) (*Entry, error) {
+	var fields cdxjFields
+	if err := json.Unmarshal([]byte(rawFields), &fields); err != nil {
+		return nil, fmt.Errorf("invalid CDXJ fields: %w", err)
+	}
+
+	length, err := strconv.ParseInt(fields.Length, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CDXJ length: %w", err)
+	}
+
+	offset, err := strconv.ParseInt(fields.Offset, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CDXJ offset: %w", err)
+	}
+
+	return &Entry{
+		SURT:      surt,
+		Timestamp: timestamp,
+		URL:       fields.URL,
+		MIME:      fields.MIME,
+		Status:    fields.Status,
+		Digest:    fields.Digest,
+		Redirect:  fields.Redirect,
+		Length:    length,
+		Offset:    offset,
+		Filename:  fields.Filename,
+	}, nil
+}
+
+func parseCDX11(line string) (*Entry, error) {
+	fields := strings.Split(line, " ")
+	if len(fields) != 11 {
+		return nil, fmt.Errorf("invalid CDX11 line, expected 11 fields, got %d", len(fields))
+	}
+
+	length, err := strconv.ParseInt(fields[8], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CDX11 length: %w", err)
+	}
+
+	offset, err := strconv.ParseInt(fields[9], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CDX11 offset: %w", err)
+	}
+
+	return &Entry{
+		SURT:      fields[0],
+		Timestamp: fields[1],
+		URL:       fields[2],
+		MIME:      fromDash(fields[3]),
+		Status:    fromDash(fields[4]),
+		Digest:    fromDash(fields[5]),
+		Redirect:  fromDash(fields[6]),
+		Length:    length,
+		Offset:    offset,
+		Filename:  fields[10],
+	}, nil
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	// CDX11 fields are space separated
+	return strings.ReplaceAll(value, " ", "%20")
+}
+
+func fromDash(value string) string {
+	if value == "-" {
+		return ""
+	}
+	return value
+}
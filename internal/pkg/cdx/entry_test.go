@@ -0,0 +1,63 @@
+package cdx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEntryRoundTrip(t *testing.T) {
+	entry := &Entry{
+		SURT:      "com,example)/a",
+		Timestamp: "20250102030405",
+		URL:       "https://example.com/a",
+		MIME:      "text/html",
+		Status:    "301",
+		Digest:    "ABCDEF",
+		Redirect:  "https://example.com/b c",
+		Length:    123,
+		Offset:    456,
+		Filename:  "ZENO-00001.warc.gz",
+	}
+
+	tests := []struct {
+		format   Format
+		expected string
+	}{
+		{FormatCDXJ, `com,example)/a 20250102030405 {"url":"https://example.com/a","mime":"text/html","status":"301","digest":"ABCDEF","redirect":"https://example.com/b c","length":"123","offset":"456","filename":"ZENO-00001.warc.gz"}`},
+		{FormatCDX11, `com,example)/a 20250102030405 https://example.com/a text/html 301 ABCDEF https://example.com/b%20c - 123 456 ZENO-00001.warc.gz`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			line := entry.Line(tt.format)
+			if line != tt.expected {
+				t.Fatalf("Line() = %q, want %q", line, tt.expected)
+			}
+
+			parsed, err := ParseLine(line)
+			if err != nil {
+				t.Fatalf("ParseLine() error = %v", err)
+			}
+
+			expected := *entry
+			if tt.format == FormatCDX11 {
+				expected.Redirect = "https://example.com/b%20c"
+			}
+			if !reflect.DeepEqual(*parsed, expected) {
+				t.Errorf("ParseLine() = %+v, want %+v", *parsed, expected)
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, value := range []string{"cdxj", "cdx"} {
+		if _, err := ParseFormat(value); err != nil {
+			t.Errorf("ParseFormat(%q) error = %v", value, err)
+		}
+	}
+
+	if _, err := ParseFormat("cdx14"); err == nil {
+		t.Error("ParseFormat(cdx14) error = nil, want an error")
+	}
+}
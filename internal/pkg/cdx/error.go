@@ -0,0 +1,10 @@
+package cdx
+
+import "errors"
+
+var (
+	// ErrIndexerAlreadyInitialized is the error returned when the indexer is already initialized
+	ErrIndexerAlreadyInitialized = errors.New("indexer already initialized")
+	// ErrUnsupportedCompression is the error returned when indexing a WARC file that isn't gzip compressed
+	ErrUnsupportedCompression = errors.New("only gzip compressed WARC files can be indexed")
+)
@@ -0,0 +1,142 @@
+package cdx
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	warc "github.com/internetarchive/gowarc"
+)
+
+// IndexFile reads a gzip compressed WARC file and returns an entry for each of
+// its response, revisit and resource records, sorted by SURT and timestamp.
+func IndexFile(warcPath string) ([]*Entry, error) {
+	filename := filepath.Base(warcPath)
+	entries := make([]*Entry, 0)
+
+	err := Walk(warcPath, func(record *warc.Record) {
+		if entry, ok := NewEntry(record, filename); ok {
+			entries = append(entries, entry)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	SortEntries(entries)
+
+	return entries, nil
+}
+
+// Walk calls fn for every record of a gzip compressed WARC file, in order.
+// The record content is closed once fn returns.
+func Walk(warcPath string, fn func(record *warc.Record)) error {
+	file, err := os.Open(warcPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader, err := warc.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for {
+		record, err := reader.ReadRecord()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("unable to read record: %w", err)
+		}
+
+		// Offsets are only known for gzip members
+		if record.Offset < 0 {
+			record.Content.Close()
+			return ErrUnsupportedCompression
+		}
+
+		fn(record)
+		record.Content.Close()
+	}
+}
+
+// SortEntries sorts the entries by SURT then timestamp, as expected by replay tools
+func SortEntries(entries []*Entry) {
+	slices.SortStableFunc(entries, func(a, b *Entry) int {
+		if c := strings.Compare(a.SURT, b.SURT); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Timestamp, b.Timestamp)
+	})
+}
+
+// NewEntry returns the index entry of a response, revisit or resource record,
+// ok is false for any other record type or if the record is invalid.
+func NewEntry(record *warc.Record, filename string) (entry *Entry, ok bool) {
+	recordType := record.Header.Get("WARC-Type")
+	if recordType != "response" && recordType != "revisit" && recordType != "resource" {
+		return nil, false
+	}
+
+	targetURI := strings.Trim(record.Header.Get("WARC-Target-URI"), "<>")
+	surt, err := SURT(targetURI)
+	if err != nil {
+		return nil, false
+	}
+
+	date, err := time.Parse(time.RFC3339Nano, record.Header.Get("WARC-Date"))
+	if err != nil {
+		return nil, false
+	}
+
+	entry = &Entry{
+		SURT:      surt,
+		Timestamp: date.UTC().Format("20060102150405"),
+		URL:       targetURI,
+		Digest:    strings.TrimPrefix(record.Header.Get("WARC-Payload-Digest"), "sha1:"),
+		Length:    record.Size,
+		Offset:    record.Offset,
+		Filename:  filename,
+	}
+
+	if recordType == "resource" {
+		entry.MIME = mediaType(record.Header.Get("Content-Type"))
+		return entry, true
+	}
+
+	// Revisits may only contain the HTTP headers
+	record.Content.Seek(0, io.SeekStart)
+	resp, err := http.ReadResponse(bufio.NewReader(record.Content), nil)
+	if err == nil {
+		entry.Status = strconv.Itoa(resp.StatusCode)
+		entry.MIME = mediaType(resp.Header.Get("Content-Type"))
+		entry.Redirect = resp.Header.Get("Location")
+	}
+
+	if recordType == "revisit" {
+		entry.MIME = "warc/revisit"
+	} else if entry.MIME == "" {
+		entry.MIME = "unk"
+	}
+
+	return entry, true
+}
+
+func mediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+	return mediaType
+}
@@ -0,0 +1,159 @@
+package cdx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	warc "github.com/internetarchive/gowarc"
+)
+
+type testRecord struct {
+	recordType string
+	uri        string
+	date       string
+	content    string
+}
+
+func writeTestWARC(t *testing.T, path string, records []testRecord) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	writer, err := warc.NewWriter(file, filepath.Base(path), warc.SHA1, warc.CompressionGzip, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writer.Reset(file)
+	if _, err := writer.WriteInfoRecord(warc.Header{"software": "Zeno"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range records {
+		record := warc.NewRecord("", false)
+		record.Header.Set("WARC-Type", r.recordType)
+		record.Header.Set("WARC-Target-URI", r.uri)
+		record.Header.Set("WARC-Date", r.date)
+		record.Header.Set("WARC-Payload-Digest", "sha1:DIGEST"+strings.ToUpper(r.recordType))
+		record.Content.Write([]byte(r.content))
+
+		writer.Reset(file)
+		if _, err := writer.WriteRecord(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+var testRecords = []testRecord{
+	{"request", "https://example.com/b", "2025-01-02T03:04:05Z", "GET /b HTTP/1.1\r\nHost: example.com\r\n\r\n"},
+	{"response", "https://example.com/b", "2025-01-02T03:04:05Z", "HTTP/1.1 200 OK\r\nContent-Type: text/html; charset=utf-8\r\nContent-Length: 5\r\n\r\nhello"},
+	{"response", "https://www.example.com/a", "2025-01-02T03:04:06.123456Z", "HTTP/1.1 302 Found\r\nLocation: https://example.com/b\r\nContent-Length: 0\r\n\r\n"},
+	{"revisit", "https://example.com/b", "2025-01-02T03:04:07Z", "HTTP/1.1 200 OK\r\nContent-Type: text/html\r\n\r\n"},
+	{"metadata", "https://example.com/b", "2025-01-02T03:04:08Z", "outlink: https://example.com/a L\r\n"},
+}
+
+func TestIndexFile(t *testing.T) {
+	warcPath := filepath.Join(t.TempDir(), "ZENO-00001.warc.gz")
+	writeTestWARC(t, warcPath, testRecords)
+
+	entries, err := IndexFile(warcPath)
+	if err != nil {
+		t.Fatalf("IndexFile() error = %v", err)
+	}
+
+	expected := []string{
+		"com,example)/a 20250102030406 https://www.example.com/a unk 302 DIGESTRESPONSE https://example.com/b -",
+		"com,example)/b 20250102030405 https://example.com/b text/html 200 DIGESTRESPONSE - -",
+		"com,example)/b 20250102030407 https://example.com/b warc/revisit 200 DIGESTREVISIT - -",
+	}
+
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(entries))
+	}
+
+	file, err := os.Open(warcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	for i, entry := range entries {
+		if got := strings.Join(strings.Split(entry.CDX11(), " ")[:8], " "); got != expected[i] {
+			t.Errorf("entry %d = %q, want %q", i, got, expected[i])
+		}
+
+		if entry.Filename != "ZENO-00001.warc.gz" || entry.Length <= 0 {
+			t.Errorf("unexpected entry location: %+v", entry)
+		}
+
+		// The offset must point to the beginning of the record
+		if _, err := file.Seek(entry.Offset, 0); err != nil {
+			t.Fatal(err)
+		}
+		reader, err := warc.NewReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		record, err := reader.ReadRecord(warc.ReadOptsNoContentOutput)
+		if err != nil {
+			t.Fatalf("unable to read record at offset %d: %v", entry.Offset, err)
+		}
+		if record.Header.Get("WARC-Target-URI") != entry.URL || record.Size != entry.Length {
+			t.Errorf("record at offset %d doesn't match entry %+v", entry.Offset, entry)
+		}
+		record.Content.Close()
+		reader.Close()
+	}
+}
+
+func TestIndexer(t *testing.T) {
+	jobDir := t.TempDir()
+	warcsDir := filepath.Join(jobDir, "warcs")
+	indexDir := filepath.Join(jobDir, "indexes")
+	if err := os.MkdirAll(warcsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestWARC(t, filepath.Join(warcsDir, "ZENO-00001.warc.gz"), testRecords[:2])
+	writeTestWARC(t, filepath.Join(warcsDir, "ZENO-00002.warc.gz"), testRecords[2:])
+	writeTestWARC(t, filepath.Join(warcsDir, "ZENO-00003.warc.gz.open"), testRecords)
+
+	if err := Start(warcsDir, indexDir, FormatCDXJ, true); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := Start(warcsDir, indexDir, FormatCDXJ, true); err != ErrIndexerAlreadyInitialized {
+		t.Fatalf("second Start() error = %v, want %v", err, ErrIndexerAlreadyInitialized)
+	}
+	Stop()
+
+	for _, name := range []string{"ZENO-00001.warc.gz.cdxj", "ZENO-00002.warc.gz.cdxj"} {
+		if _, err := os.Stat(filepath.Join(indexDir, name)); err != nil {
+			t.Errorf("expected index %s: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(indexDir, "ZENO-00003.warc.gz.open.cdxj")); err == nil {
+		t.Error("WARC files still being written must not be indexed")
+	}
+
+	lines, err := readLines(filepath.Join(indexDir, "index.cdxj"))
+	if err != nil {
+		t.Fatalf("unable to read merged index: %v", err)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines in the merged index, got %d", len(lines))
+	}
+	for i, prefix := range []string{"com,example)/a 20250102030406 ", "com,example)/b 20250102030405 ", "com,example)/b 20250102030407 "} {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("merged line %d = %q, want prefix %q", i, lines[i], prefix)
+		}
+		if _, err := ParseLine(lines[i]); err != nil {
+			t.Errorf("ParseLine(%q) error = %v", lines[i], err)
+		}
+	}
+}
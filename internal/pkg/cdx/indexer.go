@@ -0,0 +1,241 @@
+// Package cdx generates CDXJ (or CDX11) indexes of the WARC files written
+// during the crawl. Each WARC gets its own index as soon as it is closed by the
+// rotator, and an optional merged, sorted job-level index is written when the
+// crawl stops, so that the job can be replayed with pywb-style tools right away.
+package cdx
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
+)
+
+const (
+	// Interval at which the WARC directory is scanned for closed WARC files
+	scanInterval = 10 * time.Second
+	// Name (without extension) of the merged job-level index
+	mergedIndexName = "index"
+)
+
+type indexer struct {
+	wg       sync.WaitGroup
+	ctx      context.Context
+	cancel   context.CancelFunc
+	warcsDir string
+	indexDir string
+	format   Format
+	merge    bool
+}
+
+var (
+	globalIndexer *indexer
+	once          sync.Once
+	logger        = log.NewFieldedLogger(&log.Fields{
+		"component": "cdx",
+	})
+)
+
+// Start indexes the closed WARC files of warcsDir into indexDir, then keeps
+// watching warcsDir for newly closed WARC files until Stop is called.
+func Start(warcsDir, indexDir string, format Format, merge bool) error {
+	var done bool
+
+	once.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		globalIndexer = &indexer{
+			ctx:      ctx,
+			cancel:   cancel,
+			warcsDir: warcsDir,
+			indexDir: indexDir,
+			format:   format,
+			merge:    merge,
+		}
+
+		globalIndexer.wg.Add(1)
+		go globalIndexer.run()
+
+		logger.Info("started", "format", format, "merge", merge)
+		done = true
+	})
+
+	if !done {
+		return ErrIndexerAlreadyInitialized
+	}
+
+	return nil
+}
+
+// Stop must be called once the WARC writers are closed: it indexes the last
+// WARC files and writes the merged index if enabled.
+func Stop() {
+	if globalIndexer == nil {
+		return
+	}
+
+	globalIndexer.cancel()
+	globalIndexer.wg.Wait()
+
+	globalIndexer.scan()
+
+	if globalIndexer.merge {
+		mergedPath, err := Merge(globalIndexer.indexDir, globalIndexer.format)
+		if err != nil {
+			logger.Error("unable to write merged index", "err", err.Error())
+		} else {
+			logger.Info("merged index written", "path", mergedPath)
+		}
+	}
+
+	globalIndexer = nil
+	once = sync.Once{}
+	logger.Info("stopped")
+}
+
+func (i *indexer) run() {
+	defer i.wg.Done()
+
+	ticker := time.NewTicker(scanInterval)
+	defer ticker.Stop()
+
+	for {
+		i.scan()
+
+		select {
+		case <-i.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scan indexes every closed WARC file that doesn't have an index yet
+func (i *indexer) scan() {
+	dirEntries, err := os.ReadDir(i.warcsDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Error("unable to list WARC files", "dir", i.warcsDir, "err", err.Error())
+		}
+		return
+	}
+
+	for _, dirEntry := range dirEntries {
+		// Files still being written end with .open
+		if dirEntry.IsDir() || !strings.HasSuffix(dirEntry.Name(), ".warc.gz") {
+			continue
+		}
+
+		indexPath := filepath.Join(i.indexDir, dirEntry.Name()+i.format.Extension())
+		if _, err := os.Stat(indexPath); err == nil {
+			continue
+		}
+
+		entries, err := IndexFile(filepath.Join(i.warcsDir, dirEntry.Name()))
+		if err != nil {
+			logger.Error("unable to index WARC file", "file", dirEntry.Name(), "err", err.Error())
+			continue
+		}
+
+		if err := WriteIndex(indexPath, entries, i.format); err != nil {
+			logger.Error("unable to write index", "file", indexPath, "err", err.Error())
+			continue
+		}
+
+		logger.Debug("WARC file indexed", "file", dirEntry.Name(), "entries", len(entries))
+	}
+}
+
+// WriteIndex atomically writes the entries to path in the given format
+func WriteIndex(path string, entries []*Entry, format Format) error {
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		lines = append(lines, entry.Line(format))
+	}
+
+	return writeLines(path, lines, format)
+}
+
+// Merge merges the per-WARC indexes of indexDir into a single sorted index and returns its path
+func Merge(indexDir string, format Format) (string, error) {
+	mergedPath := filepath.Join(indexDir, mergedIndexName+format.Extension())
+
+	files, err := filepath.Glob(filepath.Join(indexDir, "*"+format.Extension()))
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	for _, file := range files {
+		if file == mergedPath {
+			continue
+		}
+
+		fileLines, err := readLines(file)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, fileLines...)
+	}
+
+	slices.Sort(lines)
+
+	return mergedPath, writeLines(mergedPath, lines, format)
+}
+
+func readLines(path string) (lines []string, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line == CDX11Header {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+func writeLines(path string, lines []string, format Format) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".index-*")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary index file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	if format == FormatCDX11 {
+		fmt.Fprintln(writer, CDX11Header)
+	}
+	for _, line := range lines {
+		fmt.Fprintln(writer, line)
+	}
+
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to write index file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to write index file: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
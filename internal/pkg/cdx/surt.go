@@ -0,0 +1,61 @@
+package cdx
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var wwwPrefixRe = regexp.MustCompile(`^www\d*\.`)
+
+// SURT returns the Sort-friendly URI Reordering Transform of a URL, in the
+// same canonical form as pywb and the Wayback Machine: scheme, www prefix,
+// default port and fragment are removed, the host is reversed, query
+// parameters are sorted and everything is lowercased.
+//
+// e.g. https://www.Example.com/Path?b=2&a=1#top -> com,example)/path?a=1&b=2
+func SURT(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	// Non-hierarchical URLs (dns:, urn:, ...) are only lowercased
+	if u.Host == "" {
+		return strings.ToLower(rawURL), nil
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	host = wwwPrefixRe.ReplaceAllString(host, "")
+
+	labels := strings.Split(host, ".")
+	slices.Reverse(labels)
+
+	var surt strings.Builder
+	surt.WriteString(strings.Join(labels, ","))
+
+	if port := u.Port(); port != "" && !isDefaultPort(u.Scheme, port) {
+		surt.WriteString(":" + port)
+	}
+
+	surt.WriteString(")")
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	surt.WriteString(path)
+
+	if u.RawQuery != "" {
+		params := strings.Split(u.RawQuery, "&")
+		slices.Sort(params)
+		surt.WriteString("?" + strings.Join(params, "&"))
+	}
+
+	return strings.ToLower(surt.String()), nil
+}
+
+func isDefaultPort(scheme, port string) bool {
+	return (scheme == "http" && port == "80") || (scheme == "https" && port == "443")
+}
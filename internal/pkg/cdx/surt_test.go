@@ -0,0 +1,29 @@
+package cdx
+
+import "testing"
+
+func TestSURT(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://example.com", "com,example)/"},
+		{"https://www.Example.com/Path/?b=2&a=1#top", "com,example)/path/?a=1&b=2"},
+		{"http://www2.example.co.uk:80/", "uk,co,example)/"},
+		{"https://example.com:8443/index.html", "com,example:8443)/index.html"},
+		{"http://sub.example.com./a", "com,example,sub)/a"},
+		{"dns:example.com", "dns:example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, err := SURT(tt.url)
+			if err != nil {
+				t.Fatalf("SURT(%q) error = %v", tt.url, err)
+			}
+			if got != tt.expected {
+				t.Errorf("SURT(%q) = %q, want %q", tt.url, got, tt.expected)
+			}
+		})
+	}
+}
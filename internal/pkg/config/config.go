@@ -8,15 +8,14 @@ import (
 	"os"
 	"path"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/internetarchive/Zeno/v2/internal/pkg/postprocessor/domainscrawl"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/utils"
+	"github.com/internetarchive/Zeno/v2/pkg/models"
 	warc "github.com/internetarchive/gowarc"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -44,6 +43,8 @@ type Config struct {
 
 	UserAgent                       string        `mapstructure:"user-agent"`
 	Cookies                         string        `mapstructure:"cookies"`
+	CookiesPersist                  bool          `mapstructure:"cookies-persist"`
+	RobotsPolicy                    string        `mapstructure:"robots-policy"`
 	WARCPrefix                      string        `mapstructure:"warc-prefix"`
 	WARCOperator                    string        `mapstructure:"warc-operator"`
 	WARCTempDir                     string        `mapstructure:"warc-temp-dir"`
@@ -55,7 +56,12 @@ type Config struct {
 	WARCDedupeCacheSize             int           `mapstructure:"warc-dedupe-cache-size"`
 	WARCWriteAsync                  bool          `mapstructure:"async-warc-write"`
 	WARCDiscardStatus               []int         `mapstructure:"warc-discard-status"`
+	DiscardRulesFile                string        `mapstructure:"discard-rules-file"`
+	QuarantineRulesFile             string        `mapstructure:"quarantine-rules-file"`
 	WARCDigestAlgorithm             string        `mapstructure:"warc-digest-algorithm"`
+	WARCMetadataRecords             bool          `mapstructure:"warc-metadata-records"`
+	WARCIndex                       string        `mapstructure:"warc-index"`
+	WARCIndexMerge                  bool          `mapstructure:"warc-index-merge"`
 	CDXDedupeServer                 string        `mapstructure:"warc-cdx-dedupe-server"`
 	CDXCookie                       string        `mapstructure:"warc-cdx-cookie"`
 	DoppelgangerDedupeServer        string        `mapstructure:"warc-doppelganger-dedupe-server"`
@@ -69,29 +75,42 @@ type Config struct {
 	HQSeencheckCacheSize            int           `mapstructure:"hq-seencheck-cache-size"`
 	HQSeencheckURL                  string        `mapstructure:"hq-seencheck-url"`
 	HQGZIPRequests                  bool          `mapstructure:"hq-gzip-requests"`
+	StreamAddress                   string        `mapstructure:"stream-address"`
+	StreamFinishFIFO                string        `mapstructure:"stream-finish-fifo"`
 	DisableHTMLTag                  []string      `mapstructure:"disable-html-tag"`
 	ExcludeHosts                    []string      `mapstructure:"exclude-host"`
 	IncludeHosts                    []string      `mapstructure:"include-host"`
 	IncludeString                   []string      `mapstructure:"include-string"`
 	ExcludeString                   []string      `mapstructure:"exclude-string"`
 	ExclusionFile                   []string      `mapstructure:"exclusion-file"`
-	ExclusionFileLiveReload         bool          `mapstructure:"exclusion-file-live-reload"`
-	ExclusionFileLiveReloadInterval time.Duration `mapstructure:"exclusion-file-live-reload-interval"`
+	ScopeFile                       []string      `mapstructure:"scope-file"`
+	ScopeLiveReload                 bool          `mapstructure:"scope-live-reload"`
+	ScopeLiveReloadInterval         time.Duration `mapstructure:"scope-live-reload-interval"`
 	WorkersCount                    int           `mapstructure:"workers"`
+	Autoscale                       bool          `mapstructure:"autoscale"`
+	AutoscaleMinWorkers             int           `mapstructure:"autoscale-min-workers"`
+	AutoscaleMaxWorkers             int           `mapstructure:"autoscale-max-workers"`
+	AutoscaleInterval               time.Duration `mapstructure:"autoscale-interval"`
 	MaxConcurrentAssets             int           `mapstructure:"max-concurrent-assets"`
 	MaxHops                         int           `mapstructure:"max-hops"`
 	MaxRedirect                     int           `mapstructure:"max-redirect"`
 	MaxCSSJump                      int           `mapstructure:"max-css-jump"`
 	MaxRetry                        int           `mapstructure:"max-retry"`
+	MaxRetryAfter                   time.Duration `mapstructure:"max-retry-after"`
 	MaxContentLengthMiB             int           `mapstructure:"max-content-length"`
 	MaxOutlinks                     int           `mapstructure:"max-outlinks"`
+	SeedMaxItems                    int           `mapstructure:"seed-max-items"`
+	SeedMaxSizeMiB                  int           `mapstructure:"seed-max-size"`
+	SeedMaxDuration                 time.Duration `mapstructure:"seed-max-duration"`
 	HTTPTimeout                     time.Duration `mapstructure:"http-timeout"`
+	RangeResumeMinSizeMiB           int           `mapstructure:"range-resume-min-size"`
 	ConnReadDeadline                time.Duration `mapstructure:"conn-read-deadline"`
 	CrawlTimeLimit                  int           `mapstructure:"crawl-time-limit"`
 	CrawlMaxTimeLimit               int           `mapstructure:"crawl-max-time-limit"`
 	MinSpaceRequired                float64       `mapstructure:"min-space-required"`
 	DomainsCrawl                    []string      `mapstructure:"domains-crawl"`
 	DomainsCrawlFile                []string      `mapstructure:"domains-crawl-file"`
+	DisableSitemapDiscovery         bool          `mapstructure:"disable-sitemap-discovery"`
 	CaptureAlternatePages           bool          `mapstructure:"capture-alternate-pages"`
 	StrictRegex                     bool          `mapstructure:"strict-regex"`
 	DisableLocalDedupe              bool          `mapstructure:"disable-local-dedupe"`
@@ -101,6 +120,7 @@ type Config struct {
 	MaxURLLength                    int           `mapstructure:"max-url-length"`
 	DisableAssetsCapture            bool          `mapstructure:"disable-assets-capture"`
 	UseHQ                           bool          // Special field to check if HQ is enabled depending on the command called
+	UseStream                       bool          // Special field to check if the stream source is enabled depending on the command called
 
 	// Headless
 	Headless                 bool     `mapstructure:"headless"`
@@ -123,6 +143,7 @@ type Config struct {
 
 	// Network
 	Proxy         string `mapstructure:"proxy"`
+	ProxyPoolFile string `mapstructure:"proxy-pool-file"`
 	DNSServers    []string `mapstructure:"dns-server"`
 	RandomLocalIP bool   `mapstructure:"random-local-ip"`
 	DisableIPv4   bool   `mapstructure:"disable-ipv4"`
@@ -134,6 +155,9 @@ type Config struct {
 	RateLimitCapacity         float64       `mapstructure:"rate-limit-capacity"`
 	RateLimitRefillRate       float64       `mapstructure:"rate-limit-refill-rate"`
 	RateLimitCleanupFrequency time.Duration `mapstructure:"rate-limit-cleanup-frequency"`
+	RateLimitRulesFile        string        `mapstructure:"rate-limit-rules-file"`
+	LQHostMaxInFlight         int           `mapstructure:"lq-host-max-in-flight"`
+	LQHostDelay               time.Duration `mapstructure:"lq-host-delay"`
 
 	// Logging
 	NoStdoutLogging  bool   `mapstructure:"no-stdout-log"`
@@ -149,19 +173,27 @@ type Config struct {
 	LogFileOutputDir string `mapstructure:"log-file-output-dir"`
 	LogFilePrefix    string `mapstructure:"log-file-prefix"`
 	LogFileRotation  string `mapstructure:"log-file-rotation"`
+	CrawlLog         bool   `mapstructure:"crawl-log"`
 
 	// Profiling
 	PyroscopeAddress    string        `mapstructure:"pyroscope-address"`
 	PyroscopeUploadRate time.Duration `mapstructure:"pyroscope-upload-rate"`
 	SentryDSN           string        `mapstructure:"sentry-dsn"`
+	OTelEndpoint        string        `mapstructure:"otel-endpoint"`
+	OTelSampleRatio     float64       `mapstructure:"otel-sample-ratio"`
 
 	// API
-	APIPort int  `mapstructure:"api-port"`
-	API     bool `mapstructure:"api"`
+	APIPort  int    `mapstructure:"api-port"`
+	API      bool   `mapstructure:"api"`
+	APIToken string `mapstructure:"api-token"`
+
+	// Replay
+	ReplayAddress string `mapstructure:"replay-address"`
 
 	// Prometheus and metrics
-	Prometheus       bool   `mapstructure:"prometheus"`
-	PrometheusPrefix string `mapstructure:"prometheus-prefix"`
+	Prometheus            bool   `mapstructure:"prometheus"`
+	PrometheusPrefix      string `mapstructure:"prometheus-prefix"`
+	PrometheusHostMetrics int    `mapstructure:"prometheus-host-metrics"`
 
 	// Consul
 	ConsulAddress      string   `mapstructure:"consul-address"`
@@ -170,8 +202,9 @@ type Config struct {
 	ConsulRegister     bool     `mapstructure:"consul-register"`
 	ConsulRegisterTags []string `mapstructure:"consul-register-tags"`
 
-	InputSeeds       []string     // Special field to store the input URLs
-	exclusionRegexes atomic.Value // Special field to store the compiled exclusion regex (from --exclusion-file)
+	InputSeeds []models.Seed         // Special field to store the input seeds
+	scope      atomic.Pointer[Scope] // Special field to store the live-reloadable crawl scope
+	scopeMu    sync.Mutex
 }
 
 var (
@@ -349,6 +382,20 @@ func GenerateCrawlConfig() error {
 		slog.Info("User-Agent set to", "user-agent", config.UserAgent)
 	}
 
+	if config.Autoscale {
+		config.AutoscaleMinWorkers = max(config.AutoscaleMinWorkers, 1)
+		if config.AutoscaleMaxWorkers == 0 {
+			config.AutoscaleMaxWorkers = 4 * config.WorkersCount
+		}
+		if config.AutoscaleMinWorkers > config.AutoscaleMaxWorkers {
+			return fmt.Errorf("--autoscale-min-workers (%d) is greater than --autoscale-max-workers (%d)", config.AutoscaleMinWorkers, config.AutoscaleMaxWorkers)
+		}
+
+		// --workers is the starting point of the autoscaling
+		config.WorkersCount = min(max(config.WorkersCount, config.AutoscaleMinWorkers), config.AutoscaleMaxWorkers)
+		slog.Info("workers autoscaling enabled", "min", config.AutoscaleMinWorkers, "max", config.AutoscaleMaxWorkers, "start", config.WorkersCount)
+	}
+
 	if config.MaxContentLengthMiB > 0 {
 		slog.Info("max content length is set, payload over X MiB would be discarded", "X", config.MaxContentLengthMiB)
 	}
@@ -361,6 +408,14 @@ func GenerateCrawlConfig() error {
 		slog.Warn("random local IP is enabled")
 	}
 
+	if config.DisableRateLimit && config.RateLimitRulesFile != "" {
+		return fmt.Errorf("--rate-limit-rules-file can't be used with --disable-rate-limit")
+	}
+
+	if config.Proxy != "" && config.ProxyPoolFile != "" {
+		return fmt.Errorf("--proxy and --proxy-pool-file can't be used together")
+	}
+
 	if config.DisableIPv4 && config.DisableIPv6 {
 		return fmt.Errorf("both IPv4 and IPv6 are disabled, at least one of them must be enabled.")
 	} else if config.DisableIPv4 {
@@ -369,32 +424,16 @@ func GenerateCrawlConfig() error {
 		slog.Info("IPv6 is disabled")
 	}
 
-	config.exclusionRegexes.Store([]*regexp.Regexp(nil))
-	if len(config.ExclusionFile) > 0 {
-		var exclusions []*regexp.Regexp
-
-		for _, file := range config.ExclusionFile {
-			newExclusions, err := config.loadExclusions(file)
-			if err != nil {
-				return err
-			}
-
-			exclusions = append(exclusions, newExclusions...)
-		}
-
-		config.setExclusionRegexes(exclusions)
+	if len(config.DomainsCrawl) > 0 || len(config.DomainsCrawlFile) > 0 {
+		slog.Info("domains crawl enabled", "domains/regex", config.DomainsCrawl)
+	}
 
-		if config.ExclusionFileLiveReload {
-			config.waitGroup.Go(config.exclusionFileLiveReloader)
-		}
+	if err := config.ReloadScope(); err != nil {
+		return err
 	}
 
-	if len(config.DomainsCrawl) > 0 || len(config.DomainsCrawlFile) > 0 {
-		slog.Info("domains crawl enabled", "domains/regex", config.DomainsCrawl)
-		err := domainscrawl.AddElements(config.DomainsCrawl, config.DomainsCrawlFile)
-		if err != nil {
-			panic(err)
-		}
+	if config.ScopeLiveReload {
+		config.waitGroup.Go(config.scopeLiveReloader)
 	}
 
 	// In CI/CD, set a low threshold for testing purposes
@@ -429,6 +468,14 @@ func handleFlagsAliases() {
 		viper.Set("max-concurrent-assets", viper.GetInt("ca"))
 	}
 
+	if viper.GetBool("exclusion-file-live-reload") && !viper.GetBool("scope-live-reload") {
+		viper.Set("scope-live-reload", true)
+	}
+
+	if viper.GetDuration("exclusion-file-live-reload-interval") != time.Minute && viper.GetDuration("scope-live-reload-interval") == time.Minute {
+		viper.Set("scope-live-reload-interval", viper.GetDuration("exclusion-file-live-reload-interval"))
+	}
+
 	if viper.GetInt("msr") != 20 && viper.GetInt("min-space-required") == 20 {
 		viper.Set("min-space-required", viper.GetInt("msr"))
 	}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/postprocessor/domainscrawl"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/utils"
+)
+
+// Scope holds every rule deciding whether a URL belongs to the crawl. A Scope is
+// never modified once built: reloading builds a new one that is swapped atomically,
+// so that a URL is always checked against a consistent set of rules.
+type Scope struct {
+	IncludeHosts     []string         `json:"include_hosts"`
+	ExcludeHosts     []string         `json:"exclude_hosts"`
+	IncludeString    []string         `json:"include_string"`
+	ExcludeString    []string         `json:"exclude_string"`
+	ExclusionRegexes []*regexp.Regexp `json:"exclusion_regexes"`
+	DomainsCrawl     []string         `json:"domains_crawl"`
+	LoadedAt         time.Time        `json:"loaded_at"`
+
+	exclusionFiles map[string][]*regexp.Regexp // Exclusion regexes of each exclusion file
+}
+
+// scopeFile is the JSON document read from --scope-file, its rules are added to
+// the ones given on the command line.
+type scopeFile struct {
+	IncludeHosts  []string `json:"include-host"`
+	ExcludeHosts  []string `json:"exclude-host"`
+	IncludeString []string `json:"include-string"`
+	ExcludeString []string `json:"exclude-string"`
+	Exclusions    []string `json:"exclusion-regex"`
+	DomainsCrawl  []string `json:"domains-crawl"`
+}
+
+var emptyScope = &Scope{}
+
+// GetScope returns the scope currently in use, it never returns nil
+func (c *Config) GetScope() *Scope {
+	if scope := c.scope.Load(); scope != nil {
+		return scope
+	}
+	return emptyScope
+}
+
+// ReloadScope rebuilds the scope from the command line, the exclusion files, the
+// domains crawl files and the scope files, then swaps it with the current one.
+// An exclusion file that can't be read keeps the regexes it had in the current
+// scope, if any of the other files can't be read the current scope is kept.
+func (c *Config) ReloadScope() error {
+	scope, err := c.buildScope(c.GetScope())
+	if err != nil {
+		slog.Error("failed to reload scope, keeping the current one", "err", err)
+		return err
+	}
+
+	// An empty matcher disables domains crawl
+	matcher := domainscrawl.NewMatcher()
+	if len(scope.DomainsCrawl) > 0 || len(c.DomainsCrawlFile) > 0 {
+		if err := matcher.AddElements(scope.DomainsCrawl, c.DomainsCrawlFile); err != nil {
+			slog.Error("failed to reload scope, keeping the current one", "err", err)
+			return fmt.Errorf("failed to load domains crawl rules: %w", err)
+		}
+	}
+
+	c.scopeMu.Lock()
+	defer c.scopeMu.Unlock()
+
+	c.scope.Store(scope)
+	domainscrawl.Replace(matcher)
+
+	slog.Info("scope reloaded",
+		"include_hosts", len(scope.IncludeHosts),
+		"exclude_hosts", len(scope.ExcludeHosts),
+		"include_string", len(scope.IncludeString),
+		"exclude_string", len(scope.ExcludeString),
+		"exclusion_regexes", len(scope.ExclusionRegexes),
+		"domains_crawl", len(scope.DomainsCrawl))
+
+	return nil
+}
+
+// buildScope returns a new scope built from the configuration and the files it references,
+// the exclusion files that can't be read keeping their regexes from current
+func (c *Config) buildScope(current *Scope) (*Scope, error) {
+	scope := &Scope{
+		IncludeHosts:  slices.Clone(c.IncludeHosts),
+		ExcludeHosts:  slices.Clone(c.ExcludeHosts),
+		IncludeString: slices.Clone(c.IncludeString),
+		ExcludeString: slices.Clone(c.ExcludeString),
+		DomainsCrawl:  slices.Clone(c.DomainsCrawl),
+		LoadedAt:      time.Now(),
+
+		exclusionFiles: make(map[string][]*regexp.Regexp, len(c.ExclusionFile)),
+	}
+
+	for _, file := range c.ExclusionFile {
+		exclusions, err := c.loadExclusions(file)
+		if err != nil {
+			previous, loaded := current.exclusionFiles[file]
+			if !loaded {
+				return nil, fmt.Errorf("failed to load exclusion file %s: %w", file, err)
+			}
+
+			slog.Error("failed to reload exclusion file, keeping its previous exclusions", "file", file, "err", err)
+			exclusions = previous
+		}
+
+		scope.exclusionFiles[file] = exclusions
+		scope.ExclusionRegexes = append(scope.ExclusionRegexes, exclusions...)
+	}
+
+	for _, file := range c.ScopeFile {
+		rules, err := readScopeFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load scope file %s: %w", file, err)
+		}
+
+		exclusions, errs := compileRegexes(rules.Exclusions)
+		if len(errs) > 0 {
+			return nil, fmt.Errorf("failed to compile %d regexes of scope file %s", len(errs), file)
+		}
+
+		scope.IncludeHosts = append(scope.IncludeHosts, rules.IncludeHosts...)
+		scope.ExcludeHosts = append(scope.ExcludeHosts, rules.ExcludeHosts...)
+		scope.IncludeString = append(scope.IncludeString, rules.IncludeString...)
+		scope.ExcludeString = append(scope.ExcludeString, rules.ExcludeString...)
+		scope.ExclusionRegexes = append(scope.ExclusionRegexes, exclusions...)
+		scope.DomainsCrawl = append(scope.DomainsCrawl, rules.DomainsCrawl...)
+	}
+
+	scope.IncludeHosts = utils.DedupeStrings(scope.IncludeHosts)
+	scope.ExcludeHosts = utils.DedupeStrings(scope.ExcludeHosts)
+	scope.IncludeString = utils.DedupeStrings(scope.IncludeString)
+	scope.ExcludeString = utils.DedupeStrings(scope.ExcludeString)
+
+	return scope, nil
+}
+
+func readScopeFile(file string) (*scopeFile, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := new(scopeFile)
+	if err := json.Unmarshal(data, rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (c *Config) scopeLiveReloader() {
+	ticker := time.NewTicker(c.ScopeLiveReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			slog.Info("scope live reload goroutine cancelled")
+			return
+		case <-ticker.C:
+			if err := c.ReloadScope(); err != nil {
+				slog.Error("failed to reload scope, will retry in X seconds", "interval", c.ScopeLiveReloadInterval)
+			}
+		}
+	}
+}
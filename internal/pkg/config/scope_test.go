@@ -0,0 +1,126 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/postprocessor/domainscrawl"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetScopeNotLoaded(t *testing.T) {
+	c := &Config{}
+	if scope := c.GetScope(); scope == nil || len(scope.ExcludeHosts) != 0 {
+		t.Fatalf("expected an empty scope, got %+v", scope)
+	}
+}
+
+func TestReloadScope(t *testing.T) {
+	dir := t.TempDir()
+	scopeFile := filepath.Join(dir, "scope.json")
+	exclusionFile := filepath.Join(dir, "exclusions.txt")
+
+	writeFile(t, scopeFile, `{"exclude-host": ["example.net"], "exclusion-regex": ["\\.pdf$"], "domains-crawl": ["example.org"]}`)
+	writeFile(t, exclusionFile, "# comment\n/login\n")
+
+	c := &Config{
+		IncludeHosts:  []string{"example.com"},
+		ExcludeHosts:  []string{"archive.org"},
+		ExclusionFile: []string{exclusionFile},
+		ScopeFile:     []string{scopeFile},
+	}
+	defer domainscrawl.Reset()
+
+	if err := c.ReloadScope(); err != nil {
+		t.Fatalf("ReloadScope() error = %v", err)
+	}
+
+	scope := c.GetScope()
+	if !slices.Equal(scope.IncludeHosts, []string{"example.com"}) {
+		t.Errorf("IncludeHosts = %v", scope.IncludeHosts)
+	}
+	if !slices.Equal(scope.ExcludeHosts, []string{"archive.org", "example.net"}) {
+		t.Errorf("ExcludeHosts = %v", scope.ExcludeHosts)
+	}
+	if len(scope.ExclusionRegexes) != 2 {
+		t.Errorf("expected 2 exclusion regexes, got %d", len(scope.ExclusionRegexes))
+	}
+	if !domainscrawl.Enabled() || !domainscrawl.Match("https://www.example.org/") {
+		t.Error("expected domains crawl to match example.org")
+	}
+
+	// Widen the scope: the scope file no longer excludes anything nor crawls domains
+	writeFile(t, scopeFile, `{"include-string": ["/blog/"]}`)
+	if err := c.ReloadScope(); err != nil {
+		t.Fatalf("ReloadScope() error = %v", err)
+	}
+
+	reloaded := c.GetScope()
+	if reloaded == scope {
+		t.Fatal("expected a new scope after reload")
+	}
+	if !slices.Equal(reloaded.ExcludeHosts, []string{"archive.org"}) || !slices.Equal(reloaded.IncludeString, []string{"/blog/"}) {
+		t.Errorf("unexpected scope after reload: %+v", reloaded)
+	}
+	if domainscrawl.Enabled() {
+		t.Error("expected domains crawl to be disabled after reload")
+	}
+
+	// A broken file keeps the current scope
+	for _, content := range []string{`{"exclude-host": [`, `{"exclusion-regex": ["("]}`} {
+		writeFile(t, scopeFile, content)
+		if err := c.ReloadScope(); err == nil {
+			t.Fatalf("expected an error for scope file %q", content)
+		}
+		if c.GetScope() != reloaded {
+			t.Fatalf("expected the scope to be kept for scope file %q", content)
+		}
+	}
+}
+
+func TestReloadScopeExclusionFiles(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.txt")
+	second := filepath.Join(dir, "second.txt")
+
+	writeFile(t, first, "/login\n")
+	writeFile(t, second, "\\.pdf$\n")
+
+	c := &Config{ExclusionFile: []string{first, second}}
+	defer domainscrawl.Reset()
+
+	// An exclusion file that never loaded rejects the scope
+	if err := (&Config{ExclusionFile: []string{filepath.Join(dir, "missing.txt")}}).ReloadScope(); err == nil {
+		t.Fatal("expected an error for a missing exclusion file")
+	}
+
+	if err := c.ReloadScope(); err != nil {
+		t.Fatalf("ReloadScope() error = %v", err)
+	}
+
+	// The unreadable file keeps its exclusions, the other one is reloaded
+	if err := os.Remove(first); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, second, "\\.pdf$\n\\.zip$\n")
+
+	if err := c.ReloadScope(); err != nil {
+		t.Fatalf("ReloadScope() error = %v", err)
+	}
+
+	var regexes []string
+	for _, regex := range c.GetScope().ExclusionRegexes {
+		regexes = append(regexes, regex.String())
+	}
+	if want := []string{"/login", "\\.pdf$", "\\.zip$"}; !slices.Equal(regexes, want) {
+		t.Errorf("ExclusionRegexes = %v, want %v", regexes, want)
+	}
+}
@@ -3,24 +3,32 @@ package controler
 import (
 	"fmt"
 	"os"
+	"path"
 	"time"
 
 	"github.com/internetarchive/Zeno/v2/internal/pkg/api"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/cdx"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/consul"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/controler/watchers"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/cookies"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/crawllog"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/finisher"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/postprocessor"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/postprocessor/domainscrawl"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/postprocessor/sitemap"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/preprocessor"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/preprocessor/robots"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/preprocessor/seencheck"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/reactor"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/source"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/source/hq"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/source/lq"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/source/stream"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/stats"
-	"github.com/internetarchive/Zeno/v2/pkg/models"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/tracing"
 )
 
 var sourceInterface source.Source
@@ -76,13 +84,40 @@ func startPipeline() error {
 		}
 	}
 
-	// Start the reactor that will receive
+	// Load the cookie jar shared by all workers if needed
+	if config.Get().Cookies != "" {
+		err := cookies.Start(config.Get().Cookies)
+		if err != nil {
+			logger.Error("unable to load cookies", "file", config.Get().Cookies, "err", err.Error())
+			return err
+		}
+		logger.Info("cookies loaded", "file", config.Get().Cookies, "count", cookies.Get().Len())
+	}
+
+	// Start tracing the seeds before they enter the reactor
+	if config.Get().OTelEndpoint != "" {
+		err := tracing.Start(config.Get().OTelEndpoint, config.Get().OTelSampleRatio)
+		if err != nil {
+			logger.Error("error starting tracing", "err", err.Error())
+			return err
+		}
+	}
+
+	// Start the reactor that will receive, with room for the tokens the autoscaler can add
 	reactorOutputChan := makeStageChannel(config.Get().WorkersCount)
-	err = reactor.Start(config.Get().WorkersCount, reactorOutputChan)
+	reactorMaxTokens := config.Get().WorkersCount
+	if config.Get().Autoscale {
+		reactorMaxTokens = config.Get().AutoscaleMaxWorkers
+	}
+	err = reactor.Start(reactorMaxTokens, reactorOutputChan)
 	if err != nil {
 		logger.Error("error starting reactor", "err", err.Error())
 		return err
 	}
+	if err := reactor.SetTokenLimit(config.Get().WorkersCount); err != nil {
+		logger.Error("error setting reactor token limit", "err", err.Error())
+		return err
+	}
 
 	// If needed, create the seencheck DB (only if not using HQ)
 	if config.Get().UseSeencheck && !config.Get().UseHQ {
@@ -100,6 +135,25 @@ func startPipeline() error {
 		return err
 	}
 
+	// Start the crawl log before the archiver writes to it
+	if config.Get().CrawlLog {
+		logDir := config.Get().LogFileOutputDir
+		if logDir == "" {
+			logDir = path.Join(config.Get().JobPath, "logs")
+		}
+
+		rotatePeriod, err := time.ParseDuration(config.Get().LogFileRotation)
+		if err != nil && config.Get().LogFileRotation != "" {
+			rotatePeriod = 1 * time.Hour
+		}
+
+		err = crawllog.Start(logDir, rotatePeriod)
+		if err != nil {
+			logger.Error("error starting crawl log", "err", err.Error())
+			return err
+		}
+	}
+
 	archiverOutputChan := makeStageChannel(config.Get().WorkersCount)
 	err = archiver.Start(preprocessorOutputChan, archiverOutputChan)
 	if err != nil {
@@ -107,6 +161,29 @@ func startPipeline() error {
 		return err
 	}
 
+	// Start the robots.txt cache if needed, robots.txt are fetched with the WARC-writing client so they get archived
+	robotsPolicy, err := robots.ParsePolicy(config.Get().RobotsPolicy)
+	if err != nil {
+		logger.Error("error starting robots.txt cache", "err", err.Error())
+		return err
+	}
+	robots.Start(archiver.RoutedClient{}, config.Get().UserAgent, robotsPolicy, archiver.SetCrawlDelay)
+
+	// Start indexing the WARC files as they get closed
+	if config.Get().WARCIndex != "" {
+		indexFormat, err := cdx.ParseFormat(config.Get().WARCIndex)
+		if err != nil {
+			logger.Error("error starting WARC indexer", "err", err.Error())
+			return err
+		}
+
+		err = cdx.Start(path.Join(config.Get().JobPath, "warcs"), path.Join(config.Get().JobPath, "indexes"), indexFormat, config.Get().WARCIndexMerge)
+		if err != nil {
+			logger.Error("error starting WARC indexer", "err", err.Error())
+			return err
+		}
+	}
+
 	// Start the WARC writing queue watcher
 	watchers.StartWatchWARCWritingQueue(1*time.Second, 2*time.Second, 250*time.Millisecond)
 
@@ -117,6 +194,20 @@ func startPipeline() error {
 		return err
 	}
 
+	// Start the discovery of the sitemaps of the domains crawled, pages found are fed back as new seeds
+	if !config.Get().DisableSitemapDiscovery {
+		err = sitemap.Start(archiver.RoutedClient{}, config.Get().UserAgent, domainscrawl.Match, postprocessor.EnqueueDiscovered)
+		if err != nil {
+			logger.Error("error starting sitemap discovery", "err", err.Error())
+			return err
+		}
+	}
+
+	// Start scaling the workers based on the backpressure of the stages after the archiver
+	if config.Get().Autoscale {
+		watchers.StartAutoscaler(config.Get().AutoscaleInterval, preprocessorOutputChan, archiverOutputChan, postprocessorOutputChan)
+	}
+
 	finisherFinishChan := makeStageChannel(config.Get().WorkersCount)
 	finisherProduceChan := makeStageChannel(config.Get().WorkersCount)
 
@@ -124,7 +215,14 @@ func startPipeline() error {
 		hqSource := hq.New(config.Get().HQKey, config.Get().HQSecret, config.Get().HQProject, config.Get().HQAddress, config.Get().HQTimeout, config.Get().HQSeencheckCacheSize, config.Get().HQGZIPRequests, config.Get().HQSeencheckURL)
 		preprocessor.SetSeenchecker(hqSource.SeencheckItem)
 		sourceInterface = hqSource
+	} else if config.Get().UseStream {
+		streamSource := stream.New(config.Get().StreamAddress, config.Get().StreamFinishFIFO)
+		if config.Get().UseSeencheck {
+			preprocessor.SetSeenchecker(seencheck.SeencheckItem)
+		}
+		sourceInterface = streamSource
 	} else {
+		// The input seeds are queued by LQ so that the crawl can be resumed from lq.db
 		lqSource := lq.New()
 		if config.Get().UseSeencheck {
 			preprocessor.SetSeenchecker(seencheck.SeencheckItem)
@@ -145,24 +243,6 @@ func startPipeline() error {
 		return err
 	}
 
-	// Pipe in the reactor the input seeds if any
-	if len(config.Get().InputSeeds) > 0 {
-		for _, seed := range config.Get().InputSeeds {
-			parsedURL, err := models.NewURL(seed)
-			if err != nil {
-				return err
-			}
-
-			item := models.NewItem(&parsedURL, "")
-			item.SetSource(models.ItemSourceQueue)
-
-			err = reactor.ReceiveInsert(item)
-			if err != nil {
-				logger.Error("unable to insert seed", "err", err.Error())
-				return err
-			}
-		}
-	}
 	return nil
 }
 
@@ -173,11 +253,17 @@ func stopPipeline() {
 
 	watchers.StopDiskWatcher()
 	watchers.StopWARCWritingQueueWatcher()
+	watchers.StopAutoscaler()
 
 	reactor.Freeze()
 
 	preprocessor.Stop()
+	// The sitemap workers fetch through the WARC writers, they are stopped before them
+	sitemap.Stop()
+	robots.Stop()
 	archiver.Stop()
+	crawllog.Stop()
+	cdx.Stop()
 	postprocessor.Stop()
 	finisher.Stop()
 
@@ -187,7 +273,20 @@ func stopPipeline() {
 
 	sourceInterface.Stop()
 
+	if jar := cookies.Get(); jar != nil {
+		if config.Get().CookiesPersist {
+			cookiesPath := path.Join(config.Get().JobPath, "cookies.txt")
+			if err := jar.Save(cookiesPath); err != nil {
+				logger.Error("unable to save cookies", "file", cookiesPath, "err", err.Error())
+			} else {
+				logger.Info("cookies saved", "file", cookiesPath, "count", jar.Len())
+			}
+		}
+		cookies.Stop()
+	}
+
 	reactor.Stop()
+	tracing.Stop()
 
 	if config.Get().WARCTempDir != "" {
 		err := os.Remove(config.Get().WARCTempDir)
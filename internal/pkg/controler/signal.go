@@ -6,6 +6,8 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
 )
 
@@ -17,21 +19,42 @@ func WatchSignals() {
 	logger := log.NewFieldedLogger(&log.Fields{
 		"component": "controler.signalWatcher",
 	})
-	// Handle OS signals for graceful shutdown
-	signal.Notify(SignalChan, syscall.SIGINT, syscall.SIGTERM)
+	// Handle OS signals for graceful shutdown, SIGHUP reloads the crawl scope, the rate limit rules and the discard rules
+	signal.Notify(SignalChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
-	select {
-	case <-signalWatcherCtx.Done():
-		return
-	case <-SignalChan:
-		logger.Info("received shutdown signal, stopping services...")
-		// Catch a second signal to force exit
-		go func() {
-			<-SignalChan
-			logger.Info("received second shutdown signal, forcing exit...")
-			os.Exit(1)
-		}()
+	for {
+		select {
+		case <-signalWatcherCtx.Done():
+			return
+		case sig := <-SignalChan:
+			if sig == syscall.SIGHUP {
+				logger.Info("received SIGHUP, reloading scope...")
+				config.Get().ReloadScope()
+				if config.Get().RateLimitRulesFile != "" {
+					logger.Info("reloading rate limit rules...")
+					archiver.ReloadRateLimitRules()
+				}
+				if config.Get().DiscardRulesFile != "" {
+					logger.Info("reloading discard rules...")
+					archiver.ReloadDiscardRules()
+				}
+				continue
+			}
 
-		Stop()
+			logger.Info("received shutdown signal, stopping services...")
+			// Catch a second signal to force exit
+			go func() {
+				for sig := range SignalChan {
+					if sig == syscall.SIGHUP {
+						continue
+					}
+					logger.Info("received second shutdown signal, forcing exit...")
+					os.Exit(1)
+				}
+			}()
+
+			Stop()
+			return
+		}
 	}
 }
@@ -0,0 +1,185 @@
+package watchers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/controler/pause"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/reactor"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+)
+
+var (
+	autoscaleCtx, autoscaleCancel = context.WithCancel(context.Background())
+	autoscaleWg                   sync.WaitGroup
+)
+
+// Thresholds of the autoscaling decisions
+const (
+	autoscaleMaxOutputFill   = 0.9  // Fill ratio of the channels after the archiver above which it shrinks
+	autoscaleMinInputFill    = 0.5  // Fill ratio of the archiver input channel above which it grows
+	autoscaleMaxCPU          = 0.9  // CPU usage above which it shrinks
+	autoscaleGrowMaxCPU      = 0.75 // CPU usage above which it doesn't grow
+	autoscaleMaxRespTimeRate = 2    // Ratio of the response time to its baseline above which it shrinks
+)
+
+// autoscaleSample is a measure of the pipeline backpressure
+type autoscaleSample struct {
+	workers      int           // Number of archiver workers
+	busyWorkers  int           // Number of archiver workers archiving a seed
+	tokenLimit   int           // Number of seeds the reactor lets in the pipeline
+	tokensInUse  int           // Number of seeds in the pipeline
+	inputFill    float64       // Fill ratio of the archiver input channel
+	outputFill   float64       // Highest fill ratio of the channels after the archiver
+	warcQueue    int           // Size of the WARC writing queue
+	warcQueueMax int           // Size of the WARC writing queue above which it shrinks, 0 means no limit
+	cpu          float64       // CPU usage between 0 and 1, negative if unknown
+	respTime     time.Duration // Mean response time since the previous sample, 0 if unknown
+	baseRespTime time.Duration // Baseline of the mean response time, 0 if unknown
+}
+
+// autoscaleDecision returns the number of workers the pipeline should have, between minWorkers and
+// maxWorkers, and the reason of the change if any. It shrinks by a quarter when any stage downstream
+// of the fetching is saturated or the hosts slow down, and grows by a tenth when the archiver is the
+// bottleneck and the machine has room for more.
+func autoscaleDecision(s autoscaleSample, minWorkers, maxWorkers int) (int, string) {
+	var (
+		target int
+		reason string
+	)
+
+	switch {
+	case s.warcQueueMax > 0 && s.warcQueue > s.warcQueueMax:
+		target, reason = s.workers-max(s.workers/4, 1), "WARC writing queue is full"
+	case s.outputFill >= autoscaleMaxOutputFill:
+		target, reason = s.workers-max(s.workers/4, 1), "postprocessing is lagging behind"
+	case s.cpu >= autoscaleMaxCPU:
+		target, reason = s.workers-max(s.workers/4, 1), "CPU is saturated"
+	case s.baseRespTime > 0 && s.respTime > autoscaleMaxRespTimeRate*s.baseRespTime:
+		target, reason = s.workers-max(s.workers/4, 1), "response time is increasing"
+	case s.cpu < autoscaleGrowMaxCPU && (s.inputFill >= autoscaleMinInputFill || (s.busyWorkers >= s.workers && s.tokensInUse >= s.tokenLimit)):
+		target, reason = s.workers+max(s.workers/10, 1), "archiver is the bottleneck"
+	default:
+		return s.workers, ""
+	}
+
+	target = min(max(target, minWorkers), maxWorkers)
+	if target == s.workers {
+		return s.workers, ""
+	}
+
+	return target, reason
+}
+
+// StartAutoscaler grows and shrinks the archiver workers and the reactor tokens between
+// --autoscale-min-workers and --autoscale-max-workers every interval, based on the fill of the
+// stage channels, the WARC writing queue, the CPU usage and the mean response time
+func StartAutoscaler(interval time.Duration, archiverInputChan chan *models.Item, downstreamChans ...chan *models.Item) {
+	autoscaleWg.Go(func() {
+		logger := log.NewFieldedLogger(&log.Fields{
+			"component": "controler.autoscaler",
+		})
+		defer logger.Debug("closed")
+
+		var (
+			cpu          cpuSampler
+			prevCount    uint64
+			prevSumMs    uint64
+			baseRespTime time.Duration
+		)
+
+		// The first CPU measure is the starting point
+		cpu.usage()
+		prevCount, prevSumMs = stats.MeanHTTPRespTimeTotals()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-autoscaleCtx.Done():
+				return
+			case <-ticker.C:
+				sample := autoscaleSample{
+					workers:     archiver.GetWorkersCount(),
+					busyWorkers: archiver.GetBusyWorkersCount(),
+					tokenLimit:  reactor.GetTokenLimit(),
+					tokensInUse: reactor.GetTokensInUse(),
+					inputFill:   channelFill(archiverInputChan),
+					warcQueue:   archiver.GetWARCWritingQueueSize(),
+					cpu:         cpu.usage(),
+				}
+				for _, ch := range downstreamChans {
+					sample.outputFill = max(sample.outputFill, channelFill(ch))
+				}
+
+				// Only async WARC writing has a queue that grows
+				if config.Get().WARCWriteAsync {
+					sample.warcQueueMax = config.Get().WARCQueueSize
+					if sample.warcQueueMax <= 0 {
+						sample.warcQueueMax = config.Get().WARCPoolSize
+					}
+				}
+
+				// Mean response time since the previous sample, its baseline follows it slowly when it increases
+				count, sumMs := stats.MeanHTTPRespTimeTotals()
+				if count > prevCount {
+					sample.respTime = time.Duration((sumMs-prevSumMs)/(count-prevCount)) * time.Millisecond
+					sample.baseRespTime = baseRespTime
+					if baseRespTime == 0 || sample.respTime < baseRespTime {
+						baseRespTime = sample.respTime
+					} else {
+						baseRespTime += (sample.respTime - baseRespTime) / 20
+					}
+				}
+				prevCount, prevSumMs = count, sumMs
+
+				// The workers are idle on purpose while the pipeline is paused
+				if pause.IsPaused() {
+					continue
+				}
+
+				target, reason := autoscaleDecision(sample, config.Get().AutoscaleMinWorkers, config.Get().AutoscaleMaxWorkers)
+				logger.Debug("sampled pipeline backpressure",
+					"workers", sample.workers,
+					"busy_workers", sample.busyWorkers,
+					"tokens_in_use", sample.tokensInUse,
+					"input_fill", sample.inputFill,
+					"output_fill", sample.outputFill,
+					"warc_queue", sample.warcQueue,
+					"cpu", sample.cpu,
+					"resp_time", sample.respTime,
+					"base_resp_time", sample.baseRespTime)
+
+				if target == sample.workers {
+					continue
+				}
+
+				logger.Info("scaling workers", "from", sample.workers, "to", target, "reason", reason)
+				if err := reactor.SetTokenLimit(target); err != nil {
+					logger.Error("unable to set the reactor token limit", "err", err.Error())
+					continue
+				}
+				archiver.SetWorkersCount(target)
+			}
+		}
+	})
+}
+
+// StopAutoscaler stops the autoscaler by canceling the context and waiting for the goroutine to finish
+func StopAutoscaler() {
+	autoscaleCancel()
+	autoscaleWg.Wait()
+}
+
+func channelFill(ch chan *models.Item) float64 {
+	if cap(ch) == 0 {
+		return 0
+	}
+	return float64(len(ch)) / float64(cap(ch))
+}
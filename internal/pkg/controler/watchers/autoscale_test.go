@@ -0,0 +1,178 @@
+package watchers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+)
+
+func TestAutoscaleDecision(t *testing.T) {
+	// Pipeline running at its current size without backpressure
+	steady := autoscaleSample{
+		workers:      20,
+		busyWorkers:  12,
+		tokenLimit:   20,
+		tokensInUse:  20,
+		inputFill:    0.1,
+		outputFill:   0.1,
+		warcQueue:    5,
+		warcQueueMax: 50,
+		cpu:          0.5,
+		respTime:     500 * time.Millisecond,
+		baseRespTime: 400 * time.Millisecond,
+	}
+
+	tests := []struct {
+		name       string
+		update     func(s *autoscaleSample)
+		minWorkers int
+		maxWorkers int
+		wantTarget int
+		wantReason bool
+	}{
+		{
+			name:       "steady",
+			update:     func(s *autoscaleSample) {},
+			minWorkers: 1,
+			maxWorkers: 100,
+			wantTarget: 20,
+		},
+		{
+			name:       "seeds waiting for the archiver",
+			update:     func(s *autoscaleSample) { s.inputFill = 0.8 },
+			minWorkers: 1,
+			maxWorkers: 100,
+			wantTarget: 22,
+			wantReason: true,
+		},
+		{
+			name:       "all workers busy and all tokens in use",
+			update:     func(s *autoscaleSample) { s.busyWorkers = 20 },
+			minWorkers: 1,
+			maxWorkers: 100,
+			wantTarget: 22,
+			wantReason: true,
+		},
+		{
+			name:       "grow bounded by max",
+			update:     func(s *autoscaleSample) { s.inputFill = 0.8 },
+			minWorkers: 1,
+			maxWorkers: 21,
+			wantTarget: 21,
+			wantReason: true,
+		},
+		{
+			name:       "already at max",
+			update:     func(s *autoscaleSample) { s.inputFill = 0.8 },
+			minWorkers: 1,
+			maxWorkers: 20,
+			wantTarget: 20,
+		},
+		{
+			name:       "no grow when CPU is busy",
+			update:     func(s *autoscaleSample) { s.inputFill = 0.8; s.cpu = 0.8 },
+			minWorkers: 1,
+			maxWorkers: 100,
+			wantTarget: 20,
+		},
+		{
+			name:       "grow when CPU is unknown",
+			update:     func(s *autoscaleSample) { s.inputFill = 0.8; s.cpu = -1 },
+			minWorkers: 1,
+			maxWorkers: 100,
+			wantTarget: 22,
+			wantReason: true,
+		},
+		{
+			name:       "WARC writing queue full",
+			update:     func(s *autoscaleSample) { s.inputFill = 0.8; s.warcQueue = 60 },
+			minWorkers: 1,
+			maxWorkers: 100,
+			wantTarget: 15,
+			wantReason: true,
+		},
+		{
+			name:       "WARC writing queue without limit",
+			update:     func(s *autoscaleSample) { s.warcQueue = 60; s.warcQueueMax = 0 },
+			minWorkers: 1,
+			maxWorkers: 100,
+			wantTarget: 20,
+		},
+		{
+			name:       "postprocessing lagging behind",
+			update:     func(s *autoscaleSample) { s.outputFill = 1 },
+			minWorkers: 1,
+			maxWorkers: 100,
+			wantTarget: 15,
+			wantReason: true,
+		},
+		{
+			name:       "CPU saturated",
+			update:     func(s *autoscaleSample) { s.cpu = 0.95 },
+			minWorkers: 1,
+			maxWorkers: 100,
+			wantTarget: 15,
+			wantReason: true,
+		},
+		{
+			name:       "response time increasing",
+			update:     func(s *autoscaleSample) { s.respTime = time.Second },
+			minWorkers: 1,
+			maxWorkers: 100,
+			wantTarget: 15,
+			wantReason: true,
+		},
+		{
+			name:       "response time without baseline",
+			update:     func(s *autoscaleSample) { s.respTime = time.Second; s.baseRespTime = 0 },
+			minWorkers: 1,
+			maxWorkers: 100,
+			wantTarget: 20,
+		},
+		{
+			name:       "shrink bounded by min",
+			update:     func(s *autoscaleSample) { s.cpu = 0.95 },
+			minWorkers: 18,
+			maxWorkers: 100,
+			wantTarget: 18,
+			wantReason: true,
+		},
+		{
+			name:       "shrink a single worker",
+			update:     func(s *autoscaleSample) { s.workers = 2; s.cpu = 0.95 },
+			minWorkers: 1,
+			maxWorkers: 100,
+			wantTarget: 1,
+			wantReason: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sample := steady
+			tt.update(&sample)
+
+			target, reason := autoscaleDecision(sample, tt.minWorkers, tt.maxWorkers)
+			if target != tt.wantTarget {
+				t.Errorf("expected target %d, got %d", tt.wantTarget, target)
+			}
+			if (reason != "") != tt.wantReason {
+				t.Errorf("unexpected reason %q", reason)
+			}
+		})
+	}
+}
+
+func TestChannelFill(t *testing.T) {
+	unbuffered := make(chan *models.Item)
+	if got := channelFill(unbuffered); got != 0 {
+		t.Errorf("expected 0 for an unbuffered channel, got %f", got)
+	}
+
+	buffered := make(chan *models.Item, 4)
+	buffered <- nil
+	if got := channelFill(buffered); got != 0.25 {
+		t.Errorf("expected 0.25, got %f", got)
+	}
+}
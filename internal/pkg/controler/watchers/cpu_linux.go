@@ -0,0 +1,61 @@
+//go:build linux
+
+package watchers
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// cpuSampler measures the CPU usage of the machine between two calls to usage
+type cpuSampler struct {
+	idle  uint64
+	total uint64
+}
+
+// usage returns the CPU usage since the previous call, between 0 and 1, or -1 if unknown
+func (c *cpuSampler) usage() float64 {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return -1
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		return -1
+	}
+
+	// cpu  user nice system idle iowait irq softirq steal guest guest_nice
+	fields := strings.Fields(scanner.Text())
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return -1
+	}
+
+	var idle, total uint64
+	for i, field := range fields[1:] {
+		value, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return -1
+		}
+		// guest and guest_nice are already accounted in user and nice
+		if i < 8 {
+			total += value
+		}
+		// idle and iowait
+		if i == 3 || i == 4 {
+			idle += value
+		}
+	}
+
+	prevIdle, prevTotal := c.idle, c.total
+	c.idle, c.total = idle, total
+
+	if prevTotal == 0 || total <= prevTotal {
+		return -1
+	}
+
+	return 1 - float64(idle-prevIdle)/float64(total-prevTotal)
+}
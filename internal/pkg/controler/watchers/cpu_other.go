@@ -0,0 +1,11 @@
+//go:build !linux
+
+package watchers
+
+// cpuSampler measures the CPU usage of the machine, it is only implemented on Linux
+type cpuSampler struct{}
+
+// usage returns -1 as the CPU usage is unknown
+func (c *cpuSampler) usage() float64 {
+	return -1
+}
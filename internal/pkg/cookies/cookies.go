@@ -0,0 +1,248 @@
+// Package cookies implements the cookie jar shared by all workers. It is
+// seeded from a Netscape/curl cookies.txt file (--cookies), absorbs the
+// Set-Cookie headers of the responses we get and can be written back to disk
+// in the same format when the crawl stops.
+package cookies
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Jar is a thread-safe http.CookieJar that can be enumerated and persisted
+type Jar struct {
+	mu      sync.RWMutex
+	entries map[string]*entry // key: domain;path;name
+	nowFunc func() time.Time
+}
+
+type entry struct {
+	Domain   string // Always lowercase and without leading dot
+	HostOnly bool
+	Path     string
+	Secure   bool
+	HttpOnly bool
+	Expires  time.Time // Zero value means session cookie
+	Name     string
+	Value    string
+}
+
+// globalJar is read by every worker while Start and Stop replace it
+var globalJar atomic.Pointer[Jar]
+
+// New returns an empty cookie jar
+func New() *Jar {
+	return &Jar{
+		entries: make(map[string]*entry),
+		nowFunc: time.Now,
+	}
+}
+
+// Start loads the given cookies.txt file into the global cookie jar
+func Start(path string) error {
+	jar := New()
+	if err := jar.Load(path); err != nil {
+		return err
+	}
+
+	globalJar.Store(jar)
+
+	return nil
+}
+
+// Get returns the global cookie jar, nil if --cookies is not set
+func Get() *Jar {
+	return globalJar.Load()
+}
+
+// Stop releases the global cookie jar
+func Stop() {
+	globalJar.Store(nil)
+}
+
+// Len returns the number of cookies currently stored in the jar
+func (j *Jar) Len() int {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return len(j.entries)
+}
+
+// Cookies implements http.CookieJar, it returns the cookies to send in a request for the given URL
+func (j *Jar) Cookies(u *url.URL) (cookies []*http.Cookie) {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil
+	}
+
+	host := canonicalHost(u.Hostname())
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	now := j.nowFunc()
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	for _, e := range j.entries {
+		if e.expired(now) || (e.Secure && u.Scheme != "https") {
+			continue
+		}
+
+		if !e.domainMatch(host) || !pathMatch(path, e.Path) {
+			continue
+		}
+
+		cookies = append(cookies, &http.Cookie{Name: e.Name, Value: e.Value})
+	}
+
+	return cookies
+}
+
+// SetCookies implements http.CookieJar, it stores the cookies received in a response from the given URL
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if len(cookies) == 0 || (u.Scheme != "http" && u.Scheme != "https") {
+		return
+	}
+
+	host := canonicalHost(u.Hostname())
+	now := j.nowFunc()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, c := range cookies {
+		e, ok := newEntry(c, host, u.EscapedPath(), now)
+		if !ok {
+			continue
+		}
+
+		if e.expired(now) {
+			delete(j.entries, e.key())
+			continue
+		}
+
+		j.entries[e.key()] = e
+	}
+}
+
+// AddToRequest attaches the cookies of the jar matching the request URL, cookies
+// already present in the request with the same name are left untouched.
+func (j *Jar) AddToRequest(req *http.Request) {
+	existing := req.Cookies()
+
+	for _, c := range j.Cookies(req.URL) {
+		exists := false
+		for _, e := range existing {
+			if e.Name == c.Name {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			req.AddCookie(c)
+		}
+	}
+}
+
+func newEntry(c *http.Cookie, host, requestPath string, now time.Time) (*entry, bool) {
+	if c.Name == "" {
+		return nil, false
+	}
+
+	e := &entry{
+		Name:     c.Name,
+		Value:    c.Value,
+		Secure:   c.Secure,
+		HttpOnly: c.HttpOnly,
+	}
+
+	// Domain attribute, see RFC 6265 section 5.3 step 4 to 6
+	domain := canonicalHost(strings.TrimPrefix(c.Domain, "."))
+	if domain == "" || domain == host {
+		e.Domain = host
+		e.HostOnly = domain == ""
+	} else {
+		if !strings.HasSuffix(host, "."+domain) {
+			return nil, false
+		}
+
+		// Reject cookies set for a public suffix (e.g. .co.uk)
+		if ps, _ := publicsuffix.PublicSuffix(domain); ps == domain {
+			return nil, false
+		}
+
+		e.Domain = domain
+	}
+
+	// Path attribute, see RFC 6265 section 5.2.4
+	if c.Path != "" && c.Path[0] == '/' {
+		e.Path = c.Path
+	} else {
+		e.Path = defaultPath(requestPath)
+	}
+
+	// Max-Age has precedence over Expires
+	switch {
+	case c.MaxAge < 0:
+		e.Expires = time.Unix(1, 0)
+	case c.MaxAge > 0:
+		e.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+	case !c.Expires.IsZero():
+		e.Expires = c.Expires
+	}
+
+	return e, true
+}
+
+func (e *entry) key() string {
+	return e.Domain + ";" + e.Path + ";" + e.Name
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.Expires.IsZero() && !e.Expires.After(now)
+}
+
+func (e *entry) domainMatch(host string) bool {
+	if e.Domain == host {
+		return true
+	}
+
+	return !e.HostOnly && strings.HasSuffix(host, "."+e.Domain)
+}
+
+// pathMatch implements the path-match algorithm of RFC 6265 section 5.1.4
+func pathMatch(requestPath, cookiePath string) bool {
+	if requestPath == cookiePath {
+		return true
+	}
+
+	if strings.HasPrefix(requestPath, cookiePath) {
+		return cookiePath[len(cookiePath)-1] == '/' || requestPath[len(cookiePath)] == '/'
+	}
+
+	return false
+}
+
+// defaultPath implements the default-path algorithm of RFC 6265 section 5.1.4
+func defaultPath(requestPath string) string {
+	if requestPath == "" || requestPath[0] != '/' {
+		return "/"
+	}
+
+	i := strings.LastIndex(requestPath, "/")
+	if i == 0 {
+		return "/"
+	}
+
+	return requestPath[:i]
+}
+
+func canonicalHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
@@ -0,0 +1,189 @@
+package cookies
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleCookiesTXT = "# Netscape HTTP Cookie File\n" +
+	"\n" +
+	".example.com\tTRUE\t/\tFALSE\t0\tsession\tabc\n" +
+	"www.example.com\tFALSE\t/private\tTRUE\t4102444800\ttoken\txyz\n" +
+	"#HttpOnly_.example.com\tTRUE\t/\tFALSE\t0\tsid\t42\n" +
+	".example.com\tTRUE\t/\tFALSE\t1\texpired\tgone\n"
+
+func mustParse(t *testing.T, rawURL string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("unable to parse URL %q: %v", rawURL, err)
+	}
+	return u
+}
+
+func cookieNames(cookies []*http.Cookie) map[string]string {
+	names := make(map[string]string, len(cookies))
+	for _, c := range cookies {
+		names[c.Name] = c.Value
+	}
+	return names
+}
+
+func TestRead(t *testing.T) {
+	jar := New()
+	if err := jar.Read(strings.NewReader(sampleCookiesTXT)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if jar.Len() != 3 {
+		t.Fatalf("expected 3 cookies (expired one skipped), got %d", jar.Len())
+	}
+
+	tests := []struct {
+		name     string
+		url      string
+		expected []string
+	}{
+		{"subdomain over http", "http://sub.example.com/", []string{"session", "sid"}},
+		{"host-only secure cookie over http", "http://www.example.com/private/page", []string{"session", "sid"}},
+		{"host-only secure cookie over https", "https://www.example.com/private/page", []string{"session", "sid", "token"}},
+		{"path mismatch", "https://www.example.com/privateer", []string{"session", "sid"}},
+		{"other domain", "https://example.org/", nil},
+		{"non-http scheme", "ftp://example.com/", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cookieNames(jar.Cookies(mustParse(t, tt.url)))
+			if len(got) != len(tt.expected) {
+				t.Fatalf("expected cookies %v, got %v", tt.expected, got)
+			}
+			for _, name := range tt.expected {
+				if _, ok := got[name]; !ok {
+					t.Errorf("expected cookie %q in %v", name, got)
+				}
+			}
+		})
+	}
+}
+
+func TestReadInvalidLine(t *testing.T) {
+	jar := New()
+	err := jar.Read(strings.NewReader("example.com\tTRUE\t/\n"))
+	if err == nil {
+		t.Fatal("expected an error for a line with missing fields")
+	}
+}
+
+func TestSetCookies(t *testing.T) {
+	base := time.Unix(1_700_000_000, 0)
+	jar := New()
+	jar.nowFunc = func() time.Time { return base }
+
+	u := mustParse(t, "https://www.example.com/account/login")
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "host", Value: "1"},
+		{Name: "domain", Value: "2", Domain: ".example.com", Path: "/"},
+		{Name: "foreign", Value: "3", Domain: "example.org"},
+		{Name: "suffix", Value: "4", Domain: "com"},
+		{Name: "short", Value: "5", MaxAge: 10},
+	})
+
+	if jar.Len() != 3 {
+		t.Fatalf("expected 3 cookies stored, got %d", jar.Len())
+	}
+
+	got := cookieNames(jar.Cookies(mustParse(t, "https://www.example.com/account/settings")))
+	for _, name := range []string{"host", "domain", "short"} {
+		if _, ok := got[name]; !ok {
+			t.Errorf("expected cookie %q in %v", name, got)
+		}
+	}
+
+	// Host-only and default path cookies must not leak
+	got = cookieNames(jar.Cookies(mustParse(t, "https://cdn.example.com/")))
+	if len(got) != 1 || got["domain"] != "2" {
+		t.Errorf("expected only the domain cookie, got %v", got)
+	}
+
+	// Max-Age expiration
+	base = base.Add(time.Minute)
+	got = cookieNames(jar.Cookies(mustParse(t, "https://www.example.com/account/")))
+	if _, ok := got["short"]; ok {
+		t.Errorf("expected cookie short to be expired, got %v", got)
+	}
+
+	// Deletion through a negative Max-Age
+	jar.SetCookies(u, []*http.Cookie{{Name: "domain", Domain: "example.com", Path: "/", MaxAge: -1}})
+	got = cookieNames(jar.Cookies(mustParse(t, "https://cdn.example.com/")))
+	if len(got) != 0 {
+		t.Errorf("expected no cookies after deletion, got %v", got)
+	}
+}
+
+func TestAddToRequest(t *testing.T) {
+	jar := New()
+	if err := jar.Read(strings.NewReader(sampleCookiesTXT)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req.AddCookie(&http.Cookie{Name: "session", Value: "already-set"})
+
+	jar.AddToRequest(req)
+
+	got := cookieNames(req.Cookies())
+	if got["session"] != "already-set" {
+		t.Errorf("expected existing cookie to be kept, got %q", got["session"])
+	}
+	if got["sid"] != "42" {
+		t.Errorf("expected sid cookie to be added, got %v", got)
+	}
+	if len(req.Cookies()) != 2 {
+		t.Errorf("expected 2 cookies in request, got %d", len(req.Cookies()))
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	jar := New()
+	if err := jar.Read(strings.NewReader(sampleCookiesTXT)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	if err := jar.Save(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Contains(data, []byte("#HttpOnly_.example.com\tTRUE\t/\tFALSE\t0\tsid\t42")) {
+		t.Errorf("expected HttpOnly cookie to be written, got:\n%s", data)
+	}
+
+	reloaded := New()
+	if err := reloaded.Load(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reloaded.Len() != jar.Len() {
+		t.Fatalf("expected %d cookies after reload, got %d", jar.Len(), reloaded.Len())
+	}
+
+	var a, b bytes.Buffer
+	jar.Write(&a)
+	reloaded.Write(&b)
+	if a.String() != b.String() {
+		t.Errorf("round trip mismatch:\n%s\nvs\n%s", a.String(), b.String())
+	}
+}
@@ -0,0 +1,154 @@
+package cookies
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const httpOnlyPrefix = "#HttpOnly_"
+
+// Load reads a Netscape/curl cookies.txt file into the jar
+func (j *Jar) Load(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to open cookies file: %w", err)
+	}
+	defer file.Close()
+
+	return j.Read(file)
+}
+
+// Read parses Netscape/curl cookies.txt formatted data into the jar.
+// Each line is made of 7 tab-separated fields: domain, include subdomains,
+// path, secure, expiration (unix timestamp, 0 for session cookies), name and value.
+func (j *Jar) Read(r io.Reader) error {
+	now := j.nowFunc()
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		httpOnly := false
+		if strings.HasPrefix(line, httpOnlyPrefix) {
+			httpOnly = true
+			line = strings.TrimPrefix(line, httpOnlyPrefix)
+		}
+
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return fmt.Errorf("invalid cookies file line %d: expected 7 tab-separated fields, got %d", lineNumber, len(fields))
+		}
+
+		e := &entry{
+			Domain:   canonicalHost(strings.TrimPrefix(fields[0], ".")),
+			HostOnly: !strings.EqualFold(fields[1], "TRUE"),
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			HttpOnly: httpOnly,
+			Name:     fields[5],
+			Value:    fields[6],
+		}
+
+		if e.Domain == "" || e.Name == "" {
+			return fmt.Errorf("invalid cookies file line %d: empty domain or name", lineNumber)
+		}
+
+		if e.Path == "" || e.Path[0] != '/' {
+			e.Path = "/"
+		}
+
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid cookies file line %d: invalid expiration: %w", lineNumber, err)
+		}
+		if expires > 0 {
+			e.Expires = time.Unix(expires, 0)
+		}
+
+		if e.expired(now) {
+			continue
+		}
+
+		j.entries[e.key()] = e
+	}
+
+	return scanner.Err()
+}
+
+// Write writes the non-expired cookies of the jar in the Netscape/curl cookies.txt format
+func (j *Jar) Write(w io.Writer) error {
+	now := j.nowFunc()
+
+	j.mu.RLock()
+	entries := make([]*entry, 0, len(j.entries))
+	for _, e := range j.entries {
+		if !e.expired(now) {
+			entries = append(entries, e)
+		}
+	}
+	j.mu.RUnlock()
+
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].key() < entries[b].key()
+	})
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# Netscape HTTP Cookie File")
+	fmt.Fprintln(bw, "# Generated by Zeno, edit at your own risk.")
+	fmt.Fprintln(bw)
+
+	for _, e := range entries {
+		domain, includeSubdomains := e.Domain, "FALSE"
+		if !e.HostOnly {
+			domain, includeSubdomains = "."+e.Domain, "TRUE"
+		}
+		if e.HttpOnly {
+			domain = httpOnlyPrefix + domain
+		}
+
+		var expires int64
+		if !e.Expires.IsZero() {
+			expires = e.Expires.Unix()
+		}
+
+		fmt.Fprintf(bw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", domain, includeSubdomains, e.Path, strings.ToUpper(strconv.FormatBool(e.Secure)), expires, e.Name, e.Value)
+	}
+
+	return bw.Flush()
+}
+
+// Save atomically writes the jar to the given path in the Netscape/curl cookies.txt format
+func (j *Jar) Save(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cookies-*.txt")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary cookies file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := j.Write(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to write cookies file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to write cookies file: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
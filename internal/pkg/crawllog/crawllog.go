@@ -0,0 +1,158 @@
+// Package crawllog writes the crawl log of the job (--crawl-log): one line per
+// fetched URL in the format of the Heritrix crawl.log, so that the outcome of
+// every URL can be read back without parsing the application logs. The files
+// are written next to the log files and rotated along with them.
+package crawllog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
+)
+
+// Prefix of the crawl log files, followed by the time they were opened at
+const filePrefix = "crawl"
+
+type crawlLog struct {
+	mu     sync.Mutex
+	wg     sync.WaitGroup
+	dir    string
+	file   *os.File
+	ticker *time.Ticker
+	stopCh chan struct{}
+}
+
+var (
+	globalCrawlLog *crawlLog
+	once           sync.Once
+	logger         = log.NewFieldedLogger(&log.Fields{
+		"component": "crawllog",
+	})
+)
+
+// Start opens a crawl log file in dir, a new file is opened every rotatePeriod if it isn't 0
+func Start(dir string, rotatePeriod time.Duration) (err error) {
+	var done bool
+
+	once.Do(func() {
+		done = true
+
+		c := &crawlLog{
+			dir:    dir,
+			stopCh: make(chan struct{}),
+		}
+
+		if err = os.MkdirAll(dir, 0755); err != nil {
+			return
+		}
+		if err = c.rotate(); err != nil {
+			return
+		}
+
+		if rotatePeriod > 0 {
+			c.ticker = time.NewTicker(rotatePeriod)
+			c.wg.Add(1)
+			go c.run()
+		}
+
+		globalCrawlLog = c
+		logger.Info("started", "dir", dir, "rotation", rotatePeriod)
+	})
+
+	if !done {
+		return ErrCrawlLogAlreadyInitialized
+	}
+
+	if err != nil {
+		once = sync.Once{}
+	}
+
+	return err
+}
+
+// Stop closes the crawl log file, it must be called once the archiver is stopped
+func Stop() {
+	if globalCrawlLog == nil {
+		return
+	}
+
+	if globalCrawlLog.ticker != nil {
+		globalCrawlLog.ticker.Stop()
+	}
+	close(globalCrawlLog.stopCh)
+	globalCrawlLog.wg.Wait()
+
+	globalCrawlLog.mu.Lock()
+	if err := globalCrawlLog.file.Close(); err != nil {
+		logger.Error("unable to close crawl log", "err", err.Error())
+	}
+	globalCrawlLog.file = nil
+	globalCrawlLog.mu.Unlock()
+
+	globalCrawlLog = nil
+	once = sync.Once{}
+	logger.Info("stopped")
+}
+
+// Enabled returns true if the crawl log is started
+func Enabled() bool {
+	return globalCrawlLog != nil
+}
+
+// Write appends the entry to the crawl log, it is a no-op if the crawl log isn't started
+func Write(entry *Entry) {
+	c := globalCrawlLog
+	if c == nil {
+		return
+	}
+
+	line := entry.String() + "\n"
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return
+	}
+	if _, err := c.file.WriteString(line); err != nil {
+		logger.Error("unable to write to crawl log", "err", err.Error())
+	}
+}
+
+// rotate closes the current file, if any, and opens a new one
+func (c *crawlLog) rotate() error {
+	filename := filepath.Join(c.dir, fmt.Sprintf("%s-%s.log", filePrefix, time.Now().Format("2006.01.02T15-04")))
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file != nil {
+		if err := c.file.Close(); err != nil {
+			logger.Error("unable to close crawl log", "err", err.Error())
+		}
+	}
+	c.file = file
+
+	return nil
+}
+
+func (c *crawlLog) run() {
+	defer c.wg.Done()
+
+	for {
+		select {
+		case <-c.ticker.C:
+			if err := c.rotate(); err != nil {
+				logger.Error("unable to rotate crawl log, keeping the current file", "err", err.Error())
+			}
+		case <-c.stopCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package crawllog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readCrawlLogs(t *testing.T, dir string) (files []string, lines []string) {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, filePrefix+"-*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, strings.Split(strings.TrimSpace(string(content)), "\n")...)
+	}
+
+	return files, lines
+}
+
+func TestWrite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+
+	// Writing without a started crawl log is a no-op
+	Write(&Entry{URL: "https://example.com/ignored"})
+
+	if err := Start(dir, 0); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := Start(dir, 0); err != ErrCrawlLogAlreadyInitialized {
+		t.Errorf("expected %v on second Start(), got %v", ErrCrawlLogAlreadyInitialized, err)
+	}
+
+	if !Enabled() {
+		t.Fatal("expected the crawl log to be enabled")
+	}
+
+	Write(&Entry{Status: 200, URL: "https://example.com/"})
+	Write(&Entry{Status: 404, URL: "https://example.com/missing"})
+	Stop()
+
+	if Enabled() {
+		t.Fatal("expected the crawl log to be disabled once stopped")
+	}
+
+	files, lines := readCrawlLogs(t, dir)
+	if len(files) != 1 {
+		t.Fatalf("expected 1 crawl log file, got %d", len(files))
+	}
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), lines)
+	}
+	if !strings.Contains(lines[0], " 200 ") || !strings.Contains(lines[1], "https://example.com/missing") {
+		t.Errorf("unexpected lines: %q", lines)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := Start(dir, 10*time.Millisecond); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	for range 5 {
+		Write(&Entry{Status: 200, URL: "https://example.com/"})
+		time.Sleep(20 * time.Millisecond)
+	}
+	Stop()
+
+	// Files are named after the minute they are opened at, the lines all end up in them
+	_, lines := readCrawlLogs(t, dir)
+	if len(lines) != 5 {
+		t.Fatalf("expected 5 lines, got %d", len(lines))
+	}
+}
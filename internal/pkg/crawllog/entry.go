@@ -0,0 +1,186 @@
+package crawllog
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+)
+
+// Negative status codes, as used by Heritrix, for the URLs that got no response
+const (
+	StatusDNSFailed       = -1    // DNS lookup failed
+	StatusConnectFailed   = -2    // Connection failed
+	StatusConnectBroken   = -3    // Connection lost after it was established
+	StatusTimeout         = -4    // Request timed out
+	StatusFailed          = -5    // Any other error
+	StatusQuotaExceeded   = -5003 // Blocked by the item or size budget of the seed
+	StatusRuntimeExceeded = -5004 // Blocked by the duration budget of the seed
+)
+
+const (
+	timestampFormat      = "2006-01-02T15:04:05.000Z"
+	fetchTimestampFormat = "20060102150405"
+)
+
+// Entry is a line of the crawl log
+type Entry struct {
+	Timestamp     time.Time     // When the fetch ended
+	Status        int           // HTTP status code, or one of the negative Status* codes
+	Size          int64         // Bytes of the response body, negative if unknown
+	URL           string        // URL fetched
+	HopPath       string        // Hops from the seed, L for outlinks, E for assets and R for redirections
+	Via           string        // URL the URL was found on, or the via of the seed
+	MIME          string        // Media type of the response
+	FetchStart    time.Time     // When the fetch started
+	Duration      time.Duration // Time spent fetching, retries included
+	Digest        string        // SHA-1 of the response body, as in WARC-Payload-Digest
+	SeedID        string        // ID of the seed the URL was crawled from
+	DiscardReason string        // Reason code of the discard hook, if the response was discarded
+	Retries       int           // Number of retries
+}
+
+// NewEntry returns an entry for the item with its URL, hop path, via and seed filled in,
+// the fetch is considered started now
+func NewEntry(item *models.Item) *Entry {
+	entry := &Entry{
+		Size:       -1,
+		URL:        item.GetURL().String(),
+		HopPath:    HopPath(item),
+		SeedID:     item.GetSeed().GetID(),
+		FetchStart: time.Now(),
+	}
+
+	if item.IsSeed() {
+		entry.Via = item.GetSeedVia()
+	} else {
+		entry.Via = item.GetParent().GetURL().String()
+	}
+
+	return entry
+}
+
+// End sets the time the fetch ended at
+func (e *Entry) End() {
+	e.Timestamp = time.Now()
+	e.Duration = e.Timestamp.Sub(e.FetchStart)
+}
+
+// String formats the entry as a crawl.log line: timestamp, status, size, URL, hop path,
+// via, MIME, thread (unused), fetch timestamp+duration, digest, seed ID and annotations
+func (e *Entry) String() string {
+	size := "-"
+	if e.Size >= 0 {
+		size = fmt.Sprint(e.Size)
+	}
+
+	fetch := "-"
+	if !e.FetchStart.IsZero() {
+		start := e.FetchStart.UTC()
+		fetch = fmt.Sprintf("%s%03d+%d", start.Format(fetchTimestampFormat), start.Nanosecond()/int(time.Millisecond), e.Duration.Milliseconds())
+	}
+
+	annotations := []string{fmt.Sprintf("retries:%d", e.Retries)}
+	if e.DiscardReason != "" {
+		annotations = append(annotations, "discard:"+e.DiscardReason)
+	}
+
+	return fmt.Sprintf("%s %5d %10s %s %s %s %s - %s %s %s %s",
+		e.Timestamp.UTC().Format(timestampFormat),
+		e.Status,
+		size,
+		field(e.URL),
+		field(e.HopPath),
+		field(e.Via),
+		field(e.MIME),
+		fetch,
+		field(e.Digest),
+		field(e.SeedID),
+		field(strings.Join(annotations, ",")))
+}
+
+var fieldReplacer = strings.NewReplacer(" ", "%20", "\t", "%09", "\r", "%0D", "\n", "%0A")
+
+// field returns s without whitespaces so that it fits in a column, or - if empty
+func field(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return fieldReplacer.Replace(s)
+}
+
+// HopPath returns the hop path of the item from its seed: an L per hop of the seed,
+// then an R per redirection and an E per asset down to the item
+func HopPath(item *models.Item) string {
+	var path []byte
+	for i := item; !i.IsSeed(); i = i.GetParent() {
+		if i.IsRedirection() {
+			path = append(path, 'R')
+		} else {
+			path = append(path, 'E')
+		}
+	}
+	slices.Reverse(path)
+
+	return strings.Repeat("L", item.GetSeed().GetURL().GetHops()) + string(path)
+}
+
+// MIME returns the media type of a Content-Type header, without its parameters
+func MIME(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+// StatusFromError returns the negative status code matching a request error
+func StatusFromError(err error) int {
+	var (
+		dnsErr *net.DNSError
+		opErr  *net.OpError
+		netErr net.Error
+	)
+
+	switch {
+	case errors.As(err, &dnsErr):
+		return StatusDNSFailed
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return StatusTimeout
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return StatusConnectFailed
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return StatusConnectBroken
+	default:
+		return StatusFailed
+	}
+}
+
+// Digester computes the digest of a response body as it is read
+type Digester struct {
+	hash hash.Hash
+}
+
+// NewDigester returns a SHA-1 digester
+func NewDigester() *Digester {
+	return &Digester{hash: sha1.New()}
+}
+
+// Write implements io.Writer
+func (d *Digester) Write(p []byte) (int, error) {
+	return d.hash.Write(p)
+}
+
+// Digest returns the digest of the bytes written so far, in the WARC-Payload-Digest format
+func (d *Digester) Digest() string {
+	return "sha1:" + base32.StdEncoding.EncodeToString(d.hash.Sum(nil))
+}
@@ -0,0 +1,178 @@
+package crawllog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+)
+
+func newTestItem(t *testing.T, raw string) *models.Item {
+	t.Helper()
+
+	URL, err := models.NewURL(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return models.NewItem(&URL, "")
+}
+
+func TestEntryString(t *testing.T) {
+	start := time.Date(2025, 3, 4, 5, 6, 7, 890*int(time.Millisecond), time.UTC)
+
+	tests := []struct {
+		name     string
+		entry    Entry
+		expected string
+	}{
+		{
+			name: "archived",
+			entry: Entry{
+				Timestamp:  start.Add(250 * time.Millisecond),
+				Status:     200,
+				Size:       1234,
+				URL:        "https://example.com/style.css",
+				HopPath:    "LE",
+				Via:        "https://example.com/",
+				MIME:       "text/css",
+				FetchStart: start,
+				Duration:   250 * time.Millisecond,
+				Digest:     "sha1:3I42H3S6NNFQ2MSVX7XZKYAYSCX5QBYJ",
+				SeedID:     "seed",
+			},
+			expected: "2025-03-04T05:06:08.140Z   200       1234 https://example.com/style.css LE https://example.com/ text/css - 20250304050607890+250 sha1:3I42H3S6NNFQ2MSVX7XZKYAYSCX5QBYJ seed retries:0",
+		},
+		{
+			name: "discarded after retries",
+			entry: Entry{
+				Timestamp:     start,
+				Status:        403,
+				Size:          -1,
+				URL:           "https://example.com/",
+				Via:           "curator notes",
+				FetchStart:    start,
+				SeedID:        "seed",
+				DiscardReason: "cf-challenge",
+				Retries:       2,
+			},
+			expected: "2025-03-04T05:06:07.890Z   403          - https://example.com/ - curator%20notes - - 20250304050607890+0 - seed retries:2,discard:cf-challenge",
+		},
+		{
+			name: "no response",
+			entry: Entry{
+				Timestamp: start,
+				Status:    StatusDNSFailed,
+				Size:      -1,
+				URL:       "https://example.invalid/",
+			},
+			expected: "2025-03-04T05:06:07.890Z    -1          - https://example.invalid/ - - - - - - - retries:0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.entry.String(); got != tt.expected {
+				t.Errorf("String() =\n%q\nwant\n%q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewEntry(t *testing.T) {
+	seed := newTestItem(t, "https://example.com/")
+	seed.GetURL().SetHops(2)
+
+	redirection := newTestItem(t, "https://www.example.com/")
+	if err := seed.AddChild(redirection, models.ItemGotRedirected); err != nil {
+		t.Fatal(err)
+	}
+
+	asset := newTestItem(t, "https://www.example.com/style.css")
+	if err := redirection.AddChild(asset, models.ItemGotChildren); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		item            *models.Item
+		expectedHopPath string
+		expectedVia     string
+	}{
+		{"seed", seed, "LL", ""},
+		{"redirection", redirection, "LLR", "https://example.com/"},
+		{"asset", asset, "LLRE", "https://www.example.com/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := NewEntry(tt.item)
+			if entry.HopPath != tt.expectedHopPath {
+				t.Errorf("HopPath = %q, want %q", entry.HopPath, tt.expectedHopPath)
+			}
+			if entry.Via != tt.expectedVia {
+				t.Errorf("Via = %q, want %q", entry.Via, tt.expectedVia)
+			}
+			if entry.SeedID != seed.GetID() {
+				t.Errorf("SeedID = %q, want %q", entry.SeedID, seed.GetID())
+			}
+			if entry.URL != tt.item.GetURL().String() {
+				t.Errorf("URL = %q, want %q", entry.URL, tt.item.GetURL().String())
+			}
+		})
+	}
+}
+
+func TestStatusFromError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"DNS", &net.DNSError{Err: "no such host", Name: "example.invalid"}, StatusDNSFailed},
+		{"dial", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, StatusConnectFailed},
+		{"timeout", fmt.Errorf("request: %w", context.DeadlineExceeded), StatusTimeout},
+		{"broken", fmt.Errorf("read: %w", io.ErrUnexpectedEOF), StatusConnectBroken},
+		{"other", errors.New("something else"), StatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StatusFromError(tt.err); got != tt.expected {
+				t.Errorf("StatusFromError() = %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMIME(t *testing.T) {
+	tests := []struct {
+		contentType string
+		expected    string
+	}{
+		{"text/html; charset=UTF-8", "text/html"},
+		{" Application/JSON ", "application/json"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := MIME(tt.contentType); got != tt.expected {
+			t.Errorf("MIME(%q) = %q, want %q", tt.contentType, got, tt.expected)
+		}
+	}
+}
+
+func TestDigester(t *testing.T) {
+	digester := NewDigester()
+	digester.Write([]byte("hello "))
+	digester.Write([]byte("world"))
+
+	// sha1("hello world") = 2aae6c35c94fcfb415dbe95f408b9ce91ee846ed
+	if got, expected := digester.Digest(), "sha1:FKXGYNOJJ7H3IFO35FPUBC445EPOQRXN"; got != expected {
+		t.Errorf("Digest() = %s, want %s", got, expected)
+	}
+}
@@ -0,0 +1,8 @@
+package crawllog
+
+import "errors"
+
+var (
+	// ErrCrawlLogAlreadyInitialized is the error returned when the crawl log is already initialized
+	ErrCrawlLogAlreadyInitialized = errors.New("crawl log already initialized")
+)
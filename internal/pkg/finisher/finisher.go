@@ -12,6 +12,7 @@ import (
 	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/reactor"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/tracing"
 	"github.com/internetarchive/Zeno/v2/pkg/models"
 )
 
@@ -114,6 +115,9 @@ func (f *finisher) handleSeed(seed *models.Item, workerID string, logger *log.Fi
 
 	logger.Debug("received seed", "seed", seed.GetShortID())
 
+	// The finisher stage span is ended when the seed goes back to the reactor or is finished
+	tracing.StartStage(seed, tracing.StageFinisher)
+
 	if err := seed.CheckConsistency(); err != nil {
 		return fmt.Errorf("seed consistency check failed with err: for %s: %s", err.Error(), seed.GetShortID())
 	}
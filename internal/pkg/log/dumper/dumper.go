@@ -13,7 +13,18 @@ import (
 )
 
 // Dump writes a spew dump of the items and an ASCII pretty print of the items to a dump file then returns the path to the dump file.
+// Errors are logged, see WriteDump to get them.
 func Dump(items ...*models.Item) string {
+	dumpFilePath, err := WriteDump(items...)
+	if err != nil {
+		log.Error("failed to write dump file", "err", err.Error())
+	}
+
+	return dumpFilePath
+}
+
+// WriteDump writes the dump file like Dump and returns its path along with the error that prevented writing it, if any.
+func WriteDump(items ...*models.Item) (string, error) {
 	// Creates a dump file to be written to by the dumper
 	var dumpFilePath string
 	if dumpFilePath = config.Get().LogFileOutputDir; dumpFilePath == "" {
@@ -23,21 +34,23 @@ func Dump(items ...*models.Item) string {
 	}
 	dumpFile, err := os.Create(dumpFilePath)
 	if err != nil {
-		log.Error("failed to create dump file: %v", err)
+		return dumpFilePath, err
 	}
-	defer dumpFile.Close()
 
 	if len(items) == 0 {
 		items = reactor.GetStateTableItems()
 	}
 
 	for i := range items {
-		fmt.Fprintf(dumpFile, "Item: %s\n", items[i].GetID())
+		if _, err := fmt.Fprintf(dumpFile, "Item: %s\n", items[i].GetID()); err != nil {
+			dumpFile.Close()
+			return dumpFilePath, err
+		}
 		spew.Fdump(dumpFile, items[i])
 		fmt.Fprintf(dumpFile, "\n%s\n_______________________________", items[i].DrawTreeWithStatus())
 	}
 
-	return dumpFilePath
+	return dumpFilePath, dumpFile.Close()
 }
 
 // PanicWithDump writes a spew dump of the items and an ASCII pretty print of the items to a dump file then panics with a message.
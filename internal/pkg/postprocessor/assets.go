@@ -58,6 +58,12 @@ func Extractors(item *models.Item) (assets, outlinks []*models.URL, err error) {
 			logger.Error("unable to extract assets", "err", err.Error())
 			return assets, outlinks, err
 		}
+	case extractor.IsMPD(item.GetURL()):
+		assets, err = extractor.MPD(item.GetURL())
+		if err != nil {
+			logger.Error("unable to extract assets", "err", err.Error())
+			return assets, outlinks, err
+		}
 	case extractor.IsJSON(item.GetURL()):
 		assets, outlinks, err = extractor.JSON(item.GetURL())
 		if err != nil {
@@ -51,6 +51,12 @@ func Match(rawURL string) bool {
 	return globalMatcher.Match(rawURL)
 }
 
+// Replace atomically swaps the content of the global matcher with the given matcher,
+// which must not be used afterwards. A nil matcher disables domainscrawl.
+func Replace(m *matchEngine) {
+	globalMatcher.Replace(m)
+}
+
 // NewMatcher creates a new matchEngine instance for testing or isolated usage
 func NewMatcher() *matchEngine {
 	return &matchEngine{
@@ -72,6 +78,25 @@ func (m *matchEngine) Reset() {
 	m.urls = make([]url.URL, 0)
 }
 
+// Replace swaps the content of the matcher with the content of next
+func (m *matchEngine) Replace(next *matchEngine) {
+	if next == nil {
+		m.Reset()
+		return
+	}
+
+	next.RLock()
+	defer next.RUnlock()
+
+	m.Lock()
+	defer m.Unlock()
+
+	m.enabled = next.enabled
+	m.regexes = next.regexes
+	m.domains = next.domains
+	m.urls = next.urls
+}
+
 // Enabled returns true if the domainscrawl matcher is enabled
 func (m *matchEngine) Enabled() bool {
 	m.RLock()
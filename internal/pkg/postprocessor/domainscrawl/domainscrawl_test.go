@@ -74,6 +74,32 @@ func TestEnabled(t *testing.T) {
 	}
 }
 
+func TestReplace(t *testing.T) {
+	matcher := NewMatcher()
+	if err := matcher.AddElements([]string{"example.com"}, nil); err != nil {
+		t.Fatalf("Failed to add elements: %v", err)
+	}
+
+	next := NewMatcher()
+	if err := next.AddElements([]string{"example.org", `^https://archive\.org/details/`}, nil); err != nil {
+		t.Fatalf("Failed to add elements: %v", err)
+	}
+
+	matcher.Replace(next)
+
+	if matcher.Match("https://example.com/") {
+		t.Error("Match() = true for a pattern that was replaced")
+	}
+	if !matcher.Match("https://www.example.org/") || !matcher.Match("https://archive.org/details/foo") {
+		t.Error("Match() = false for a pattern of the new matcher")
+	}
+
+	matcher.Replace(nil)
+	if matcher.Enabled() {
+		t.Error("Enabled() = true after replacing with nil, expected false")
+	}
+}
+
 // Test AddElements function
 func TestAddElements(t *testing.T) {
 	tests := []struct {
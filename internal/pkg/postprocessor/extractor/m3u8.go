@@ -1,19 +1,33 @@
 package extractor
 
 import (
+	"strings"
+
 	"github.com/grafov/m3u8"
 	"github.com/internetarchive/Zeno/v2/pkg/models"
 )
 
 func IsM3U8(URL *models.URL) bool {
-	return URL.GetMIMEType() != nil &&
-		URL.GetMIMEType().Is("application/vnd.apple.mpegurl") || URL.GetMIMEType().Is("application/x-mpegURL")
+	if URL.GetMIMEType() == nil {
+		return false
+	}
+
+	if URL.GetMIMEType().Is("application/vnd.apple.mpegurl") || URL.GetMIMEType().Is("application/x-mpegURL") {
+		return true
+	}
+
+	// Playlists are often served as text/plain
+	return URL.GetMIMEType().Is("text/plain") && URL.GetParsed() != nil && strings.HasSuffix(strings.ToLower(URL.GetParsed().Path), ".m3u8")
 }
 
+// M3U8 extracts the URLs of an HLS playlist: the variant streams, alternative renditions and
+// I-frame playlists of a master playlist, the segments, encryption keys (EXT-X-KEY) and
+// initialization sections (EXT-X-MAP) of a media playlist. The byte-range segments of a same
+// file are extracted once, as the whole file is archived. URLs are resolved against the playlist URL.
 func M3U8(URL *models.URL) (assets []*models.URL, err error) {
 	defer URL.RewindBody()
 
-	var rawAssets ([]string)
+	var rawAssets []string
 
 	playlist, listType, err := m3u8.DecodeFrom(URL.GetBody(), true)
 	if err != nil {
@@ -24,22 +38,35 @@ func M3U8(URL *models.URL) (assets []*models.URL, err error) {
 	case m3u8.MEDIA:
 		mediapl := playlist.(*m3u8.MediaPlaylist)
 
+		if mediapl.Key != nil {
+			rawAssets = append(rawAssets, mediapl.Key.URI)
+		}
+		if mediapl.Map != nil {
+			rawAssets = append(rawAssets, mediapl.Map.URI)
+		}
+
 		for _, segment := range mediapl.Segments {
-			if segment != nil && segment.URI != "" {
-				rawAssets = append(rawAssets, segment.URI)
+			if segment == nil {
+				continue
+			}
+
+			if segment.Key != nil {
+				rawAssets = append(rawAssets, segment.Key.URI)
 			}
+			if segment.Map != nil {
+				rawAssets = append(rawAssets, segment.Map.URI)
+			}
+			rawAssets = append(rawAssets, segment.URI)
 		}
 	case m3u8.MASTER:
 		masterpl := playlist.(*m3u8.MasterPlaylist)
 
 		for _, variant := range masterpl.Variants {
 			if variant != nil {
-				if variant.URI != "" {
-					rawAssets = append(rawAssets, variant.URI)
-				}
+				rawAssets = append(rawAssets, variant.URI)
 
 				for _, alt := range variant.Alternatives {
-					if alt != nil && alt.URI != "" {
+					if alt != nil {
 						rawAssets = append(rawAssets, alt.URI)
 					}
 				}
@@ -47,7 +74,24 @@ func M3U8(URL *models.URL) (assets []*models.URL, err error) {
 		}
 	}
 
+	seen := make(map[string]struct{}, len(rawAssets))
 	for _, rawAsset := range rawAssets {
+		// Keys can be inline (data:) or handled by a DRM system (skd:), they are filtered out later on
+		if rawAsset == "" {
+			continue
+		}
+
+		if URL.GetParsed() != nil {
+			if resolved, err := resolveURL(rawAsset, URL); err == nil {
+				rawAsset = resolved
+			}
+		}
+
+		if _, ok := seen[rawAsset]; ok {
+			continue
+		}
+		seen[rawAsset] = struct{}{}
+
 		assets = append(assets, &models.URL{
 			Raw: rawAsset,
 		})
@@ -0,0 +1,128 @@
+package extractor
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+	"github.com/internetarchive/gowarc/pkg/spooledtempfile"
+)
+
+func newManifestURL(t *testing.T, raw, body string) *models.URL {
+	t.Helper()
+
+	URL, err := models.NewURL(raw)
+	if err != nil {
+		t.Fatalf("unable to create URL: %v", err)
+	}
+
+	spooledTempFile := spooledtempfile.NewSpooledTempFile("test", os.TempDir(), 2048, false, -1)
+	spooledTempFile.Write([]byte(body))
+	URL.SetBody(spooledTempFile)
+	URL.SetMIMEType(mimetype.Detect([]byte(body)))
+
+	return &URL
+}
+
+func TestM3U8(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected []string
+	}{
+		{
+			name: "Master playlist with alternatives and I-frames",
+			body: `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,URI="audio/en.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=1280000,AUDIO="aac"
+low/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=2560000,AUDIO="aac"
+https://cdn.example.com/high/index.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=86000,URI="low/iframe.m3u8"
+`,
+			expected: []string{
+				"https://example.com/video/low/index.m3u8",
+				"https://example.com/video/audio/en.m3u8",
+				"https://cdn.example.com/high/index.m3u8",
+				"https://example.com/video/low/iframe.m3u8",
+			},
+		},
+		{
+			name: "Media playlist with key rotation and init section",
+			body: `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MAP:URI="init.mp4"
+#EXT-X-KEY:METHOD=AES-128,URI="https://keys.example.com/key1"
+#EXTINF:6.0,
+seg1.m4s
+#EXT-X-KEY:METHOD=AES-128,URI="/keys/key2"
+#EXTINF:6.0,
+seg2.m4s
+#EXT-X-ENDLIST
+`,
+			expected: []string{
+				"https://keys.example.com/key1",
+				"https://example.com/video/init.mp4",
+				"https://example.com/video/seg1.m4s",
+				"https://example.com/keys/key2",
+				"https://example.com/video/seg2.m4s",
+			},
+		},
+		{
+			name: "Media playlist with byte-range segments",
+			body: `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-TARGETDURATION:10
+#EXTINF:10.0,
+#EXT-X-BYTERANGE:75232@0
+main.ts
+#EXTINF:10.0,
+#EXT-X-BYTERANGE:82112@75232
+main.ts
+#EXT-X-ENDLIST
+`,
+			expected: []string{
+				"https://example.com/video/main.ts",
+			},
+		},
+		{
+			name: "Media playlist with encryption disabled",
+			body: `#EXTM3U
+#EXT-X-TARGETDURATION:10
+#EXT-X-KEY:METHOD=NONE
+#EXTINF:10.0,
+seg1.ts
+#EXT-X-ENDLIST
+`,
+			expected: []string{
+				"https://example.com/video/seg1.ts",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			URL := newManifestURL(t, "https://example.com/video/master.m3u8", tt.body)
+
+			assets, err := M3U8(URL)
+			if err != nil {
+				t.Fatalf("M3U8() error = %v", err)
+			}
+
+			var got []string
+			for _, asset := range assets {
+				got = append(got, asset.Raw)
+			}
+
+			slices.Sort(got)
+			expected := slices.Clone(tt.expected)
+			slices.Sort(expected)
+			if !slices.Equal(got, expected) {
+				t.Errorf("M3U8() = %v, want %v", got, expected)
+			}
+		})
+	}
+}
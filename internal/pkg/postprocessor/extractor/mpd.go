@@ -0,0 +1,395 @@
+package extractor
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+)
+
+// mpdMaxSegments is the maximum number of segments extracted per representation,
+// it bounds the expansion of templates of live or very long presentations
+const mpdMaxSegments = 100000
+
+// mpdTemplateIdentifier matches the identifiers of a SegmentTemplate, e.g. $Number%05d$
+var mpdTemplateIdentifier = regexp.MustCompile(`\$(RepresentationID|Number|Time|Bandwidth|SubNumber)?(%0(\d+)d)?\$`)
+
+type mpdManifest struct {
+	Type                      string      `xml:"type,attr"`
+	MediaPresentationDuration string      `xml:"mediaPresentationDuration,attr"`
+	BaseURLs                  []string    `xml:"BaseURL"`
+	Periods                   []mpdPeriod `xml:"Period"`
+}
+
+type mpdPeriod struct {
+	Duration        string              `xml:"duration,attr"`
+	BaseURLs        []string            `xml:"BaseURL"`
+	SegmentBase     *mpdSegmentBase     `xml:"SegmentBase"`
+	SegmentList     *mpdSegmentList     `xml:"SegmentList"`
+	SegmentTemplate *mpdSegmentTemplate `xml:"SegmentTemplate"`
+	AdaptationSets  []mpdAdaptationSet  `xml:"AdaptationSet"`
+}
+
+type mpdAdaptationSet struct {
+	BaseURLs        []string            `xml:"BaseURL"`
+	SegmentBase     *mpdSegmentBase     `xml:"SegmentBase"`
+	SegmentList     *mpdSegmentList     `xml:"SegmentList"`
+	SegmentTemplate *mpdSegmentTemplate `xml:"SegmentTemplate"`
+	Representations []mpdRepresentation `xml:"Representation"`
+}
+
+type mpdRepresentation struct {
+	ID              string              `xml:"id,attr"`
+	Bandwidth       string              `xml:"bandwidth,attr"`
+	BaseURLs        []string            `xml:"BaseURL"`
+	SegmentBase     *mpdSegmentBase     `xml:"SegmentBase"`
+	SegmentList     *mpdSegmentList     `xml:"SegmentList"`
+	SegmentTemplate *mpdSegmentTemplate `xml:"SegmentTemplate"`
+}
+
+type mpdURLType struct {
+	SourceURL string `xml:"sourceURL,attr"`
+}
+
+type mpdSegmentBase struct {
+	Initialization *mpdURLType `xml:"Initialization"`
+}
+
+type mpdSegmentList struct {
+	Initialization *mpdURLType `xml:"Initialization"`
+	SegmentURLs    []struct {
+		Media string `xml:"media,attr"`
+	} `xml:"SegmentURL"`
+}
+
+type mpdSegmentTemplate struct {
+	Media           string              `xml:"media,attr"`
+	Initialization  string              `xml:"initialization,attr"`
+	StartNumber     *int64              `xml:"startNumber,attr"`
+	Timescale       *int64              `xml:"timescale,attr"`
+	Duration        *int64              `xml:"duration,attr"`
+	SegmentTimeline *mpdSegmentTimeline `xml:"SegmentTimeline"`
+}
+
+type mpdSegmentTimeline struct {
+	S []struct {
+		T *int64 `xml:"t,attr"`
+		D int64  `xml:"d,attr"`
+		R int64  `xml:"r,attr"`
+	} `xml:"S"`
+}
+
+// IsMPD returns true if the URL is a MPEG-DASH manifest
+func IsMPD(URL *models.URL) bool {
+	if URL.GetMIMEType() == nil {
+		return false
+	}
+
+	if URL.GetMIMEType().Is("application/dash+xml") {
+		return true
+	}
+
+	// Manifests served without their Content-Type are detected as XML
+	return (URL.GetMIMEType().Is("text/xml") || URL.GetMIMEType().Is("text/plain")) &&
+		URL.GetParsed() != nil && strings.HasSuffix(strings.ToLower(URL.GetParsed().Path), ".mpd")
+}
+
+// MPD extracts the URLs of the initialization and media segments of a MPEG-DASH manifest,
+// described by SegmentTemplate, SegmentList, SegmentBase or a single BaseURL per representation.
+// The BaseURL elements are resolved level by level against the manifest URL, only the first
+// of each level is used as the others are alternative locations of the same content.
+func MPD(URL *models.URL) (assets []*models.URL, err error) {
+	defer URL.RewindBody()
+
+	var manifest mpdManifest
+
+	decoder := xml.NewDecoder(URL.GetBody())
+	decoder.Strict = false
+	if err := decoder.Decode(&manifest); err != nil {
+		return assets, err
+	}
+
+	if URL.GetParsed() == nil {
+		return assets, fmt.Errorf("unable to resolve MPD URLs: %s isn't parsed", URL.Raw)
+	}
+
+	var (
+		seen   = make(map[string]struct{})
+		static = manifest.Type != "dynamic"
+	)
+
+	addAsset := func(base *url.URL, raw string) {
+		if raw == "" {
+			return
+		}
+
+		ref, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			return
+		}
+
+		resolved := base.ResolveReference(ref).String()
+		if _, ok := seen[resolved]; ok {
+			return
+		}
+		seen[resolved] = struct{}{}
+
+		assets = append(assets, &models.URL{Raw: resolved})
+	}
+
+	mpdBase := mpdResolveBase(URL.GetParsed(), manifest.BaseURLs)
+	presentationDuration := parseISO8601Duration(manifest.MediaPresentationDuration)
+
+	for _, period := range manifest.Periods {
+		periodBase := mpdResolveBase(mpdBase, period.BaseURLs)
+
+		periodDuration := parseISO8601Duration(period.Duration)
+		if periodDuration == 0 && len(manifest.Periods) == 1 {
+			periodDuration = presentationDuration
+		}
+
+		for _, adaptationSet := range period.AdaptationSets {
+			adaptationSetBase := mpdResolveBase(periodBase, adaptationSet.BaseURLs)
+
+			for _, representation := range adaptationSet.Representations {
+				representationBase := mpdResolveBase(adaptationSetBase, representation.BaseURLs)
+
+				template := mergeSegmentTemplates(period.SegmentTemplate, adaptationSet.SegmentTemplate, representation.SegmentTemplate)
+				segmentList := firstNonNil(representation.SegmentList, adaptationSet.SegmentList, period.SegmentList)
+				segmentBase := firstNonNil(representation.SegmentBase, adaptationSet.SegmentBase, period.SegmentBase)
+
+				switch {
+				case template != nil:
+					addAsset(representationBase, expandSegmentTemplate(template.Initialization, representation, 0, 0))
+					for _, segment := range templateSegments(template, periodDuration, static) {
+						addAsset(representationBase, expandSegmentTemplate(template.Media, representation, segment.number, segment.time))
+					}
+				case segmentList != nil:
+					if segmentList.Initialization != nil {
+						addAsset(representationBase, segmentList.Initialization.SourceURL)
+					}
+					for _, segmentURL := range segmentList.SegmentURLs {
+						// A SegmentURL without media is a byte range of the BaseURL
+						if segmentURL.Media == "" {
+							addAsset(representationBase, representationBase.String())
+						} else {
+							addAsset(representationBase, segmentURL.Media)
+						}
+					}
+				default:
+					// The representation is a single file, with its initialization as a byte range of it or a separate file
+					if segmentBase != nil && segmentBase.Initialization != nil {
+						addAsset(representationBase, segmentBase.Initialization.SourceURL)
+					}
+					if len(representation.BaseURLs) > 0 || len(adaptationSet.BaseURLs) > 0 {
+						addAsset(representationBase, representationBase.String())
+					}
+				}
+			}
+		}
+	}
+
+	return assets, nil
+}
+
+// mpdResolveBase resolves the first BaseURL of a level against the base of the parent level
+func mpdResolveBase(parent *url.URL, baseURLs []string) *url.URL {
+	if len(baseURLs) == 0 {
+		return parent
+	}
+
+	ref, err := url.Parse(strings.TrimSpace(baseURLs[0]))
+	if err != nil {
+		return parent
+	}
+
+	return parent.ResolveReference(ref)
+}
+
+// mergeSegmentTemplates returns the SegmentTemplate of a representation, with the
+// attributes it doesn't set inherited from the adaptation set and the period
+func mergeSegmentTemplates(templates ...*mpdSegmentTemplate) *mpdSegmentTemplate {
+	var merged *mpdSegmentTemplate
+
+	for _, template := range templates {
+		if template == nil {
+			continue
+		}
+
+		if merged == nil {
+			merged = &mpdSegmentTemplate{}
+		}
+
+		if template.Media != "" {
+			merged.Media = template.Media
+		}
+		if template.Initialization != "" {
+			merged.Initialization = template.Initialization
+		}
+		if template.StartNumber != nil {
+			merged.StartNumber = template.StartNumber
+		}
+		if template.Timescale != nil {
+			merged.Timescale = template.Timescale
+		}
+		if template.Duration != nil {
+			merged.Duration = template.Duration
+		}
+		if template.SegmentTimeline != nil {
+			merged.SegmentTimeline = template.SegmentTimeline
+		}
+	}
+
+	return merged
+}
+
+func firstNonNil[T any](values ...*T) *T {
+	for _, value := range values {
+		if value != nil {
+			return value
+		}
+	}
+	return nil
+}
+
+type mpdSegment struct {
+	number int64
+	time   int64
+}
+
+// templateSegments returns the number and time of the segments of a SegmentTemplate, from its
+// SegmentTimeline or from the segment duration over the period. The segments of a dynamic
+// (live) manifest without timeline aren't known in advance and none is returned.
+func templateSegments(template *mpdSegmentTemplate, periodDuration time.Duration, static bool) (segments []mpdSegment) {
+	if template.Media == "" {
+		return nil
+	}
+
+	number := int64(1)
+	if template.StartNumber != nil {
+		number = *template.StartNumber
+	}
+
+	timescale := int64(1)
+	if template.Timescale != nil && *template.Timescale > 0 {
+		timescale = *template.Timescale
+	}
+
+	if template.SegmentTimeline != nil {
+		var currentTime int64
+		timeline := template.SegmentTimeline.S
+
+		for i, s := range timeline {
+			if s.T != nil {
+				currentTime = *s.T
+			}
+			if s.D <= 0 {
+				continue
+			}
+
+			repeat := s.R
+			// A negative repeat count repeats the segment until the next S or the end of the period
+			if repeat < 0 {
+				switch {
+				case i+1 < len(timeline) && timeline[i+1].T != nil:
+					repeat = (*timeline[i+1].T-currentTime)/s.D - 1
+				case periodDuration > 0:
+					end := int64(periodDuration.Seconds() * float64(timescale))
+					repeat = int64(math.Ceil(float64(end-currentTime)/float64(s.D))) - 1
+				default:
+					repeat = 0
+				}
+			}
+
+			for range repeat + 1 {
+				if len(segments) >= mpdMaxSegments {
+					return segments
+				}
+				segments = append(segments, mpdSegment{number: number, time: currentTime})
+				number++
+				currentTime += s.D
+			}
+		}
+
+		return segments
+	}
+
+	if !static || periodDuration <= 0 || template.Duration == nil || *template.Duration <= 0 {
+		return nil
+	}
+
+	segmentDuration := float64(*template.Duration) / float64(timescale)
+	count := min(int64(math.Ceil(periodDuration.Seconds()/segmentDuration)), mpdMaxSegments)
+	for i := range count {
+		segments = append(segments, mpdSegment{number: number + i, time: i * *template.Duration})
+	}
+
+	return segments
+}
+
+// expandSegmentTemplate replaces the identifiers of a SegmentTemplate media or initialization attribute
+func expandSegmentTemplate(template string, representation mpdRepresentation, number, segmentTime int64) string {
+	return mpdTemplateIdentifier.ReplaceAllStringFunc(template, func(identifier string) string {
+		match := mpdTemplateIdentifier.FindStringSubmatch(identifier)
+
+		var value string
+		switch match[1] {
+		case "":
+			return "$"
+		case "RepresentationID":
+			return representation.ID
+		case "Number":
+			value = strconv.FormatInt(number, 10)
+		case "Time":
+			value = strconv.FormatInt(segmentTime, 10)
+		case "Bandwidth":
+			value = representation.Bandwidth
+		default:
+			return identifier
+		}
+
+		// Zero padded to the width of the format tag
+		if match[3] != "" {
+			width, err := strconv.Atoi(match[3])
+			if err == nil && len(value) < width {
+				value = strings.Repeat("0", width-len(value)) + value
+			}
+		}
+
+		return value
+	})
+}
+
+// iso8601Duration matches the durations of the MPD attributes, e.g. PT1H2M3.5S or P1DT12H
+var iso8601Duration = regexp.MustCompile(`^P(?:(\d+(?:\.\d+)?)Y)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// parseISO8601Duration parses an ISO 8601 duration, it returns 0 if it is invalid.
+// Years and months are counted as 365 and 30 days.
+func parseISO8601Duration(value string) time.Duration {
+	match := iso8601Duration.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return 0
+	}
+
+	units := []time.Duration{365 * 24 * time.Hour, 30 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+
+	var duration time.Duration
+	for i, unit := range units {
+		if match[i+1] == "" {
+			continue
+		}
+		amount, err := strconv.ParseFloat(match[i+1], 64)
+		if err != nil {
+			return 0
+		}
+		duration += time.Duration(amount * float64(unit))
+	}
+
+	return duration
+}
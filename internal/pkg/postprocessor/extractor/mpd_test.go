@@ -0,0 +1,169 @@
+package extractor
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMPD(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected []string
+	}{
+		{
+			name: "SegmentTemplate with number and duration",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" type="static" mediaPresentationDuration="PT8S">
+  <Period>
+    <AdaptationSet mimeType="video/mp4">
+      <SegmentTemplate initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/seg-$Number%03d$.m4s" startNumber="1" timescale="1000" duration="4000"/>
+      <Representation id="720p" bandwidth="3000000"/>
+    </AdaptationSet>
+  </Period>
+</MPD>`,
+			expected: []string{
+				"https://example.com/dash/720p/init.mp4",
+				"https://example.com/dash/720p/seg-001.m4s",
+				"https://example.com/dash/720p/seg-002.m4s",
+			},
+		},
+		{
+			name: "SegmentTemplate with time timeline and repeat",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" type="static" mediaPresentationDuration="PT10S">
+  <Period>
+    <AdaptationSet mimeType="audio/mp4">
+      <Representation id="audio" bandwidth="128000">
+        <SegmentTemplate initialization="audio-init.mp4" media="audio-$Time$.m4s" timescale="10">
+          <SegmentTimeline>
+            <S t="0" d="20" r="2"/>
+            <S d="40"/>
+          </SegmentTimeline>
+        </SegmentTemplate>
+      </Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>`,
+			expected: []string{
+				"https://example.com/dash/audio-init.mp4",
+				"https://example.com/dash/audio-0.m4s",
+				"https://example.com/dash/audio-20.m4s",
+				"https://example.com/dash/audio-40.m4s",
+				"https://example.com/dash/audio-60.m4s",
+			},
+		},
+		{
+			name: "SegmentList with BaseURL chain",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" type="static">
+  <BaseURL>https://cdn.example.com/content/</BaseURL>
+  <Period>
+    <BaseURL>period1/</BaseURL>
+    <AdaptationSet>
+      <Representation id="1" bandwidth="500000">
+        <BaseURL>low/</BaseURL>
+        <SegmentList>
+          <Initialization sourceURL="init.mp4"/>
+          <SegmentURL media="1.m4s"/>
+          <SegmentURL media="2.m4s"/>
+        </SegmentList>
+      </Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>`,
+			expected: []string{
+				"https://cdn.example.com/content/period1/low/init.mp4",
+				"https://cdn.example.com/content/period1/low/1.m4s",
+				"https://cdn.example.com/content/period1/low/2.m4s",
+			},
+		},
+		{
+			name: "Single file representations with SegmentBase",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" type="static">
+  <Period>
+    <AdaptationSet>
+      <Representation id="video" bandwidth="1000000">
+        <BaseURL>video.mp4</BaseURL>
+        <SegmentBase indexRange="800-1200">
+          <Initialization range="0-799"/>
+        </SegmentBase>
+      </Representation>
+      <Representation id="audio" bandwidth="64000">
+        <BaseURL>/media/audio.mp4</BaseURL>
+      </Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>`,
+			expected: []string{
+				"https://example.com/dash/video.mp4",
+				"https://example.com/media/audio.mp4",
+			},
+		},
+		{
+			name: "Dynamic manifest without timeline",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" type="dynamic">
+  <Period>
+    <AdaptationSet>
+      <SegmentTemplate initialization="init-$RepresentationID$.mp4" media="$RepresentationID$-$Number$.m4s" duration="2"/>
+      <Representation id="live" bandwidth="1000000"/>
+    </AdaptationSet>
+  </Period>
+</MPD>`,
+			expected: []string{
+				"https://example.com/dash/init-live.mp4",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			URL := newManifestURL(t, "https://example.com/dash/manifest.mpd", tt.body)
+
+			if !IsMPD(URL) {
+				t.Fatalf("IsMPD() = false, want true (MIME %s)", URL.GetMIMEType())
+			}
+
+			assets, err := MPD(URL)
+			if err != nil {
+				t.Fatalf("MPD() error = %v", err)
+			}
+
+			var got []string
+			for _, asset := range assets {
+				got = append(got, asset.Raw)
+			}
+
+			slices.Sort(got)
+			expected := slices.Clone(tt.expected)
+			slices.Sort(expected)
+			if !slices.Equal(got, expected) {
+				t.Errorf("MPD() = %v, want %v", got, expected)
+			}
+		})
+	}
+}
+
+func TestParseISO8601Duration(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"PT10S", 10 * time.Second},
+		{"PT1H2M3.5S", time.Hour + 2*time.Minute + 3500*time.Millisecond},
+		{"P1DT1S", 24*time.Hour + time.Second},
+		{"", 0},
+		{"invalid", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseISO8601Duration(tt.value); got != tt.expected {
+				t.Errorf("parseISO8601Duration(%q) = %v, want %v", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
@@ -27,8 +27,14 @@ func postprocessItem(item *models.Item) []*models.Item {
 
 	logger.Debug("postprocessing item")
 
-	// Verify if there is any redirection
+	// Verify if there is any redirection, the ones to a login page are quarantined and not followed
 	if item.GetURL().GetResponse() != nil && isStatusCodeRedirect(item.GetURL().GetResponse().StatusCode) {
+		if item.GetQuarantineReason() != "" {
+			logger.Debug("item is a quarantined redirection, not following it", "reason", item.GetQuarantineReason())
+			item.SetStatus(models.ItemCompleted)
+			return outlinks
+		}
+
 		logger.Debug("item is a redirection")
 
 		// Check if the current redirections count doesn't exceed the max allowed
@@ -44,6 +50,7 @@ func postprocessItem(item *models.Item) []*models.Item {
 			Redirects: item.GetURL().GetRedirects() + 1,
 			Hops:      item.GetURL().GetHops(),
 		}
+		newURL.Method, newURL.Headers, newURL.Payload = redirectRequest(item.GetURL(), item.GetURL().GetResponse().StatusCode, newURL.Raw)
 
 		newChild := models.NewItem(newURL, "")
 		err := item.AddChild(newChild, models.ItemGotRedirected)
@@ -120,7 +127,8 @@ func postprocessItem(item *models.Item) []*models.Item {
 			}
 		}
 
-		// Extract outlinks from the page
+		// Extract outlinks from the page. The assets of quarantined pages are still extracted above on
+		// purpose, for their capture to replay, only their outlinks are skipped by shouldExtractOutlinks
 		if shouldExtractOutlinks(item) {
 			newOutlinks, err := extractOutlinks(item)
 			if err != nil {
@@ -141,12 +149,13 @@ func postprocessItem(item *models.Item) []*models.Item {
 					if domainscrawl.Enabled() && domainscrawl.Match(newOutlinks[i].Raw) {
 						logger.Debug("setting hop count to 0 (domains crawl)", "url", newOutlinks[i].Raw)
 						newOutlinks[i].SetHops(0)
-					} else if domainscrawl.Enabled() && !domainscrawl.Match(newOutlinks[i].Raw) && item.GetURL().GetHops() >= config.Get().MaxHops {
+					} else if domainscrawl.Enabled() && !domainscrawl.Match(newOutlinks[i].Raw) && item.GetURL().GetHops() >= item.GetSeedScope().GetMaxHops(config.Get().MaxHops) {
 						logger.Debug("skipping outlink due to hop count", "url", newOutlinks[i].Raw)
 						continue
 					}
 
 					newOutlinkItem := models.NewItem(newOutlinks[i], item.GetURL().String())
+					newOutlinkItem.SetSeedScope(item.GetSeedScope())
 					outlinks = append(outlinks, newOutlinkItem)
 				}
 
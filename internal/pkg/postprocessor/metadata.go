@@ -0,0 +1,99 @@
+package postprocessor
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+)
+
+// writeMetadataRecord writes a WARC metadata record describing how the item was
+// reached and what was found in it, so that the crawl graph can be rebuilt from the WARCs alone
+func writeMetadataRecord(item *models.Item, outlinks []*models.Item) {
+	// Quarantined captures, the ones disallowed by robots.txt and the ones made through a proxy
+	// of the pool are always tagged, for them to be told apart in the WARCs
+	if !config.Get().WARCMetadataRecords && item.GetQuarantineReason() == "" && !item.IsRobotsDisallowed() && item.GetProxy() == "" {
+		return
+	}
+
+	err := archiver.WriteMetadataRecord(item.GetURL().String(), item.GetProxy(), item.GetRecordID(), buildMetadataFields(item, outlinks))
+	if errors.Is(err, archiver.ErrArchiverNotRunning) {
+		logger.Debug("unable to write metadata record, archiver stopped", "item_id", item.GetShortID())
+	} else if err != nil {
+		logger.Error("unable to write metadata record", "item_id", item.GetShortID(), "err", err.Error())
+	}
+}
+
+// buildMetadataFields returns the application/warc-fields payload of the metadata record,
+// outlinks use the Heritrix notation: L for links, E for embeds and R for redirections.
+func buildMetadataFields(item *models.Item, outlinks []*models.Item) string {
+	var fields strings.Builder
+
+	writeField := func(name, value string) {
+		fields.WriteString(name)
+		fields.WriteString(": ")
+		fields.WriteString(value)
+		fields.WriteString("\r\n")
+	}
+
+	if item.IsSeed() {
+		if item.GetSeedVia() != "" {
+			writeField("via", item.GetSeedVia())
+		}
+	} else {
+		writeField("via", item.GetParent().GetURL().String())
+	}
+
+	if hopPath := item.GetHopPath(); hopPath != "" {
+		writeField("hopsFromSeed", hopPath)
+	}
+
+	if reason := item.GetDiscardReason(); reason != "" {
+		writeField("discardReason", reason)
+	}
+
+	if reason := item.GetQuarantineReason(); reason != "" {
+		writeField("quarantine", reason)
+	}
+
+	if item.IsRobotsDisallowed() {
+		writeField("robots", "disallowed")
+	}
+
+	if proxy := item.GetProxy(); proxy != "" {
+		writeField("proxy", proxy)
+	}
+
+	hopType := "E"
+	if item.GetStatus() == models.ItemGotRedirected {
+		hopType = "R"
+	}
+
+	for _, child := range item.GetChildren() {
+		writeField("outlink", resolveURL(item, child.GetURL())+" "+hopType)
+	}
+
+	for _, outlink := range outlinks {
+		writeField("outlink", resolveURL(item, outlink.GetURL())+" L")
+	}
+
+	return fields.String()
+}
+
+// resolveURL returns the absolute form of a URL found in the item, redirections
+// and some extracted URLs are only resolved later on by the preprocessor.
+func resolveURL(item *models.Item, URL *models.URL) string {
+	if URL.GetParsed() != nil {
+		return URL.String()
+	}
+
+	if base := item.GetURL().GetParsed(); base != nil {
+		if resolved, err := base.Parse(URL.Raw); err == nil {
+			return resolved.String()
+		}
+	}
+
+	return URL.Raw
+}
@@ -0,0 +1,91 @@
+package postprocessor
+
+import (
+	"testing"
+
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+)
+
+func newParsedItem(t *testing.T, raw, via string, hops int) *models.Item {
+	t.Helper()
+
+	URL, err := models.NewURL(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	URL.SetHops(hops)
+
+	return models.NewItem(&URL, via)
+}
+
+func TestBuildMetadataFields(t *testing.T) {
+	seed := newParsedItem(t, "https://example.com/page", "https://example.com/", 1)
+
+	// Assets are added by the postprocessor, not parsed yet
+	for _, raw := range []string{"https://example.com/style.css", "/script.js"} {
+		if err := seed.AddChild(models.NewItem(&models.URL{Raw: raw}, ""), models.ItemGotChildren); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outlinks := []*models.Item{newParsedItem(t, "https://example.org/", seed.GetURL().String(), 2)}
+
+	expected := "via: https://example.com/\r\n" +
+		"hopsFromSeed: L\r\n" +
+		"outlink: https://example.com/style.css E\r\n" +
+		"outlink: https://example.com/script.js E\r\n" +
+		"outlink: https://example.org/ L\r\n"
+
+	if got := buildMetadataFields(seed, outlinks); got != expected {
+		t.Errorf("buildMetadataFields() = %q, want %q", got, expected)
+	}
+}
+
+func TestBuildMetadataFieldsRedirection(t *testing.T) {
+	seed := newParsedItem(t, "http://example.com/", "", 0)
+	if err := seed.AddChild(models.NewItem(&models.URL{Raw: "https://example.com/"}, ""), models.ItemGotRedirected); err != nil {
+		t.Fatal(err)
+	}
+
+	redirection := seed.GetChildren()[0]
+	if err := redirection.GetURL().Parse(); err != nil {
+		t.Fatal(err)
+	}
+	redirection.SetDiscardReason("cloudflare challenge")
+
+	if got, want := buildMetadataFields(seed, nil), "outlink: https://example.com/ R\r\n"; got != want {
+		t.Errorf("buildMetadataFields(seed) = %q, want %q", got, want)
+	}
+
+	want := "via: http://example.com/\r\nhopsFromSeed: R\r\ndiscardReason: cloudflare challenge\r\n"
+	if got := buildMetadataFields(redirection, nil); got != want {
+		t.Errorf("buildMetadataFields(redirection) = %q, want %q", got, want)
+	}
+}
+
+func TestBuildMetadataFieldsQuarantine(t *testing.T) {
+	seed := newParsedItem(t, "http://example.com/missing", "", 0)
+	seed.SetQuarantineReason("soft-404")
+
+	if got, want := buildMetadataFields(seed, nil), "quarantine: soft-404\r\n"; got != want {
+		t.Errorf("buildMetadataFields() = %q, want %q", got, want)
+	}
+}
+
+func TestBuildMetadataFieldsProxy(t *testing.T) {
+	seed := newParsedItem(t, "http://example.com/", "", 0)
+	seed.SetProxy("eu")
+
+	if got, want := buildMetadataFields(seed, nil), "proxy: eu\r\n"; got != want {
+		t.Errorf("buildMetadataFields() = %q, want %q", got, want)
+	}
+}
+
+func TestBuildMetadataFieldsRobotsDisallowed(t *testing.T) {
+	seed := newParsedItem(t, "http://example.com/private", "", 0)
+	seed.SetRobotsDisallowed(true)
+
+	if got, want := buildMetadataFields(seed, nil), "robots: disallowed\r\n"; got != want {
+		t.Errorf("buildMetadataFields() = %q, want %q", got, want)
+	}
+}
@@ -149,13 +149,18 @@ func filterMaxOutlinks(outlinks []*models.URL) []*models.URL {
 }
 
 func shouldExtractOutlinks(item *models.Item) bool {
+	// Quarantined pages (soft-404, login walls) would lead the crawl to the same useless pages
+	if item.GetQuarantineReason() != "" {
+		return false
+	}
+
 	// Bypass the hop count if we are domain crawling to ensure we don't miss an outlink from a domain we are interested in
 	if domainscrawl.Enabled() && item.GetURL().GetBody() != nil {
 		return true
 	}
 
 	// Match pure hops count
-	if item.GetURL().GetHops() < config.Get().MaxHops && item.GetURL().GetBody() != nil {
+	if item.GetURL().GetHops() < item.GetSeedScope().GetMaxHops(config.Get().MaxHops) && item.GetURL().GetBody() != nil {
 		return true
 	}
 
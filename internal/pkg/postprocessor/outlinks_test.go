@@ -2,6 +2,7 @@ package postprocessor
 
 import (
 	_ "embed"
+	"net/http"
 	"os"
 	"testing"
 
@@ -26,6 +27,88 @@ func TestFilterURLsByProtocol(t *testing.T) {
 	}
 }
 
+func TestRedirectRequest(t *testing.T) {
+	post := &models.URL{
+		Raw:     "https://example.com/search",
+		Method:  http.MethodPost,
+		Headers: http.Header{"Content-Type": {"application/json"}, "Authorization": {"Bearer x"}},
+		Payload: []byte(`{"q":"zeno"}`),
+	}
+
+	tests := []struct {
+		statusCode      int
+		expectedMethod  string
+		expectedPayload string
+		expectedContent bool
+	}{
+		{http.StatusMovedPermanently, http.MethodGet, "", false},
+		{http.StatusFound, http.MethodGet, "", false},
+		{http.StatusSeeOther, http.MethodGet, "", false},
+		{http.StatusTemporaryRedirect, http.MethodPost, `{"q":"zeno"}`, true},
+		{http.StatusPermanentRedirect, http.MethodPost, `{"q":"zeno"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.statusCode), func(t *testing.T) {
+			URL := &models.URL{}
+			URL.Method, URL.Headers, URL.Payload = redirectRequest(post, tt.statusCode, "/results")
+
+			if URL.GetMethod() != tt.expectedMethod {
+				t.Errorf("method = %s, want %s", URL.GetMethod(), tt.expectedMethod)
+			}
+			if string(URL.Payload) != tt.expectedPayload {
+				t.Errorf("payload = %q, want %q", URL.Payload, tt.expectedPayload)
+			}
+			if (URL.Headers.Get("Content-Type") != "") != tt.expectedContent {
+				t.Errorf("unexpected Content-Type header: %v", URL.Headers)
+			}
+			if URL.Headers.Get("Authorization") != "Bearer x" {
+				t.Errorf("expected the other headers to be kept, got %v", URL.Headers)
+			}
+		})
+	}
+}
+
+func TestRedirectRequestCredentials(t *testing.T) {
+	seed := &models.URL{
+		Raw: "https://example.com/private",
+		Headers: http.Header{
+			"Authorization":       {"Bearer x"},
+			"Cookie":              {"session=1"},
+			"Proxy-Authorization": {"Basic y"},
+			"X-Api-Key":           {"key"},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		location        string
+		wantCredentials bool
+	}{
+		{"relative", "/login", true},
+		{"same host", "https://EXAMPLE.com/login", true},
+		{"subdomain", "https://auth.example.com/login", true},
+		{"other host", "https://tracker.example.net/collect", false},
+		{"parent domain", "https://com/", false},
+		{"suffix but not subdomain", "https://notexample.com/", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, headers, _ := redirectRequest(seed, http.StatusFound, tt.location)
+
+			for _, name := range []string{"Authorization", "Cookie", "Proxy-Authorization"} {
+				if (headers.Get(name) != "") != tt.wantCredentials {
+					t.Errorf("%s header kept = %v, want %v", name, headers.Get(name) != "", tt.wantCredentials)
+				}
+			}
+			if headers.Get("X-Api-Key") != "key" {
+				t.Errorf("expected the other headers to be kept, got %v", headers)
+			}
+		})
+	}
+}
+
 func TestFilterMaxOutlinks(t *testing.T) {
 	var outlinks []*models.URL
 	outlinks = append(outlinks, &models.URL{Raw: "http://e1.com"})
@@ -9,7 +9,10 @@ import (
 	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/controler/pause"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/postprocessor/domainscrawl"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/postprocessor/sitemap"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/tracing"
 	"github.com/internetarchive/Zeno/v2/pkg/models"
 )
 
@@ -96,8 +99,15 @@ func (p *postprocessor) worker(workerID string) {
 					panic(fmt.Sprintf("seed consistency check failed with err: %s, seed id %s", err.Error(), seed.GetShortID()))
 				}
 
+				tracing.StartStage(seed, tracing.StagePostprocessor)
+
 				if seed.GetStatus() != models.ItemArchived && seed.GetStatus() != models.ItemGotRedirected && seed.GetStatus() != models.ItemGotChildren {
 					logger.Debug("skipping seed", "seed", seed.GetShortID(), "depth", seed.GetDepth(), "hops", seed.GetURL().GetHops(), "status", seed.GetStatus())
+
+					// Discarded seeds still get a metadata record explaining why
+					if seed.GetDiscardReason() != "" {
+						writeMetadataRecord(seed, nil)
+					}
 				} else {
 					outlinks := postprocess(workerID, seed)
 					for i := range outlinks {
@@ -112,6 +122,7 @@ func (p *postprocessor) worker(workerID string) {
 				}
 
 				closeBodies(seed)
+				tracing.EndStage(seed, tracing.StagePostprocessor)
 
 				select {
 				case <-p.ctx.Done():
@@ -139,13 +150,40 @@ func postprocess(workerID string, seed *models.Item) []*models.Item {
 	}
 
 	for i := range childs {
+		fetched := childs[i].GetStatus() == models.ItemArchived || childs[i].GetDiscardReason() != ""
+
 		seedOutlinks := postprocessItem(childs[i])
 		outlinks = append(outlinks, seedOutlinks...)
+
+		if fetched {
+			writeMetadataRecord(childs[i], seedOutlinks)
+		}
+
+		// Hosts matched by --domains-crawl get their sitemaps walked once
+		if childs[i].GetStatus() == models.ItemArchived && domainscrawl.Enabled() && domainscrawl.Match(childs[i].GetURL().String()) {
+			sitemap.Discover(childs[i].GetURL().GetParsed())
+		}
 	}
 
 	return outlinks
 }
 
+// EnqueueDiscovered sends a URL discovered outside of the processing of an item,
+// e.g. in a sitemap, to the finisher as a new seed at hop 0
+func EnqueueDiscovered(rawURL, via string) {
+	if globalPostprocessor == nil {
+		return
+	}
+
+	URL := &models.URL{Raw: rawURL}
+	URL.SetHops(0)
+
+	select {
+	case <-globalPostprocessor.ctx.Done():
+	case globalPostprocessor.outputCh <- models.NewItem(URL, via):
+	}
+}
+
 func closeBodies(seed *models.Item) {
 	seed.Traverse(func(seed *models.Item) {
 		seed.Close()
@@ -0,0 +1,10 @@
+package sitemap
+
+import "errors"
+
+var (
+	// ErrSitemapAlreadyInitialized is the error returned when the sitemap discovery is already initialized
+	ErrSitemapAlreadyInitialized = errors.New("sitemap discovery already initialized")
+	// ErrNotASitemap is the error returned when a document is neither a urlset nor a sitemapindex
+	ErrNotASitemap = errors.New("document is not a sitemap")
+)
@@ -0,0 +1,141 @@
+// Package sitemap discovers the sitemaps of the hosts matched by --domains-crawl:
+// the first time such a host is seen, its robots.txt Sitemap: lines and its
+// /sitemap.xml are fetched, sitemap indexes are walked (gzip'd sitemaps
+// included) and every <loc> page is handed back to be crawled at hop 0.
+package sitemap
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
+)
+
+const (
+	// Hosts waiting for discovery, above that new hosts are dropped and retried when seen again
+	queueSize = 1000
+	// Number of hosts discovered concurrently
+	workers = 2
+)
+
+// Doer is the HTTP client used to fetch robots.txt and sitemaps, in practice the WARC-writing client
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// MatchFunc tells if a page found in a sitemap is in scope
+type MatchFunc func(rawURL string) bool
+
+// EnqueueFunc is called for every in scope page found in a sitemap, via is the sitemap URL
+type EnqueueFunc func(rawURL, via string)
+
+type discoverer struct {
+	wg        sync.WaitGroup
+	ctx       context.Context
+	cancel    context.CancelFunc
+	client    Doer
+	userAgent string
+	match     MatchFunc
+	enqueue   EnqueueFunc
+	queue     chan *url.URL
+	seen      sync.Map // Hosts (scheme://host) already discovered or queued
+}
+
+var (
+	globalDiscoverer *discoverer
+	once             sync.Once
+	logger           = log.NewFieldedLogger(&log.Fields{
+		"component": "postprocessor.sitemap",
+	})
+)
+
+// Start starts the sitemap discovery workers
+func Start(client Doer, userAgent string, match MatchFunc, enqueue EnqueueFunc) error {
+	var done bool
+
+	once.Do(func() {
+		globalDiscoverer = newDiscoverer(client, userAgent, match, enqueue)
+
+		for range workers {
+			globalDiscoverer.wg.Add(1)
+			go globalDiscoverer.worker()
+		}
+
+		logger.Info("started")
+		done = true
+	})
+
+	if !done {
+		return ErrSitemapAlreadyInitialized
+	}
+
+	return nil
+}
+
+// Stop stops the discovery, sitemaps being walked are abandoned
+func Stop() {
+	if globalDiscoverer == nil {
+		return
+	}
+
+	globalDiscoverer.cancel()
+	globalDiscoverer.wg.Wait()
+	globalDiscoverer = nil
+	once = sync.Once{}
+	logger.Info("stopped")
+}
+
+// Discover queues the host of u for sitemap discovery if it wasn't already, it never blocks
+func Discover(u *url.URL) {
+	if globalDiscoverer == nil || u == nil {
+		return
+	}
+	globalDiscoverer.discover(u)
+}
+
+func newDiscoverer(client Doer, userAgent string, match MatchFunc, enqueue EnqueueFunc) *discoverer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &discoverer{
+		ctx:       ctx,
+		cancel:    cancel,
+		client:    client,
+		userAgent: userAgent,
+		match:     match,
+		enqueue:   enqueue,
+		queue:     make(chan *url.URL, queueSize),
+	}
+}
+
+func (d *discoverer) discover(u *url.URL) {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return
+	}
+
+	root := &url.URL{Scheme: u.Scheme, Host: u.Host}
+	if _, loaded := d.seen.LoadOrStore(root.String(), struct{}{}); loaded {
+		return
+	}
+
+	select {
+	case d.queue <- root:
+	default:
+		logger.Debug("discovery queue full, host will be retried later", "host", root.String())
+		d.seen.Delete(root.String())
+	}
+}
+
+func (d *discoverer) worker() {
+	defer d.wg.Done()
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case root := <-d.queue:
+			found := d.walk(root)
+			logger.Info("sitemaps walked", "host", root.String(), "urls", found)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/preprocessor/robots"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name             string
+		document         string
+		expectedSitemaps []string
+		expectedPages    []string
+		expectedErr      bool
+	}{
+		{
+			name: "urlset",
+			document: `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">
+  <url><loc> https://example.com/a </loc><lastmod>2025-01-01</lastmod></url>
+  <url><loc>/relative</loc><image:image><image:loc>https://example.com/img.png</image:loc></image:image></url>
+  <url><loc>mailto:someone@example.com</loc></url>
+</urlset>`,
+			expectedPages: []string{"https://example.com/a", "https://example.com/relative"},
+		},
+		{
+			name: "sitemap index",
+			document: `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://example.com/s1.xml</loc></sitemap>
+  <sitemap><loc>https://example.com/s2.xml.gz</loc></sitemap>
+</sitemapindex>`,
+			expectedSitemaps: []string{"https://example.com/s1.xml", "https://example.com/s2.xml.gz"},
+		},
+		{
+			name:          "truncated",
+			document:      `<urlset><url><loc>https://example.com/a</loc></url><url><loc>https://exa`,
+			expectedPages: []string{"https://example.com/a"},
+		},
+		{
+			name:        "not a sitemap",
+			document:    `<html><body><a href="https://example.com/">x</a></body></html>`,
+			expectedErr: true,
+		},
+		{
+			name:        "empty",
+			document:    "",
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sitemaps, pages, err := Parse(strings.NewReader(tt.document), "https://example.com/sitemap.xml")
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("Parse() error = %v, expected error: %v", err, tt.expectedErr)
+			}
+
+			if !slices.Equal(sitemaps, tt.expectedSitemaps) {
+				t.Errorf("sitemaps = %v, want %v", sitemaps, tt.expectedSitemaps)
+			}
+
+			if !slices.Equal(pages, tt.expectedPages) {
+				t.Errorf("pages = %v, want %v", pages, tt.expectedPages)
+			}
+		})
+	}
+}
+
+func gzipBytes(t *testing.T, s string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	writer.Write([]byte(s))
+	writer.Close()
+
+	return buf.Bytes()
+}
+
+func newTestSite(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.Write([]byte("User-agent: *\nDisallow: /private\nSitemap: " + server.URL + "/index.xml\n"))
+		case "/index.xml":
+			w.Write([]byte(`<sitemapindex>
+				<sitemap><loc>` + server.URL + `/s1.xml</loc></sitemap>
+				<sitemap><loc>` + server.URL + `/s2.xml.gz</loc></sitemap>
+				<sitemap><loc>` + server.URL + `/index.xml</loc></sitemap>
+				<sitemap><loc>` + server.URL + `/old.xml</loc></sitemap>
+			</sitemapindex>`))
+		case "/s1.xml":
+			w.Write([]byte(`<urlset>
+				<url><loc>` + server.URL + `/page1</loc></url>
+				<url><loc>https://elsewhere.example.org/page</loc></url>
+			</urlset>`))
+		case "/s2.xml.gz":
+			w.Header().Set("Content-Type", "application/gzip")
+			w.Write(gzipBytes(t, `<urlset><url><loc>`+server.URL+`/page2</loc></url></urlset>`))
+		case "/old.xml":
+			http.Redirect(w, r, "/s3.xml", http.StatusMovedPermanently)
+		case "/s3.xml":
+			w.Write([]byte(`<urlset><url><loc>` + server.URL + `/page3</loc></url></urlset>`))
+		case "/sitemap.xml":
+			w.Write([]byte(`<urlset><url><loc>` + server.URL + `/page4</loc></url></urlset>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	t.Cleanup(func() {
+		server.Client().CloseIdleConnections()
+		server.Close()
+	})
+
+	return server
+}
+
+type enqueued struct {
+	sync.Mutex
+	pages map[string]string // via by page
+}
+
+func (e *enqueued) add(rawURL, via string) {
+	e.Lock()
+	defer e.Unlock()
+	e.pages[rawURL] = via
+}
+
+func (e *enqueued) get() map[string]string {
+	e.Lock()
+	defer e.Unlock()
+	return e.pages
+}
+
+func TestWalk(t *testing.T) {
+	server := newTestSite(t)
+	serverURL, _ := url.Parse(server.URL)
+
+	got := &enqueued{pages: make(map[string]string)}
+	match := func(rawURL string) bool {
+		return strings.HasPrefix(rawURL, server.URL)
+	}
+
+	d := newDiscoverer(server.Client(), "Zeno", match, got.add)
+	defer d.cancel()
+
+	found := d.walk(serverURL)
+
+	expected := map[string]string{
+		server.URL + "/page1": server.URL + "/s1.xml",
+		server.URL + "/page2": server.URL + "/s2.xml.gz",
+		server.URL + "/page3": server.URL + "/old.xml",
+		server.URL + "/page4": server.URL + "/sitemap.xml",
+	}
+
+	if found != len(expected) {
+		t.Errorf("walk() = %d, want %d", found, len(expected))
+	}
+
+	for page, via := range expected {
+		if got.get()[page] != via {
+			t.Errorf("expected %s to be enqueued via %s, got %q", page, via, got.get()[page])
+		}
+	}
+
+	if _, ok := got.get()["https://elsewhere.example.org/page"]; ok {
+		t.Error("expected out of scope page not to be enqueued")
+	}
+}
+
+// countingClient counts the robots.txt requests sent through it
+type countingClient struct {
+	client *http.Client
+	robots atomic.Int32
+}
+
+func (c *countingClient) Do(req *http.Request) (*http.Response, error) {
+	if req.URL.Path == "/robots.txt" {
+		c.robots.Add(1)
+	}
+	return c.client.Do(req)
+}
+
+func TestWalkCachedRobots(t *testing.T) {
+	server := newTestSite(t)
+	serverURL, _ := url.Parse(server.URL)
+	client := &countingClient{client: server.Client()}
+
+	robots.Start(client, "Zeno", robots.PolicyArchiveOnly, nil)
+	defer robots.Stop()
+
+	// The robots.txt of the host was already fetched by the preprocessor
+	robots.Get(serverURL)
+
+	got := &enqueued{pages: make(map[string]string)}
+	d := newDiscoverer(client, "Zeno", func(string) bool { return true }, got.add)
+	defer d.cancel()
+
+	if found := d.walk(serverURL); found != 5 {
+		t.Errorf("walk() = %d, want 5", found)
+	}
+	if got.get()[server.URL+"/page1"] != server.URL+"/s1.xml" {
+		t.Error("expected the sitemaps of the cached robots.txt to be walked")
+	}
+	if client.robots.Load() != 1 {
+		t.Errorf("expected robots.txt to be fetched once, got %d", client.robots.Load())
+	}
+}
+
+func TestDiscoverOncePerHost(t *testing.T) {
+	server := newTestSite(t)
+	serverURL, _ := url.Parse(server.URL)
+
+	got := &enqueued{pages: make(map[string]string)}
+	if err := Start(server.Client(), "Zeno", func(string) bool { return true }, got.add); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer Stop()
+
+	if err := Start(server.Client(), "Zeno", nil, nil); err != ErrSitemapAlreadyInitialized {
+		t.Errorf("second Start() error = %v, want %v", err, ErrSitemapAlreadyInitialized)
+	}
+
+	for range 3 {
+		Discover(serverURL.JoinPath("some", "page"))
+	}
+	Discover(&url.URL{Scheme: "ftp", Host: serverURL.Host})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(got.get()) < 5 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if len(got.get()) != 5 {
+		t.Errorf("expected 5 pages enqueued, got %v", got.get())
+	}
+
+	queued := 0
+	globalDiscoverer.seen.Range(func(_, _ any) bool {
+		queued++
+		return true
+	})
+	if queued != 1 {
+		t.Errorf("expected one host to be discovered, got %d", queued)
+	}
+}
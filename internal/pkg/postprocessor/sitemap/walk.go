@@ -0,0 +1,242 @@
+package sitemap
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/preprocessor/robots"
+)
+
+const (
+	// The sitemaps protocol limits sitemaps to 50 MB uncompressed
+	maxSitemapSize = 50 * 1024 * 1024
+	// robots.txt are parsed up to 512 KiB, as in the robots package
+	maxRobotsSize = 512 * 1024
+	// Maximum number of sitemaps (indexes included) walked per host
+	maxSitemapsPerHost = 1000
+	maxRedirects       = 5
+	fetchTimeout       = 2 * time.Minute
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// walk fetches the sitemaps of root and enqueues the pages they list, it returns the number of pages enqueued
+func (d *discoverer) walk(root *url.URL) (found int) {
+	queue := d.entrypoints(root)
+	visited := make(map[string]bool)
+
+	for len(queue) > 0 && len(visited) < maxSitemapsPerHost {
+		if d.ctx.Err() != nil {
+			return found
+		}
+
+		sitemapURL := queue[0]
+		queue = queue[1:]
+
+		if visited[sitemapURL] {
+			continue
+		}
+		visited[sitemapURL] = true
+
+		sitemaps, pages, err := d.fetchSitemap(sitemapURL)
+		if err != nil {
+			logger.Debug("unable to fetch sitemap", "url", sitemapURL, "err", err.Error())
+			continue
+		}
+
+		queue = append(queue, sitemaps...)
+
+		for _, page := range pages {
+			if d.ctx.Err() != nil {
+				return found
+			}
+			if d.match(page) {
+				d.enqueue(page, sitemapURL)
+				found++
+			}
+		}
+	}
+
+	return found
+}
+
+// entrypoints returns the sitemaps listed in robots.txt followed by /sitemap.xml. When robots.txt
+// are handled, the cached one is used so that it isn't fetched and archived twice.
+func (d *discoverer) entrypoints(root *url.URL) (sitemaps []string) {
+	defaultSitemap := root.JoinPath("sitemap.xml").String()
+
+	var listed []string
+	if rules := robots.Get(root); rules != nil {
+		listed = rules.Sitemaps
+	} else if !robots.Enabled() {
+		body, err := d.get(root.JoinPath("robots.txt").String(), maxRobotsSize)
+		if err != nil {
+			logger.Debug("unable to fetch robots.txt for sitemaps", "host", root.String(), "err", err.Error())
+		} else {
+			listed = robots.Parse(body, d.userAgent).Sitemaps
+		}
+	}
+
+	for _, sitemap := range listed {
+		if sitemap != defaultSitemap {
+			sitemaps = append(sitemaps, sitemap)
+		}
+	}
+
+	return append(sitemaps, defaultSitemap)
+}
+
+func (d *discoverer) fetchSitemap(sitemapURL string) (sitemaps, pages []string, err error) {
+	body, err := d.get(sitemapURL, maxSitemapSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// .xml.gz sitemaps are usually served as application/gzip without Content-Encoding
+	var reader io.Reader = bytes.NewReader(body)
+	if bytes.HasPrefix(body, gzipMagic) {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer gzipReader.Close()
+		reader = io.LimitReader(gzipReader, maxSitemapSize)
+	}
+
+	return Parse(reader, sitemapURL)
+}
+
+// get fetches rawURL following redirections and returns up to limit bytes of its body.
+// The rest of the body is consumed so that the WARC record gets written entirely.
+func (d *discoverer) get(rawURL string, limit int64) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(d.ctx, fetchTimeout)
+	defer cancel()
+
+	for range maxRedirects + 1 {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", d.userAgent)
+
+		resp, err := d.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode >= 300 && resp.StatusCode < 400 && resp.Header.Get("Location") != "":
+			location, err := req.URL.Parse(resp.Header.Get("Location"))
+			if err != nil {
+				return nil, err
+			}
+			rawURL = location.String()
+			continue
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			return body, err
+		default:
+			return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+	}
+
+	return nil, fmt.Errorf("too many redirections")
+}
+
+// Parse parses a sitemap or a sitemap index and returns the sitemaps and pages
+// it lists, resolved against base. Extension elements (image:loc, video:loc...)
+// are ignored, only the <loc> of <url> and <sitemap> elements are returned.
+func Parse(r io.Reader, base string) (sitemaps, pages []string, err error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	decoder := xml.NewDecoder(bufio.NewReader(r))
+	decoder.Strict = false
+	// The protocol requires UTF-8, other labels are read as is rather than rejected
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	var (
+		stack []string
+		root  string
+		loc   strings.Builder
+	)
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			if root == "" {
+				return nil, nil, err
+			}
+			// Keep what was parsed from truncated or broken sitemaps
+			break
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if root == "" {
+				root = t.Name.Local
+				if root != "urlset" && root != "sitemapindex" {
+					return nil, nil, ErrNotASitemap
+				}
+			}
+			stack = append(stack, t.Name.Local)
+			loc.Reset()
+		case xml.CharData:
+			if len(stack) > 0 && stack[len(stack)-1] == "loc" {
+				loc.Write(t)
+			}
+		case xml.EndElement:
+			if len(stack) >= 2 && t.Name.Local == "loc" {
+				parent := stack[len(stack)-2]
+				if resolved := resolve(baseURL, loc.String()); resolved != "" {
+					if parent == "sitemap" && root == "sitemapindex" {
+						sitemaps = append(sitemaps, resolved)
+					} else if parent == "url" && root == "urlset" {
+						pages = append(pages, resolved)
+					}
+				}
+			}
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	if root == "" {
+		return nil, nil, ErrNotASitemap
+	}
+
+	return sitemaps, pages, nil
+}
+
+func resolve(base *url.URL, loc string) string {
+	loc = strings.TrimSpace(loc)
+	if loc == "" {
+		return ""
+	}
+
+	u, err := base.Parse(loc)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+
+	return u.String()
+}
@@ -1,7 +1,11 @@
 package postprocessor
 
 import (
+	"net/http"
+	"net/url"
 	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/internetarchive/Zeno/v2/pkg/models"
 )
@@ -19,6 +23,59 @@ func isStatusCodeRedirect(statusCode int) bool {
 	}
 }
 
+// sensitiveHeaders are only sent again on redirections to the same host or one of its subdomains, as net/http does
+var sensitiveHeaders = []string{"Authorization", "Www-Authenticate", "Cookie", "Cookie2", "Proxy-Authorization", "Proxy-Authenticate"}
+
+// redirectRequest returns the request overrides of the URL a redirection to location leads to:
+// 307 and 308 keep the method and body, the others turn anything but HEAD into a
+// GET without body, as browsers do. The headers describing the body are dropped with it,
+// and the credentials aren't sent to another host.
+func redirectRequest(URL *models.URL, statusCode int, location string) (method string, headers http.Header, payload []byte) {
+	keepBody := statusCode == http.StatusTemporaryRedirect || statusCode == http.StatusPermanentRedirect ||
+		URL.GetMethod() == http.MethodHead
+	keepCredentials := isSameHostRedirect(URL, location)
+
+	for key, values := range URL.Headers {
+		if !keepBody && strings.HasPrefix(key, "Content-") {
+			continue
+		}
+		if !keepCredentials && slices.Contains(sensitiveHeaders, key) {
+			continue
+		}
+		if headers == nil {
+			headers = make(http.Header)
+		}
+		headers[key] = slices.Clone(values)
+	}
+
+	if keepBody {
+		return URL.Method, headers, URL.Payload
+	}
+
+	return "", headers, nil
+}
+
+// isSameHostRedirect returns true if location, resolved against the URL, points to the
+// host of the URL or to one of its subdomains
+func isSameHostRedirect(URL *models.URL, location string) bool {
+	base := URL.GetParsed()
+	if base == nil {
+		var err error
+		if base, err = url.Parse(URL.Raw); err != nil {
+			return false
+		}
+	}
+
+	target, err := base.Parse(location)
+	if err != nil {
+		return false
+	}
+
+	src, dst := strings.ToLower(base.Hostname()), strings.ToLower(target.Hostname())
+
+	return dst == src || strings.HasSuffix(dst, "."+src)
+}
+
 func filterURLsByProtocol(links []*models.URL) []*models.URL {
 	var filtered []*models.URL
 	for _, link := range links {
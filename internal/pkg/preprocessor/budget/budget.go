@@ -0,0 +1,100 @@
+// Package budget enforces the per-seed crawl budgets (--seed-max-items,
+// --seed-max-size and --seed-max-duration) on the item trees: once a seed went
+// over one of them, the items of its tree that are left are marked as failed.
+package budget
+
+import (
+	"fmt"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/crawllog"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+)
+
+// Budgets a seed can go over
+const (
+	ReasonMaxItems    = "max-items"
+	ReasonMaxSize     = "max-size"
+	ReasonMaxDuration = "max-duration"
+)
+
+var logger = log.NewFieldedLogger(&log.Fields{
+	"component": "preprocessor.budget",
+})
+
+// Exceeded returns the size or duration budget the seed of item went over, empty if none
+func Exceeded(item *models.Item) string {
+	if maxDuration := config.Get().SeedMaxDuration; maxDuration > 0 && item.GetBudgetElapsed() >= maxDuration {
+		return ReasonMaxDuration
+	}
+
+	if maxSize := int64(config.Get().SeedMaxSizeMiB) * 1024 * 1024; maxSize > 0 && item.GetBudgetBytes() >= maxSize {
+		return ReasonMaxSize
+	}
+
+	return ""
+}
+
+// Enforce marks as failed the fresh items of the seed that don't fit in its budgets
+// and returns the ones that do, the seed itself is never failed
+func Enforce(seed *models.Item, items []*models.Item) []*models.Item {
+	if len(items) == 0 || (len(items) == 1 && items[0].IsSeed()) {
+		return items
+	}
+
+	if reason := Exceeded(seed); reason != "" {
+		for i := range items {
+			Fail(items[i], reason)
+		}
+		return nil
+	}
+
+	maxItems := config.Get().SeedMaxItems
+	if maxItems <= 0 {
+		return items
+	}
+
+	// The items crawled so far are the ones in the tree that aren't waiting to be
+	allowed := maxItems - (seed.GetBudgetItems() - len(items))
+	if allowed >= len(items) {
+		return items
+	}
+	allowed = max(allowed, 0)
+
+	for i := allowed; i < len(items); i++ {
+		Fail(items[i], ReasonMaxItems)
+	}
+
+	return items[:allowed]
+}
+
+// Fail marks the item as failed because its seed went over the given budget,
+// the overrun is logged and counted once per seed
+func Fail(item *models.Item, reason string) {
+	item.SetStatus(models.ItemFailed)
+	item.SetError(fmt.Errorf("%w: %s", ErrBudgetExceeded, reason))
+
+	// The item isn't fetched, log it as blocked the way Heritrix does
+	if crawllog.Enabled() {
+		entry := crawllog.NewEntry(item)
+		entry.End()
+		entry.Status = crawllog.StatusQuotaExceeded
+		if reason == ReasonMaxDuration {
+			entry.Status = crawllog.StatusRuntimeExceeded
+		}
+		crawllog.Write(entry)
+	}
+
+	if item.SetBudgetExceeded(reason) {
+		stats.SeedBudgetOverrunsIncr(reason)
+		logger.Warn("seed budget exceeded, skipping its remaining items",
+			"seed_id", item.GetSeed().GetShortID(),
+			"seed", item.GetSeed().GetURL().String(),
+			"reason", reason,
+			"items", item.GetBudgetItems(),
+			"bytes", item.GetBudgetBytes(),
+			"elapsed", item.GetBudgetElapsed())
+	}
+}
@@ -0,0 +1,177 @@
+package budget
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+)
+
+func newTestURL(t *testing.T, raw string) *models.URL {
+	t.Helper()
+
+	URL, err := models.NewURL(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &URL
+}
+
+// newTestSeed returns an archived seed with the given number of already crawled
+// children, and the given number of fresh grandchildren waiting to be crawled
+func newTestSeed(t *testing.T, crawled, fresh int) (*models.Item, []*models.Item) {
+	t.Helper()
+
+	seed := models.NewItem(newTestURL(t, "https://example.com/"), "")
+	seed.SetStatus(models.ItemArchived)
+
+	var parent *models.Item
+	for i := range crawled {
+		child := models.NewItem(newTestURL(t, "https://example.com/"+strconv.Itoa(i)), "")
+		if err := seed.AddChild(child, models.ItemGotChildren); err != nil {
+			t.Fatal(err)
+		}
+		child.SetStatus(models.ItemArchived)
+		parent = child
+	}
+
+	var items []*models.Item
+	for i := range fresh {
+		child := models.NewItem(newTestURL(t, "https://example.com/fresh/"+strconv.Itoa(i)), "")
+		if err := parent.AddChild(child, models.ItemGotChildren); err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, child)
+	}
+
+	return seed, items
+}
+
+func TestEnforce(t *testing.T) {
+	stats.Init()
+
+	tests := []struct {
+		name           string
+		maxItems       int
+		maxSizeMiB     int
+		maxDuration    time.Duration
+		crawled        int
+		fresh          int
+		bytes          int64
+		elapsed        time.Duration
+		expectedKept   int
+		expectedReason string
+	}{
+		{
+			name:         "no budget",
+			crawled:      10,
+			fresh:        10,
+			expectedKept: 10,
+		},
+		{
+			name:         "within max items",
+			maxItems:     20,
+			crawled:      10,
+			fresh:        10,
+			expectedKept: 10,
+		},
+		{
+			name:           "over max items",
+			maxItems:       15,
+			crawled:        10,
+			fresh:          10,
+			expectedKept:   5,
+			expectedReason: ReasonMaxItems,
+		},
+		{
+			name:           "max items already reached",
+			maxItems:       5,
+			crawled:        10,
+			fresh:          10,
+			expectedKept:   0,
+			expectedReason: ReasonMaxItems,
+		},
+		{
+			name:           "over max size",
+			maxSizeMiB:     1,
+			crawled:        1,
+			fresh:          3,
+			bytes:          2 * 1024 * 1024,
+			expectedKept:   0,
+			expectedReason: ReasonMaxSize,
+		},
+		{
+			name:           "over max duration",
+			maxDuration:    time.Millisecond,
+			crawled:        1,
+			fresh:          3,
+			elapsed:        10 * time.Millisecond,
+			expectedKept:   0,
+			expectedReason: ReasonMaxDuration,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := config.Get()
+			config.Set(&config.Config{SeedMaxItems: tt.maxItems, SeedMaxSizeMiB: tt.maxSizeMiB, SeedMaxDuration: tt.maxDuration})
+			defer config.Set(previous)
+
+			seed, items := newTestSeed(t, tt.crawled, tt.fresh)
+			seed.StartBudget()
+			seed.AddBudgetBytes(tt.bytes)
+			time.Sleep(tt.elapsed)
+
+			overruns := stats.GetMapTUI()["Seed budget overruns"].(int64)
+
+			kept := Enforce(seed, items)
+			if len(kept) != tt.expectedKept {
+				t.Fatalf("Enforce() kept %d items, want %d", len(kept), tt.expectedKept)
+			}
+
+			failed := 0
+			for _, item := range items {
+				if item.GetStatus() == models.ItemFailed {
+					failed++
+					if !errors.Is(item.GetError(), ErrBudgetExceeded) {
+						t.Errorf("expected the failed item error to be %v, got %v", ErrBudgetExceeded, item.GetError())
+					}
+				}
+			}
+			if failed != tt.fresh-tt.expectedKept {
+				t.Errorf("expected %d failed items, got %d", tt.fresh-tt.expectedKept, failed)
+			}
+
+			if seed.GetBudgetExceeded() != tt.expectedReason {
+				t.Errorf("GetBudgetExceeded() = %q, want %q", seed.GetBudgetExceeded(), tt.expectedReason)
+			}
+
+			// An overrun is counted once per seed
+			expectedOverruns := overruns
+			if tt.expectedReason != "" {
+				expectedOverruns++
+			}
+			if got := stats.GetMapTUI()["Seed budget overruns"].(int64); got != expectedOverruns {
+				t.Errorf("expected %d overruns, got %d", expectedOverruns, got)
+			}
+		})
+	}
+}
+
+func TestEnforceSeedOnly(t *testing.T) {
+	previous := config.Get()
+	config.Set(&config.Config{SeedMaxItems: 1, SeedMaxDuration: time.Nanosecond})
+	defer config.Set(previous)
+
+	seed := models.NewItem(newTestURL(t, "https://example.com/"), "")
+	seed.StartBudget()
+	time.Sleep(time.Millisecond)
+
+	if kept := Enforce(seed, []*models.Item{seed}); len(kept) != 1 || seed.GetStatus() != models.ItemFresh {
+		t.Fatalf("expected the seed to never be failed, got %d items and status %s", len(kept), seed.GetStatus())
+	}
+}
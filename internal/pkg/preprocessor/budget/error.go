@@ -0,0 +1,8 @@
+package budget
+
+import "errors"
+
+var (
+	// ErrBudgetExceeded is the error set on the items failed because their seed went over a budget
+	ErrBudgetExceeded = errors.New("seed budget exceeded")
+)
@@ -10,19 +10,26 @@
 package preprocessor
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"sync"
 	"time"
 
+	"github.com/internetarchive/Zeno/v2/internal/pkg/archiver/discard/discarder/rules"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/controler/pause"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/cookies"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/log/dumper"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/preprocessor/budget"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/preprocessor/robots"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/preprocessor/sitespecific"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/tracing"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/utils"
 	"github.com/internetarchive/Zeno/v2/pkg/models"
 )
@@ -138,9 +145,11 @@ func (p *preprocessor) worker(workerID string) {
 					panic(fmt.Sprintf("preprocessor received seed with status %d, seed id: %s, worker_id %s", seed.GetStatus(), seed.GetShortID(), workerID))
 				}
 
+				tracing.StartStage(seed, tracing.StagePreprocessor)
 				if err := preprocess(workerID, seed); err != nil {
 					panic(fmt.Sprintf("preprocess failed with err: %v", err))
 				}
+				tracing.EndStage(seed, tracing.StagePreprocessor)
 
 				select {
 				case <-p.ctx.Done():
@@ -162,6 +171,9 @@ func preprocess(workerID string, seed *models.Item) error {
 
 	operatingDepth := seed.GetMaxDepth()
 
+	// The seed's duration budget starts the first time it is preprocessed
+	seed.StartBudget()
+
 	items, err := seed.GetNodesAtLevel(operatingDepth)
 	if err != nil {
 		return err
@@ -191,10 +203,16 @@ func preprocess(workerID string, seed *models.Item) error {
 			}
 		}
 
+		// The scope can be reloaded at any time, use the same one for all the checks
+		scope := config.Get().GetScope()
+
+		// The seed can override the included hosts
+		includeHosts := items[i].GetSeedScope().GetIncludeHosts(scope.IncludeHosts)
+
 		// Apply include filters first, if any are defined
-		if len(config.Get().IncludeHosts) > 0 || len(config.Get().IncludeString) > 0 {
-			if !utils.StringContainsSliceElements(items[i].GetURL().GetParsed().Host, config.Get().IncludeHosts) &&
-				!utils.StringContainsSliceElements(items[i].GetURL().String(), config.Get().IncludeString) {
+		if len(includeHosts) > 0 || len(scope.IncludeString) > 0 {
+			if !utils.StringContainsSliceElements(items[i].GetURL().GetParsed().Host, includeHosts) &&
+				!utils.StringContainsSliceElements(items[i].GetURL().String(), scope.IncludeString) {
 
 				logger.Debug("URL excluded (does not match include filters)",
 					"item_id", items[i].GetShortID(),
@@ -211,9 +229,9 @@ func preprocess(workerID string, seed *models.Item) error {
 		}
 
 		// Apply exclusion filters even if it passed inclusion
-		if utils.StringContainsSliceElements(items[i].GetURL().GetParsed().Host, config.Get().ExcludeHosts) ||
-			utils.StringContainsSliceElements(items[i].GetURL().String(), config.Get().ExcludeString) ||
-			matchRegexExclusion(config.Get().GetExclusionRegexes(), items[i]) {
+		if utils.StringContainsSliceElements(items[i].GetURL().GetParsed().Host, scope.ExcludeHosts) ||
+			utils.StringContainsSliceElements(items[i].GetURL().String(), scope.ExcludeString) ||
+			matchRegexExclusion(scope.ExclusionRegexes, items[i]) {
 
 			logger.Debug("URL excluded (matches exclusion filters)",
 				"item_id", items[i].GetShortID(),
@@ -228,6 +246,50 @@ func preprocess(workerID string, seed *models.Item) error {
 			return nil
 		}
 
+		// Check robots.txt if needed, robots.txt itself is always allowed
+		if robots.Enabled() && !robots.Allowed(items[i].GetURL().GetParsed()) {
+			stats.RobotsDisallowedIncr()
+
+			if robots.GetPolicy() == robots.PolicyObey {
+				logger.Debug("URL excluded (disallowed by robots.txt)",
+					"item_id", items[i].GetShortID(),
+					"url", items[i].GetURL())
+
+				if items[i].IsChild() || items[i].IsRedirection() {
+					items[i].GetParent().RemoveChild(items[i])
+					continue
+				}
+
+				items[i].SetStatus(models.ItemCompleted)
+				return nil
+			}
+
+			// The capture is tagged in its metadata record for it to be told apart in the WARCs
+			items[i].SetRobotsDisallowed(true)
+			logger.Debug("URL disallowed by robots.txt, archiving anyway",
+				"item_id", items[i].GetShortID(),
+				"url", items[i].GetURL(),
+				"robots_disallowed", true)
+		}
+
+		// Discard rules only matching on the URL are applied before the request is sent
+		if discarded, reason := rules.MatchURL(items[i].GetURL().GetParsed()); discarded {
+			stats.DiscardedResponsesIncr(reason)
+			logger.Debug("URL discarded by a discard rule",
+				"item_id", items[i].GetShortID(),
+				"url", items[i].GetURL(),
+				"reason", reason)
+
+			if items[i].IsChild() || items[i].IsRedirection() {
+				items[i].GetParent().RemoveChild(items[i])
+				continue
+			}
+
+			items[i].SetDiscardReason(reason)
+			items[i].SetStatus(models.ItemFailed)
+			return nil
+		}
+
 		// If we are processing assets, then we need to remove childs that are just domains
 		// (which means that they are not assets, but false positives)
 		if items[i].IsChild() {
@@ -299,9 +361,25 @@ func preprocess(workerID string, seed *models.Item) error {
 		return nil
 	}
 
+	// Fail the items that don't fit in the seed's budgets
+	items = budget.Enforce(seed, items)
+	if len(items) == 0 {
+		logger.Debug("no more work to do within the seed budget")
+		seed.SetStatus(models.ItemCompleted)
+		return nil
+	}
+
 	// Finally, we build the requests, applying any site-specific behavior needed
 	for i := range items {
-		req, err := http.NewRequest(http.MethodGet, items[i].GetURL().String(), nil)
+		URL := items[i].GetURL()
+
+		// A bytes.Reader body lets the archiver rewind it when retrying
+		var body io.Reader
+		if len(URL.Payload) > 0 {
+			body = bytes.NewReader(URL.Payload)
+		}
+
+		req, err := http.NewRequest(URL.GetMethod(), URL.String(), body)
 		if err != nil {
 			logger.Error("unable to create request for URL", "item_id", items[i].GetShortID(), "url", items[i].GetURL(), "err", err.Error())
 			items[i].SetStatus(models.ItemFailed)
@@ -311,6 +389,16 @@ func preprocess(workerID string, seed *models.Item) error {
 		// Apply configured User-Agent
 		req.Header.Set("User-Agent", config.Get().UserAgent)
 
+		// Apply the seed's headers, they take precedence over the defaults
+		for key, values := range URL.Headers {
+			req.Header[key] = values
+		}
+
+		// Attach the cookies from the jar if --cookies is set
+		if jar := cookies.Get(); jar != nil {
+			jar.AddToRequest(req)
+		}
+
 		sitespecific.RunPreprocessors(items[i].GetURL(), req)
 
 		items[i].GetURL().SetRequest(req)
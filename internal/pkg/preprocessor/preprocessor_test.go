@@ -0,0 +1,62 @@
+package preprocessor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/preprocessor/robots"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/stats"
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+)
+
+// robotsClient serves robots.txt without network, the preprocessor refuses the URLs of local test servers
+type robotsClient string
+
+func (c robotsClient) Do(req *http.Request) (*http.Response, error) {
+	recorder := httptest.NewRecorder()
+	recorder.WriteString(string(c))
+	return recorder.Result(), nil
+}
+
+func TestPreprocessRobotsPolicy(t *testing.T) {
+	previous := config.Get()
+	t.Cleanup(func() { config.Set(previous) })
+	config.Set(&config.Config{UserAgent: "Zeno", MaxURLLength: 2048})
+	stats.Init()
+
+	client := robotsClient("User-agent: *\nDisallow: /private\n")
+
+	tests := []struct {
+		name           string
+		policy         robots.Policy
+		path           string
+		wantStatus     models.ItemState
+		wantDisallowed bool
+	}{
+		{name: "archive-only disallowed", policy: robots.PolicyArchiveOnly, path: "/private/page", wantStatus: models.ItemPreProcessed, wantDisallowed: true},
+		{name: "archive-only allowed", policy: robots.PolicyArchiveOnly, path: "/page", wantStatus: models.ItemPreProcessed},
+		{name: "obey disallowed", policy: robots.PolicyObey, path: "/private/page", wantStatus: models.ItemCompleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			robots.Start(client, "Zeno", tt.policy, nil)
+			defer robots.Stop()
+
+			seed := models.NewItem(&models.URL{Raw: "https://example.com" + tt.path}, "")
+			seed.SetSource(models.ItemSourceInsert)
+			if err := preprocess("test", seed); err != nil {
+				t.Fatalf("preprocess() error = %v", err)
+			}
+
+			if seed.GetStatus() != tt.wantStatus {
+				t.Errorf("status = %s, want %s", seed.GetStatus(), tt.wantStatus)
+			}
+			if seed.IsRobotsDisallowed() != tt.wantDisallowed {
+				t.Errorf("IsRobotsDisallowed() = %v, want %v", seed.IsRobotsDisallowed(), tt.wantDisallowed)
+			}
+		})
+	}
+}
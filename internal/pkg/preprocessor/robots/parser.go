@@ -0,0 +1,184 @@
+package robots
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rules holds the parsed robots.txt directives that apply to our User-Agent
+type Rules struct {
+	rules      []rule
+	CrawlDelay time.Duration
+	Sitemaps   []string
+}
+
+type rule struct {
+	pattern string
+	allow   bool
+}
+
+type group struct {
+	agents     []string
+	rules      []rule
+	crawlDelay time.Duration
+}
+
+var (
+	// allowAll is used when robots.txt is missing (4xx)
+	allowAll = &Rules{}
+
+	// disallowAll is used when robots.txt is unreachable (5xx or network error)
+	disallowAll = &Rules{rules: []rule{{pattern: "/", allow: false}}}
+)
+
+// Parse parses a robots.txt body following RFC 9309 and returns the rules
+// applying to the given User-Agent. A group matches if its user-agent token
+// is contained in our User-Agent string, the longest matching token wins and
+// the "*" group is used as a fallback.
+func Parse(data []byte, userAgent string) *Rules {
+	var (
+		groups       []*group
+		current      *group
+		inAgentLines bool
+		sitemaps     []string
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRobotsSize)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgentLines {
+				current = &group{}
+				groups = append(groups, current)
+				inAgentLines = true
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgentLines = false
+			if current == nil || value == "" {
+				continue
+			}
+			current.rules = append(current.rules, rule{pattern: value, allow: key == "allow"})
+		case "crawl-delay":
+			inAgentLines = false
+			if current == nil {
+				continue
+			}
+			if delay, err := strconv.ParseFloat(value, 64); err == nil && delay > 0 {
+				current.crawlDelay = time.Duration(delay * float64(time.Second))
+			}
+		case "sitemap":
+			// Sitemap lines are not tied to any group
+			if value != "" {
+				sitemaps = append(sitemaps, value)
+			}
+		}
+	}
+
+	rules := &Rules{Sitemaps: sitemaps}
+
+	userAgent = strings.ToLower(userAgent)
+	bestLength := -1
+	for _, g := range groups {
+		for _, agent := range g.agents {
+			length := -1
+			if agent == "*" {
+				length = 0
+			} else if agent != "" && strings.Contains(userAgent, agent) {
+				length = len(agent)
+			}
+
+			switch {
+			case length > bestLength:
+				bestLength = length
+				rules.rules = append([]rule(nil), g.rules...)
+				rules.CrawlDelay = g.crawlDelay
+			case length == bestLength && length >= 0:
+				// Groups with the same user-agent are merged
+				rules.rules = append(rules.rules, g.rules...)
+				rules.CrawlDelay = max(rules.CrawlDelay, g.crawlDelay)
+			}
+		}
+	}
+
+	return rules
+}
+
+// Allowed reports whether the given path (including the query string) can be
+// crawled. The most specific (longest) matching rule wins, allow wins ties.
+func (r *Rules) Allowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+
+	// robots.txt itself is always allowed
+	if path == "/robots.txt" {
+		return true
+	}
+
+	bestLength := -1
+	allowed := true
+	for _, rule := range r.rules {
+		if !match(rule.pattern, path) {
+			continue
+		}
+
+		length := len(rule.pattern)
+		if length > bestLength || (length == bestLength && rule.allow) {
+			bestLength = length
+			allowed = rule.allow
+		}
+	}
+
+	return allowed
+}
+
+// match reports whether path matches pattern, "*" matches any sequence of
+// characters and a trailing "$" anchors the pattern at the end of the path.
+func match(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = pattern[:len(pattern)-1]
+	}
+
+	parts := strings.Split(pattern, "*")
+
+	// The first part must be a prefix of the path
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	position := len(parts[0])
+
+	for i := 1; i < len(parts); i++ {
+		// The last part of an anchored pattern must match the end of the path
+		if anchored && i == len(parts)-1 {
+			return len(path)-position >= len(parts[i]) && strings.HasSuffix(path, parts[i])
+		}
+
+		index := strings.Index(path[position:], parts[i])
+		if index < 0 {
+			return false
+		}
+		position += index + len(parts[i])
+	}
+
+	return !anchored || position == len(path)
+}
@@ -0,0 +1,111 @@
+package robots
+
+import (
+	"testing"
+	"time"
+)
+
+const zenoUA = "Mozilla/5.0 (compatible; archive.org_bot +http://archive.org/details/archive.org_bot) Zeno/v2.0.0 warc/v0.8.101"
+
+func TestParseGroupSelection(t *testing.T) {
+	robotsTXT := []byte(`# comment
+User-agent: *
+Disallow: /private
+Crawl-delay: 1
+
+User-agent: archive.org_bot
+User-agent: other-bot
+Disallow: /archive-only # trailing comment
+Allow: /archive-only/public
+Crawl-delay: 2.5
+
+Sitemap: https://example.com/sitemap.xml
+
+User-agent: archive.org_bot
+Disallow: /merged
+`)
+
+	rules := Parse(robotsTXT, zenoUA)
+
+	if rules.CrawlDelay != 2500*time.Millisecond {
+		t.Errorf("expected crawl delay of 2.5s, got %s", rules.CrawlDelay)
+	}
+
+	if len(rules.Sitemaps) != 1 || rules.Sitemaps[0] != "https://example.com/sitemap.xml" {
+		t.Errorf("expected one sitemap, got %v", rules.Sitemaps)
+	}
+
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/", true},
+		{"/private", true}, // The "*" group must not be used
+		{"/archive-only", false},
+		{"/archive-only/public/page", true},
+		{"/merged/page", false},
+		{"/robots.txt", true},
+	}
+
+	for _, tt := range tests {
+		if got := rules.Allowed(tt.path); got != tt.allowed {
+			t.Errorf("Allowed(%q) = %v, want %v", tt.path, got, tt.allowed)
+		}
+	}
+
+	// Fallback to the "*" group
+	rules = Parse(robotsTXT, "SomeOtherCrawler/1.0")
+	if rules.Allowed("/private") {
+		t.Error("expected /private to be disallowed for the wildcard group")
+	}
+	if rules.CrawlDelay != time.Second {
+		t.Errorf("expected crawl delay of 1s, got %s", rules.CrawlDelay)
+	}
+}
+
+func TestAllowedPatterns(t *testing.T) {
+	rules := Parse([]byte(`User-agent: *
+Disallow: /*.php$
+Disallow: /search*q=
+Allow: /page
+Disallow: /page
+Disallow: /fish
+Allow: /fish/salmon.html
+Disallow: /empty-allow-wins
+Allow: /empty-allow-wins
+Disallow:
+`), zenoUA)
+
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/index.php", false},
+		{"/index.php?id=1", true},
+		{"/dir/index.php", false},
+		{"/index.phps", true},
+		{"/search?q=zeno", false},
+		{"/search/advanced?lang=en&q=zeno", false},
+		{"/search", true},
+		{"/page", true}, // Same length, allow wins
+		{"/fish", false},
+		{"/fish.html", false},
+		{"/fish/salmon.html", true},
+		{"/Fish", true}, // Paths are case-sensitive
+		{"/empty-allow-wins", true},
+		{"/other", true},
+	}
+
+	for _, tt := range tests {
+		if got := rules.Allowed(tt.path); got != tt.allowed {
+			t.Errorf("Allowed(%q) = %v, want %v", tt.path, got, tt.allowed)
+		}
+	}
+}
+
+func TestParseNoGroups(t *testing.T) {
+	rules := Parse([]byte("Disallow: /\nnot a directive\n"), zenoUA)
+	if !rules.Allowed("/anything") {
+		t.Error("expected rules outside of any group to be ignored")
+	}
+}
@@ -0,0 +1,269 @@
+// Package robots fetches, archives and caches the robots.txt of every host
+// encountered during the crawl, and tells the preprocessor whether a URL is
+// allowed according to the configured --robots-policy.
+package robots
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
+)
+
+// Policy defines what to do with robots.txt
+type Policy string
+
+const (
+	// PolicyIgnore doesn't fetch robots.txt at all
+	PolicyIgnore Policy = "ignore"
+	// PolicyObey fetches and archives robots.txt, drops disallowed URLs and honours Crawl-delay
+	PolicyObey Policy = "obey"
+	// PolicyArchiveOnly fetches and archives robots.txt, disallowed URLs are only tagged
+	PolicyArchiveOnly Policy = "archive-only"
+)
+
+const (
+	// RFC 9309 requires parsing at least 500 KiB
+	maxRobotsSize = 512 * 1024
+	// RFC 9309 requires following at least 5 consecutive redirects
+	maxRobotsRedirects = 5
+	// RFC 9309 recommends not caching robots.txt for more than 24 hours
+	cacheTTL = 24 * time.Hour
+	// Unreachable robots.txt are retried sooner
+	errorCacheTTL = 10 * time.Minute
+	// Above this many cached hosts, expired entries are purged
+	maxCachedHosts = 100_000
+	fetchTimeout   = 30 * time.Second
+)
+
+// Doer is the HTTP client used to fetch robots.txt, in practice the WARC-writing client
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// CrawlDelayFunc is called once per host when its robots.txt defines a Crawl-delay
+type CrawlDelayFunc func(host string, delay time.Duration)
+
+type hostEntry struct {
+	ready     chan struct{}
+	rules     *Rules
+	expiresAt time.Time
+}
+
+type manager struct {
+	client       Doer
+	userAgent    string
+	policy       Policy
+	onCrawlDelay CrawlDelayFunc
+
+	mu      sync.Mutex
+	entries map[string]*hostEntry
+	nowFunc func() time.Time
+}
+
+var (
+	globalManager atomic.Pointer[manager] // Replaced by Start and Stop while the workers read it
+	logger        = log.NewFieldedLogger(&log.Fields{
+		"component": "preprocessor.robots",
+	})
+)
+
+// ParsePolicy validates a --robots-policy value
+func ParsePolicy(value string) (Policy, error) {
+	switch policy := Policy(value); policy {
+	case PolicyIgnore, PolicyObey, PolicyArchiveOnly:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid robots policy %q, must be one of: %s, %s, %s", value, PolicyObey, PolicyIgnore, PolicyArchiveOnly)
+	}
+}
+
+// Start initializes the robots.txt cache, onCrawlDelay may be nil
+func Start(client Doer, userAgent string, policy Policy, onCrawlDelay CrawlDelayFunc) {
+	if policy == PolicyIgnore {
+		return
+	}
+
+	globalManager.Store(newManager(client, userAgent, policy, onCrawlDelay))
+	logger.Info("started", "policy", policy)
+}
+
+// Stop releases the robots.txt cache
+func Stop() {
+	globalManager.Store(nil)
+}
+
+// Enabled returns true if robots.txt are fetched
+func Enabled() bool {
+	return globalManager.Load() != nil
+}
+
+// GetPolicy returns the policy in use, PolicyIgnore if not started
+func GetPolicy() Policy {
+	m := globalManager.Load()
+	if m == nil {
+		return PolicyIgnore
+	}
+	return m.policy
+}
+
+// Allowed fetches (or gets from the cache) the robots.txt of the URL's host
+// and returns whether the URL is allowed. It always returns true if not started.
+func Allowed(u *url.URL) bool {
+	m := globalManager.Load()
+	if m == nil {
+		return true
+	}
+	return m.get(u).Allowed(requestPath(u))
+}
+
+// Get returns the robots.txt rules for the URL's host, fetching it if needed.
+// It returns nil if not started.
+func Get(u *url.URL) *Rules {
+	m := globalManager.Load()
+	if m == nil {
+		return nil
+	}
+	return m.get(u)
+}
+
+func newManager(client Doer, userAgent string, policy Policy, onCrawlDelay CrawlDelayFunc) *manager {
+	return &manager{
+		client:       client,
+		userAgent:    userAgent,
+		policy:       policy,
+		onCrawlDelay: onCrawlDelay,
+		entries:      make(map[string]*hostEntry),
+		nowFunc:      time.Now,
+	}
+}
+
+func (m *manager) get(u *url.URL) *Rules {
+	key := u.Scheme + "://" + u.Host
+
+	m.mu.Lock()
+	entry, ok := m.entries[key]
+	if ok {
+		select {
+		case <-entry.ready:
+			if m.nowFunc().After(entry.expiresAt) {
+				ok = false
+			}
+		default:
+			// Another worker is fetching it
+		}
+	}
+
+	if ok {
+		m.mu.Unlock()
+		<-entry.ready
+		return entry.rules
+	}
+
+	if len(m.entries) >= maxCachedHosts {
+		m.purgeExpired()
+	}
+
+	entry = &hostEntry{ready: make(chan struct{})}
+	m.entries[key] = entry
+	m.mu.Unlock()
+
+	rules, ttl := m.fetch(u)
+	entry.rules = rules
+	entry.expiresAt = m.nowFunc().Add(ttl)
+	close(entry.ready)
+
+	if rules.CrawlDelay > 0 && m.policy == PolicyObey && m.onCrawlDelay != nil {
+		m.onCrawlDelay(u.Host, rules.CrawlDelay)
+	}
+
+	return rules
+}
+
+// purgeExpired must be called with the lock held
+func (m *manager) purgeExpired() {
+	now := m.nowFunc()
+	for key, entry := range m.entries {
+		select {
+		case <-entry.ready:
+			if now.After(entry.expiresAt) {
+				delete(m.entries, key)
+			}
+		default:
+		}
+	}
+}
+
+// fetch gets robots.txt following RFC 9309 section 2.3.1: 2xx responses are
+// parsed, 4xx mean there are no restrictions and 5xx or network errors mean
+// complete disallow.
+func (m *manager) fetch(u *url.URL) (*Rules, time.Duration) {
+	robotsURL := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+
+	for redirect := 0; redirect <= maxRobotsRedirects; redirect++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL.String(), nil)
+		if err != nil {
+			logger.Warn("unable to create robots.txt request", "url", robotsURL.String(), "err", err.Error())
+			return allowAll, cacheTTL
+		}
+		req.Header.Set("User-Agent", m.userAgent)
+
+		resp, err := m.client.Do(req)
+		if err != nil {
+			logger.Warn("unable to fetch robots.txt, disallowing host for now", "url", robotsURL.String(), "err", err.Error())
+			return disallowAll, errorCacheTTL
+		}
+
+		// Read what we parse, then consume the rest so that the record gets written entirely
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsSize))
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode >= 300 && resp.StatusCode < 400 && resp.Header.Get("Location") != "":
+			location, err := robotsURL.Parse(resp.Header.Get("Location"))
+			if err != nil {
+				logger.Debug("invalid robots.txt redirection", "url", robotsURL.String(), "location", resp.Header.Get("Location"))
+				return allowAll, cacheTTL
+			}
+			robotsURL = location
+			continue
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			if err != nil {
+				logger.Warn("unable to read robots.txt, disallowing host for now", "url", robotsURL.String(), "err", err.Error())
+				return disallowAll, errorCacheTTL
+			}
+			logger.Debug("robots.txt fetched", "url", robotsURL.String(), "size", len(body))
+			return Parse(body, m.userAgent), cacheTTL
+		case resp.StatusCode >= 500:
+			logger.Warn("robots.txt unreachable, disallowing host for now", "url", robotsURL.String(), "status_code", resp.StatusCode)
+			return disallowAll, errorCacheTTL
+		default:
+			// 4xx, or any status we don't know what to do with
+			return allowAll, cacheTTL
+		}
+	}
+
+	// Too many redirects, RFC 9309 says to assume unavailable
+	return allowAll, cacheTTL
+}
+
+func requestPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path
+}
@@ -0,0 +1,151 @@
+package robots
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestManager(t *testing.T, handler http.HandlerFunc, onCrawlDelay CrawlDelayFunc) (*manager, *httptest.Server) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	client := server.Client()
+	t.Cleanup(func() {
+		client.CloseIdleConnections()
+		server.Close()
+	})
+
+	return newManager(client, zenoUA, PolicyObey, onCrawlDelay), server
+}
+
+func mustParseURL(t *testing.T, rawURL string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("unable to parse URL %q: %v", rawURL, err)
+	}
+	return u
+}
+
+func TestManagerFetchAndCache(t *testing.T) {
+	var fetches atomic.Int32
+	var delays sync.Map
+
+	m, server := newTestManager(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fetches.Add(1)
+			if r.Header.Get("User-Agent") != zenoUA {
+				t.Errorf("unexpected User-Agent %q", r.Header.Get("User-Agent"))
+			}
+			// Slow down to make concurrent callers wait for the same fetch
+			time.Sleep(50 * time.Millisecond)
+			w.Write([]byte("User-agent: *\nDisallow: /private\nCrawl-delay: 3\n"))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}, func(host string, delay time.Duration) {
+		delays.Store(host, delay)
+	})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			if m.get(mustParseURL(t, server.URL+"/private/page")).Allowed("/private/page") {
+				t.Error("expected /private/page to be disallowed")
+			}
+		})
+	}
+	wg.Wait()
+
+	if fetches.Load() != 1 {
+		t.Errorf("expected robots.txt to be fetched once, got %d", fetches.Load())
+	}
+
+	host := mustParseURL(t, server.URL).Host
+	if delay, ok := delays.Load(host); !ok || delay.(time.Duration) != 3*time.Second {
+		t.Errorf("expected crawl delay of 3s for %s, got %v", host, delay)
+	}
+
+	// Expired entries are refetched
+	m.nowFunc = func() time.Time { return time.Now().Add(cacheTTL + time.Minute) }
+	m.get(mustParseURL(t, server.URL+"/"))
+	if fetches.Load() != 2 {
+		t.Errorf("expected robots.txt to be refetched after expiration, got %d fetches", fetches.Load())
+	}
+}
+
+func TestManagerStatusCodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		allowed bool
+	}{
+		{
+			name: "not found allows everything",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			allowed: true,
+		},
+		{
+			name: "server error disallows everything",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			allowed: false,
+		},
+		{
+			name: "redirects are followed",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/robots.txt" {
+					http.Redirect(w, r, "/real-robots.txt", http.StatusMovedPermanently)
+					return
+				}
+				w.Write([]byte("User-agent: *\nDisallow: /\n"))
+			},
+			allowed: false,
+		},
+		{
+			name: "redirect loops allow everything",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "/robots.txt", http.StatusFound)
+			},
+			allowed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+				// Mimic the WARC-writing client that doesn't follow redirects
+				return http.ErrUseLastResponse
+			}}
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+			defer client.CloseIdleConnections()
+
+			m := newManager(client, zenoUA, PolicyObey, nil)
+			if got := m.get(mustParseURL(t, server.URL+"/page")).Allowed("/page"); got != tt.allowed {
+				t.Errorf("expected allowed to be %v, got %v", tt.allowed, got)
+			}
+		})
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	for _, value := range []string{"obey", "ignore", "archive-only"} {
+		if _, err := ParsePolicy(value); err != nil {
+			t.Errorf("unexpected error for %q: %v", value, err)
+		}
+	}
+
+	if _, err := ParsePolicy("maybe"); err == nil {
+		t.Error("expected an error for an invalid policy")
+	}
+}
@@ -2,8 +2,11 @@ package seencheck
 
 import (
 	"hash/fnv"
+	"maps"
 	"path"
+	"slices"
 	"strconv"
+	"strings"
 	"sync/atomic"
 
 	"github.com/internetarchive/Zeno/v2/pkg/models"
@@ -86,6 +89,15 @@ func SeencheckItem(item *models.Item) error {
 			return err
 		}
 
+		// Requests to the same URL with different methods, headers or bodies are different captures
+		if URL := items[i].GetURL(); URL.HasCustomRequest() {
+			h.Write([]byte(URL.GetMethod()))
+			for _, name := range slices.Sorted(maps.Keys(URL.Headers)) {
+				h.Write([]byte(name + ": " + strings.Join(URL.Headers[name], ", ") + "\r\n"))
+			}
+			h.Write(URL.Payload)
+		}
+
 		hash := strconv.FormatUint(h.Sum64(), 10)
 
 		var URLType string
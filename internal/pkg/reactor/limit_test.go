@@ -0,0 +1,70 @@
+package reactor
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+)
+
+func TestSetTokenLimit(t *testing.T) {
+	outputChan := make(chan *models.Item, 4)
+	if err := Start(4, outputChan); err != nil {
+		t.Fatalf("Error starting reactor: %s", err)
+	}
+	defer log.Stop()
+	defer Stop()
+
+	// checkLimit verifies the token limit and the number of seeds being processed
+	checkLimit := func(step string, wantLimit, wantInUse int) {
+		t.Helper()
+		if got := GetTokenLimit(); got != wantLimit {
+			t.Errorf("%s: expected token limit %d, got %d", step, wantLimit, got)
+		}
+		if got := GetTokensInUse(); got != wantInUse {
+			t.Errorf("%s: expected %d tokens in use, got %d", step, wantInUse, got)
+		}
+	}
+
+	checkLimit("start", 4, 0)
+
+	seeds := make([]*models.Item, 3)
+	for i := range seeds {
+		seeds[i] = models.NewItem(&models.URL{Raw: fmt.Sprintf("http://example.com/%d", i)}, "")
+		if err := ReceiveInsert(seeds[i]); err != nil {
+			t.Fatalf("Error inserting seed: %s", err)
+		}
+	}
+	checkLimit("inserted", 4, 3)
+
+	// Lowered below the seeds being processed, the tokens are taken back as they finish
+	if err := SetTokenLimit(1); err != nil {
+		t.Fatalf("Error setting token limit: %s", err)
+	}
+	checkLimit("lowered", 1, 3)
+
+	if err := MarkAsFinished(seeds[0]); err != nil {
+		t.Fatalf("Error marking seed as finished: %s", err)
+	}
+	checkLimit("one finished", 1, 2)
+
+	// Raised above the maximum, it is bounded by it
+	if err := SetTokenLimit(10); err != nil {
+		t.Fatalf("Error setting token limit: %s", err)
+	}
+	checkLimit("raised", 4, 2)
+
+	for _, seed := range seeds[1:] {
+		if err := MarkAsFinished(seed); err != nil {
+			t.Fatalf("Error marking seed as finished: %s", err)
+		}
+	}
+	checkLimit("all finished", 4, 0)
+
+	// Never lowered below 1
+	if err := SetTokenLimit(0); err != nil {
+		t.Fatalf("Error setting token limit: %s", err)
+	}
+	checkLimit("zero", 1, 0)
+}
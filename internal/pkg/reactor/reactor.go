@@ -7,6 +7,7 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/tracing"
 	"github.com/internetarchive/Zeno/v2/pkg/models"
 )
 
@@ -21,6 +22,9 @@ type reactor struct {
 	output       chan *models.Item  // Output channel
 	stateTable   sync.Map           // State table for tracking seeds by UUID
 	wg           sync.WaitGroup     // WaitGroup to manage goroutines
+	limitMu      sync.Mutex         // Mutex protecting the reserved tokens
+	reserved     int                // Tokens held by the reactor itself to lower the limit
+	toReserve    int                // Tokens to reserve as soon as seeds release them
 	// stopChan   chan struct{}      // Channel to signal when stop is finished
 }
 
@@ -105,6 +109,7 @@ func ReceiveFeedback(item *models.Item) error {
 		// An item sent to the feedback channel should be present on the state table, if not present reactor should error out
 		return ErrFeedbackItemNotPresent
 	}
+	tracing.StartStage(item, tracing.StageReactor)
 	select {
 	case <-globalReactor.ctx.Done():
 		return ErrReactorShuttingDown
@@ -145,6 +150,9 @@ func ReceiveInsert(item *models.Item) error {
 			return ErrReactorItemPresent
 		}
 
+		tracing.StartSeed(item)
+		tracing.StartStage(item, tracing.StageReactor)
+
 		globalReactor.input <- item
 		return nil
 	}
@@ -157,12 +165,85 @@ func MarkAsFinished(item *models.Item) error {
 	}
 
 	if _, loaded := globalReactor.stateTable.LoadAndDelete(item.GetID()); loaded {
-		<-globalReactor.tokenPool
+		tracing.EndSeed(item)
+
+		// The token of the seed is kept by the reactor if the limit was lowered while it was in use
+		globalReactor.limitMu.Lock()
+		if globalReactor.toReserve > 0 {
+			globalReactor.toReserve--
+			globalReactor.reserved++
+		} else {
+			<-globalReactor.tokenPool
+		}
+		globalReactor.limitMu.Unlock()
 		return nil
 	}
 	return ErrFinisehdItemNotFound
 }
 
+// SetTokenLimit changes the number of seeds that can be processed at the same time,
+// it is bounded by the maximum tokens given to Start. When the limit is lowered below the
+// number of seeds being processed, the tokens are taken back as the seeds finish.
+func SetTokenLimit(limit int) error {
+	if globalReactor == nil {
+		return ErrReactorNotInitialized
+	}
+
+	r := globalReactor
+	r.limitMu.Lock()
+	defer r.limitMu.Unlock()
+
+	limit = min(max(limit, 1), cap(r.tokenPool))
+	target := cap(r.tokenPool) - limit
+
+	// Give back the tokens that aren't reserved yet first
+	if target < r.reserved+r.toReserve {
+		release := r.reserved + r.toReserve - target
+		fromPending := min(release, r.toReserve)
+		r.toReserve -= fromPending
+		for range release - fromPending {
+			<-r.tokenPool
+			r.reserved--
+		}
+		return nil
+	}
+
+	for range target - r.reserved - r.toReserve {
+		select {
+		case r.tokenPool <- struct{}{}:
+			r.reserved++
+		default:
+			r.toReserve++
+		}
+	}
+
+	return nil
+}
+
+// GetTokenLimit returns the number of seeds that can be processed at the same time
+func GetTokenLimit() int {
+	if globalReactor == nil {
+		return 0
+	}
+
+	globalReactor.limitMu.Lock()
+	defer globalReactor.limitMu.Unlock()
+
+	return cap(globalReactor.tokenPool) - globalReactor.reserved - globalReactor.toReserve
+}
+
+// GetTokensInUse returns the number of seeds being processed
+func GetTokensInUse() int {
+	if globalReactor == nil {
+		return 0
+	}
+
+	globalReactor.limitMu.Lock()
+	defer globalReactor.limitMu.Unlock()
+
+	return len(globalReactor.tokenPool) - globalReactor.reserved
+}
+
 func (r *reactor) run() {
 	defer r.wg.Done()
 
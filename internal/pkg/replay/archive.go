@@ -0,0 +1,245 @@
+package replay
+
+import (
+	"bufio"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/cdx"
+	warc "github.com/internetarchive/gowarc"
+)
+
+// Archive is the in-memory index of the captures of a job
+type Archive struct {
+	warcsDir string
+	captures map[string][]*cdx.Entry // By SURT, sorted by timestamp
+	digests  map[string]*cdx.Entry   // First non-revisit capture of each payload digest
+	metadata map[string]*Metadata    // Latest metadata record of each URL
+	count    int
+}
+
+// Metadata is the content of a metadata record written with --warc-metadata-records
+type Metadata struct {
+	Via           string
+	HopPath       string
+	DiscardReason string
+	Outlinks      []Outlink
+}
+
+// Outlink is a URL found in a capture, Hop is L (link), E (embed) or R (redirection)
+type Outlink struct {
+	URL string
+	Hop string
+}
+
+// Load indexes all the closed WARC files of warcsDir
+func Load(warcsDir string) (*Archive, error) {
+	archive := &Archive{
+		warcsDir: warcsDir,
+		captures: make(map[string][]*cdx.Entry),
+		digests:  make(map[string]*cdx.Entry),
+		metadata: make(map[string]*Metadata),
+	}
+
+	files, err := filepath.Glob(filepath.Join(warcsDir, "*.warc.gz"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, ErrNoWARCFiles
+	}
+
+	for _, file := range files {
+		filename := filepath.Base(file)
+
+		err := cdx.Walk(file, func(record *warc.Record) {
+			if entry, ok := cdx.NewEntry(record, filename); ok {
+				archive.add(entry)
+				return
+			}
+
+			if record.Header.Get("WARC-Type") == "metadata" {
+				targetURI := strings.Trim(record.Header.Get("WARC-Target-URI"), "<>")
+				record.Content.Seek(0, io.SeekStart)
+				archive.metadata[targetURI] = parseMetadata(record.Content)
+			}
+		})
+		if err != nil {
+			logger.Error("unable to index WARC file", "file", filename, "err", err.Error())
+			continue
+		}
+
+		logger.Info("WARC file indexed", "file", filename)
+	}
+
+	for _, entries := range archive.captures {
+		cdx.SortEntries(entries)
+	}
+
+	return archive, nil
+}
+
+func (a *Archive) add(entry *cdx.Entry) {
+	a.captures[entry.SURT] = append(a.captures[entry.SURT], entry)
+	a.count++
+
+	if entry.MIME != "warc/revisit" && entry.Digest != "" {
+		if _, ok := a.digests[entry.Digest]; !ok {
+			a.digests[entry.Digest] = entry
+		}
+	}
+}
+
+// Len returns the number of captures in the archive
+func (a *Archive) Len() int {
+	return a.count
+}
+
+// Captures returns all the captures of a URL, sorted by timestamp
+func (a *Archive) Captures(rawURL string) []*cdx.Entry {
+	surt, err := cdx.SURT(rawURL)
+	if err != nil {
+		return nil
+	}
+	return a.captures[surt]
+}
+
+// Closest returns the capture of a URL closest to the given 14 digits timestamp,
+// an empty timestamp returns the latest capture.
+func (a *Archive) Closest(rawURL, timestamp string) *cdx.Entry {
+	captures := a.Captures(rawURL)
+	if len(captures) == 0 {
+		return nil
+	}
+
+	target, err := parseTimestamp(timestamp)
+	if err != nil {
+		return captures[len(captures)-1]
+	}
+
+	var (
+		closest  *cdx.Entry
+		distance time.Duration
+	)
+	for _, capture := range captures {
+		captureTime, err := parseTimestamp(capture.Timestamp)
+		if err != nil {
+			continue
+		}
+
+		d := captureTime.Sub(target)
+		if d < 0 {
+			d = -d
+		}
+
+		if closest == nil || d < distance {
+			closest, distance = capture, d
+		}
+	}
+
+	return closest
+}
+
+// Original returns the capture a revisit refers to, the capture itself if it isn't a revisit
+func (a *Archive) Original(entry *cdx.Entry) *cdx.Entry {
+	if entry.MIME != "warc/revisit" {
+		return entry
+	}
+	return a.digests[entry.Digest]
+}
+
+// Metadata returns the latest metadata record of a URL, nil if none
+func (a *Archive) Metadata(rawURL string) *Metadata {
+	return a.metadata[rawURL]
+}
+
+// Seeds returns the URLs that were captured as seeds (hop path empty), sorted
+func (a *Archive) Seeds() []string {
+	var seeds []string
+	for URL, metadata := range a.metadata {
+		if metadata.HopPath == "" {
+			seeds = append(seeds, URL)
+		}
+	}
+	slices.Sort(seeds)
+	return seeds
+}
+
+// URLs returns the captured URLs matching the given MIME type prefix, sorted and capped to limit
+func (a *Archive) URLs(mimePrefix string, limit int) []string {
+	var URLs []string
+	for _, captures := range a.captures {
+		capture := captures[len(captures)-1]
+		if strings.HasPrefix(capture.MIME, mimePrefix) {
+			URLs = append(URLs, capture.URL)
+		}
+	}
+	slices.Sort(URLs)
+	if len(URLs) > limit {
+		URLs = URLs[:limit]
+	}
+	return URLs
+}
+
+// ReadRecord reads the WARC record of a capture, the caller must close its content
+func (a *Archive) ReadRecord(entry *cdx.Entry) (*warc.Record, error) {
+	file, err := os.Open(filepath.Join(a.warcsDir, filepath.Base(entry.Filename)))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(entry.Offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	reader, err := warc.NewReader(io.LimitReader(file, entry.Length))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	record, err := reader.ReadRecord()
+	if err != nil {
+		return nil, err
+	}
+
+	record.Content.Seek(0, io.SeekStart)
+
+	return record, nil
+}
+
+func parseMetadata(r io.Reader) *Metadata {
+	metadata := new(Metadata)
+
+	// application/warc-fields uses the same syntax as MIME headers
+	fields, err := textproto.NewReader(bufio.NewReader(io.MultiReader(r, strings.NewReader("\r\n")))).ReadMIMEHeader()
+	if err != nil && len(fields) == 0 {
+		return metadata
+	}
+
+	metadata.Via = fields.Get("via")
+	metadata.HopPath = fields.Get("hopsFromSeed")
+	metadata.DiscardReason = fields.Get("discardReason")
+
+	for _, outlink := range fields.Values("outlink") {
+		URL, hop, _ := strings.Cut(outlink, " ")
+		metadata.Outlinks = append(metadata.Outlinks, Outlink{URL: URL, Hop: hop})
+	}
+
+	return metadata
+}
+
+func parseTimestamp(timestamp string) (time.Time, error) {
+	// Partial timestamps (e.g. 2025) are padded, as in the Wayback Machine
+	if len(timestamp) < 14 && len(timestamp) >= 4 {
+		timestamp += "00000101000000"[len(timestamp)-4:]
+	}
+	return time.Parse("20060102150405", timestamp)
+}
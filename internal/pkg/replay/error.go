@@ -0,0 +1,10 @@
+package replay
+
+import "errors"
+
+var (
+	// ErrNoWARCFiles is the error returned when the job has no closed WARC file to replay
+	ErrNoWARCFiles = errors.New("no WARC files to replay")
+	// ErrNotFound is the error returned when a capture can't be found in the archive
+	ErrNotFound = errors.New("capture not found")
+)
@@ -0,0 +1,187 @@
+package replay
+
+import (
+	"html/template"
+	"net/http"
+)
+
+const (
+	// Maximum number of URLs listed on the index page
+	maxIndexURLs = 1000
+	// Maximum depth of the capture tree of a seed
+	maxTreeDepth = 16
+)
+
+// node is a URL of the capture tree of a seed
+type node struct {
+	URL       string
+	Hop       string
+	Timestamp string // Empty if the URL wasn't captured
+	Status    string
+	MIME      string
+	Discarded string
+	Children  []*node
+}
+
+type seedPage struct {
+	URL       string
+	Via       string
+	HopPath   string
+	Captures  []capture
+	Tree      *node
+	Outlinks  []*node
+	HasRecord bool
+}
+
+type capture struct {
+	Timestamp string
+	Status    string
+	MIME      string
+	Path      string
+}
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"replay": replayPath,
+}).Parse(`
+{{define "header"}}<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Zeno replay</title>
+<style>body{font-family:sans-serif;margin:2em}li{margin:.2em 0}.missing{color:#a00}.hop{font-family:monospace;color:#555}</style>
+</head><body><h1><a href="/">Zeno replay</a></h1>
+<form action="/seed" method="get"><input name="url" size="80" placeholder="https://example.com/"> <button>Lookup</button></form>
+{{end}}
+
+{{define "footer"}}</body></html>{{end}}
+
+{{define "index"}}{{template "header"}}
+<p>{{.Captures}} captures.</p>
+{{if .Seeds}}<h2>Seeds</h2>{{else}}<h2>Pages</h2>{{end}}
+<ul>{{range .URLs}}<li><a href="/seed?url={{.}}">{{.}}</a></li>{{end}}</ul>
+{{if .Truncated}}<p>Only the first {{.Limit}} URLs are listed.</p>{{end}}
+{{template "footer"}}{{end}}
+
+{{define "node"}}<li><span class="hop">{{.Hop}}</span>
+{{if .Timestamp}}<a href="{{replay .Timestamp .URL}}">{{.URL}}</a> {{.Status}} {{.MIME}}{{else}}<span class="missing">{{.URL}} (not captured)</span>{{end}}
+{{if .Discarded}}<span class="missing">discarded: {{.Discarded}}</span>{{end}}
+{{if .Children}}<ul>{{range .Children}}{{template "node" .}}{{end}}</ul>{{end}}</li>{{end}}
+
+{{define "seed"}}{{template "header"}}
+<h2>{{.URL}}</h2>
+{{if .Via}}<p>Via: <a href="/seed?url={{.Via}}">{{.Via}}</a></p>{{end}}
+{{if .HopPath}}<p>Hop path: <span class="hop">{{.HopPath}}</span></p>{{end}}
+<h3>Captures</h3>
+{{if .Captures}}<ul>{{range .Captures}}<li><a href="{{.Path}}">{{.Timestamp}}</a> {{.Status}} {{.MIME}}</li>{{end}}</ul>{{else}}<p class="missing">This URL wasn't captured.</p>{{end}}
+{{if .HasRecord}}
+<h3>Captured from this URL</h3>
+<ul>{{template "node" .Tree}}</ul>
+<h3>Outlinks</h3>
+{{if .Outlinks}}<ul>{{range .Outlinks}}<li>{{if .Timestamp}}<a href="/seed?url={{.URL}}">{{.URL}}</a>{{else}}<span class="missing">{{.URL}} (not captured)</span>{{end}}</li>{{end}}</ul>{{else}}<p>None.</p>{{end}}
+{{else}}<p>No metadata record for this URL, run the crawl with --warc-metadata-records to get the capture tree.</p>{{end}}
+{{template "footer"}}{{end}}
+
+{{define "notfound"}}{{template "header"}}
+<h2>Not found</h2><p class="missing">{{.}} wasn't captured.</p>
+{{template "footer"}}{{end}}
+`))
+
+func (s *Server) handleIndex(w http.ResponseWriter, _ *http.Request) {
+	URLs := s.archive.Seeds()
+	seeds := len(URLs) > 0
+	if !seeds {
+		URLs = s.archive.URLs("text/html", maxIndexURLs+1)
+	}
+
+	truncated := len(URLs) > maxIndexURLs
+	if truncated {
+		URLs = URLs[:maxIndexURLs]
+	}
+
+	s.render(w, http.StatusOK, "index", map[string]any{
+		"Captures":  s.archive.Len(),
+		"Seeds":     seeds,
+		"URLs":      URLs,
+		"Truncated": truncated,
+		"Limit":     maxIndexURLs,
+	})
+}
+
+func (s *Server) handleSeed(w http.ResponseWriter, r *http.Request) {
+	_, _, targetURL := parseReplayPath(r.URL.Query().Get("url"))
+
+	page := seedPage{URL: targetURL}
+
+	for _, entry := range s.archive.Captures(targetURL) {
+		page.Captures = append(page.Captures, capture{
+			Timestamp: entry.Timestamp,
+			Status:    entry.Status,
+			MIME:      entry.MIME,
+			Path:      replayPath(entry.Timestamp, entry.URL),
+		})
+	}
+
+	if metadata := s.archive.Metadata(targetURL); metadata != nil {
+		page.HasRecord = true
+		page.Via = metadata.Via
+		page.HopPath = metadata.HopPath
+		page.Tree = s.tree(targetURL, "", make(map[string]bool), 0)
+
+		for _, outlink := range metadata.Outlinks {
+			if outlink.Hop == "L" {
+				page.Outlinks = append(page.Outlinks, s.newNode(outlink.URL, outlink.Hop))
+			}
+		}
+	}
+
+	s.render(w, http.StatusOK, "seed", page)
+}
+
+// tree returns the capture tree of a URL: the assets and redirections captured from it, recursively
+func (s *Server) tree(URL, hop string, visited map[string]bool, depth int) *node {
+	n := s.newNode(URL, hop)
+
+	if visited[URL] || depth >= maxTreeDepth {
+		return n
+	}
+	visited[URL] = true
+
+	metadata := s.archive.Metadata(URL)
+	if metadata == nil {
+		return n
+	}
+
+	for _, outlink := range metadata.Outlinks {
+		if outlink.Hop == "L" {
+			continue
+		}
+		n.Children = append(n.Children, s.tree(outlink.URL, outlink.Hop, visited, depth+1))
+	}
+
+	return n
+}
+
+func (s *Server) newNode(URL, hop string) *node {
+	n := &node{URL: URL, Hop: hop}
+
+	if entry := s.archive.Closest(URL, ""); entry != nil {
+		n.Timestamp = entry.Timestamp
+		n.Status = entry.Status
+		n.MIME = entry.MIME
+	}
+
+	if metadata := s.archive.Metadata(URL); metadata != nil {
+		n.Discarded = metadata.DiscardReason
+	}
+
+	return n
+}
+
+func (s *Server) renderNotFound(w http.ResponseWriter, targetURL string) {
+	s.render(w, http.StatusNotFound, "notfound", targetURL)
+}
+
+func (s *Server) render(w http.ResponseWriter, status int, name string, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := templates.ExecuteTemplate(w, name, data); err != nil {
+		logger.Error("unable to render page", "page", name, "err", err.Error())
+	}
+}
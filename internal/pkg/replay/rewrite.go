@@ -0,0 +1,166 @@
+package replay
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	// Attributes holding a single URL
+	urlAttributes = map[string]bool{
+		"href":       true,
+		"src":        true,
+		"action":     true,
+		"poster":     true,
+		"data":       true,
+		"background": true,
+		"formaction": true,
+	}
+
+	cssURLRe    = regexp.MustCompile(`(?i)url\(\s*(['"]?)([^'")]+)(['"]?)\s*\)`)
+	cssImportRe = regexp.MustCompile(`(?i)@import\s+(['"])([^'"]+)(['"])`)
+)
+
+// rewriter rewrites the URLs of a capture so that they point to the replay server
+type rewriter struct {
+	base      *url.URL
+	timestamp string
+}
+
+func newRewriter(base *url.URL, timestamp string) *rewriter {
+	return &rewriter{
+		base:      base,
+		timestamp: timestamp,
+	}
+}
+
+// URL returns the replay URL of ref, resolved against the base URL of the capture
+func (r *rewriter) URL(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") {
+		return ref
+	}
+
+	for _, scheme := range []string{"data:", "javascript:", "mailto:", "tel:", "about:", "blob:"} {
+		if len(ref) >= len(scheme) && strings.EqualFold(ref[:len(scheme)], scheme) {
+			return ref
+		}
+	}
+
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+
+	resolved := r.base.ResolveReference(parsed)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return ref
+	}
+
+	return replayPath(r.timestamp, resolved.String())
+}
+
+// CSS rewrites url() and @import references of a stylesheet
+func (r *rewriter) CSS(css string) string {
+	css = cssURLRe.ReplaceAllStringFunc(css, func(match string) string {
+		groups := cssURLRe.FindStringSubmatch(match)
+		return "url(" + groups[1] + r.URL(groups[2]) + groups[3] + ")"
+	})
+
+	return cssImportRe.ReplaceAllStringFunc(css, func(match string) string {
+		groups := cssImportRe.FindStringSubmatch(match)
+		return "@import " + groups[1] + r.URL(groups[2]) + groups[3]
+	})
+}
+
+// HTML rewrites the URL attributes, inline styles and stylesheets of a page.
+// Untouched tokens are written as is, to stay as close as possible to the capture.
+func (r *rewriter) HTML(body io.Reader) []byte {
+	var (
+		out       bytes.Buffer
+		tokenizer = html.NewTokenizer(body)
+		inStyle   bool
+	)
+
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			return out.Bytes()
+		case html.TextToken:
+			if inStyle {
+				out.WriteString(r.CSS(string(tokenizer.Raw())))
+				continue
+			}
+			out.Write(tokenizer.Raw())
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			inStyle = token.Data == "style" && tokenType == html.StartTagToken
+
+			if !r.rewriteToken(&token) {
+				out.Write(tokenizer.Raw())
+				continue
+			}
+			out.WriteString(token.String())
+		case html.EndTagToken:
+			inStyle = false
+			out.Write(tokenizer.Raw())
+		default:
+			out.Write(tokenizer.Raw())
+		}
+	}
+}
+
+// rewriteToken rewrites the attributes of a tag and returns true if any was modified
+func (r *rewriter) rewriteToken(token *html.Token) (modified bool) {
+	for i, attr := range token.Attr {
+		var value string
+
+		switch {
+		case urlAttributes[attr.Key]:
+			// <base href> changes the base URL of every following relative URL
+			if token.Data == "base" && attr.Key == "href" {
+				if base, err := url.Parse(strings.TrimSpace(attr.Val)); err == nil {
+					r.base = r.base.ResolveReference(base)
+				}
+			}
+			value = r.URL(attr.Val)
+		case attr.Key == "srcset":
+			value = r.srcset(attr.Val)
+		case attr.Key == "style":
+			value = r.CSS(attr.Val)
+		default:
+			continue
+		}
+
+		if value != attr.Val {
+			token.Attr[i].Val = value
+			modified = true
+		}
+	}
+
+	return modified
+}
+
+// srcset rewrites the candidates of a srcset attribute, e.g. "a.png 1x, b.png 2x"
+func (r *rewriter) srcset(srcset string) string {
+	candidates := strings.Split(srcset, ",")
+	for i, candidate := range candidates {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		fields[0] = r.URL(fields[0])
+		candidates[i] = strings.Join(fields, " ")
+	}
+	return strings.Join(candidates, ", ")
+}
+
+func replayPath(timestamp, rawURL string) string {
+	return "/web/" + timestamp + "/" + rawURL
+}
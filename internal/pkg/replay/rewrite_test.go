@@ -0,0 +1,135 @@
+package replay
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newTestRewriter(t *testing.T) *rewriter {
+	t.Helper()
+
+	base, err := url.Parse("https://example.com/dir/page.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return newRewriter(base, "20250102030405")
+}
+
+func TestRewriteURL(t *testing.T) {
+	tests := []struct {
+		ref      string
+		expected string
+	}{
+		{"/a.css", "/web/20250102030405/https://example.com/a.css"},
+		{"b.png", "/web/20250102030405/https://example.com/dir/b.png"},
+		{"//cdn.example.org/c.js", "/web/20250102030405/https://cdn.example.org/c.js"},
+		{"http://other.com/?q=1", "/web/20250102030405/http://other.com/?q=1"},
+		{"#top", "#top"},
+		{"", ""},
+		{"data:image/png;base64,AAAA", "data:image/png;base64,AAAA"},
+		{"JavaScript:void(0)", "JavaScript:void(0)"},
+		{"mailto:someone@example.com", "mailto:someone@example.com"},
+		{"ftp://example.com/file", "ftp://example.com/file"},
+	}
+
+	r := newTestRewriter(t)
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			if got := r.URL(tt.ref); got != tt.expected {
+				t.Errorf("URL(%q) = %q, want %q", tt.ref, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRewriteCSS(t *testing.T) {
+	tests := []struct {
+		name     string
+		css      string
+		expected string
+	}{
+		{
+			name:     "unquoted url",
+			css:      "body{background:url(bg.png)}",
+			expected: "body{background:url(/web/20250102030405/https://example.com/dir/bg.png)}",
+		},
+		{
+			name:     "quoted url",
+			css:      `@font-face{src:url("/f.woff2")}`,
+			expected: `@font-face{src:url("/web/20250102030405/https://example.com/f.woff2")}`,
+		},
+		{
+			name:     "import",
+			css:      `@import "print.css";`,
+			expected: `@import "/web/20250102030405/https://example.com/dir/print.css";`,
+		},
+		{
+			name:     "data url",
+			css:      "a{background:url(data:image/gif;base64,R0lG)}",
+			expected: "a{background:url(data:image/gif;base64,R0lG)}",
+		},
+	}
+
+	r := newTestRewriter(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.CSS(tt.css); got != tt.expected {
+				t.Errorf("CSS() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRewriteHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		contains []string
+	}{
+		{
+			name: "links and assets",
+			html: `<a href="/next">next</a><img src="i.png" srcset="i.png 1x, /i2.png 2x">`,
+			contains: []string{
+				`href="/web/20250102030405/https://example.com/next"`,
+				`src="/web/20250102030405/https://example.com/dir/i.png"`,
+				`srcset="/web/20250102030405/https://example.com/dir/i.png 1x, /web/20250102030405/https://example.com/i2.png 2x"`,
+				">next</a>",
+			},
+		},
+		{
+			name: "base href",
+			html: `<base href="https://static.example.com/"><script src="app.js"></script>`,
+			contains: []string{
+				`src="/web/20250102030405/https://static.example.com/app.js"`,
+			},
+		},
+		{
+			name: "styles",
+			html: `<style>div{background:url(/s.png)}</style><p style="background:url(p.png)">text</p>`,
+			contains: []string{
+				"div{background:url(/web/20250102030405/https://example.com/s.png)}",
+				`style="background:url(/web/20250102030405/https://example.com/dir/p.png)"`,
+			},
+		},
+		{
+			name: "untouched markup",
+			html: `<!DOCTYPE html><!-- comment --><p class="x">&amp;</p>`,
+			contains: []string{
+				`<!DOCTYPE html><!-- comment --><p class="x">&amp;</p>`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(newTestRewriter(t).HTML(strings.NewReader(tt.html)))
+			for _, expected := range tt.contains {
+				if !strings.Contains(got, expected) {
+					t.Errorf("HTML() = %q, expected it to contain %q", got, expected)
+				}
+			}
+		})
+	}
+}
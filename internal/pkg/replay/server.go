@@ -0,0 +1,241 @@
+package replay
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/cdx"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
+)
+
+// Response headers of the capture that must not be replayed
+var strippedHeaders = []string{
+	"Content-Length",
+	"Content-Encoding",
+	"Transfer-Encoding",
+	"Set-Cookie",
+	"Content-Security-Policy",
+	"Content-Security-Policy-Report-Only",
+	"Strict-Transport-Security",
+	"X-Frame-Options",
+}
+
+var logger = log.NewFieldedLogger(&log.Fields{
+	"component": "replay",
+})
+
+// Server serves the captures of an archive:
+//
+//	/                          seeds of the job and lookup form
+//	/seed?url=<url>            captures of a URL and everything captured from it
+//	/web/<timestamp>/<url>     capture closest to timestamp, with URLs rewritten
+//	/web/<timestamp>id_/<url>  capture closest to timestamp, as archived
+type Server struct {
+	archive *Archive
+	mux     *http.ServeMux
+}
+
+// NewServer returns a replay server for the given archive
+func NewServer(archive *Archive) *Server {
+	s := &Server{
+		archive: archive,
+		mux:     http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("GET /{$}", s.handleIndex)
+	s.mux.HandleFunc("GET /seed", s.handleSeed)
+
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Replay paths contain "//" which http.ServeMux would clean and redirect
+	if strings.HasPrefix(r.URL.Path, "/web/") {
+		s.handleCapture(w, r)
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleCapture(w http.ResponseWriter, r *http.Request) {
+	timestamp, raw, targetURL := parseReplayPath(r.RequestURI)
+
+	entry := s.archive.Closest(targetURL, timestamp)
+	if entry == nil {
+		s.renderNotFound(w, targetURL)
+		return
+	}
+
+	// Redirect to the actual timestamp so that relative URLs are resolved against it
+	if entry.Timestamp != timestamp {
+		modifier := ""
+		if raw {
+			modifier = "id_"
+		}
+		// http.Redirect would clean the "//" of the archived URL
+		w.Header().Set("Location", replayPath(entry.Timestamp+modifier, entry.URL))
+		w.WriteHeader(http.StatusFound)
+		return
+	}
+
+	if err := s.serveEntry(w, entry, raw); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			s.renderNotFound(w, targetURL)
+			return
+		}
+
+		logger.Error("unable to replay capture", "url", entry.URL, "timestamp", entry.Timestamp, "err", err.Error())
+		http.Error(w, "unable to replay capture: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) serveEntry(w http.ResponseWriter, entry *cdx.Entry, raw bool) error {
+	original := s.archive.Original(entry)
+	if original == nil {
+		return ErrNotFound
+	}
+
+	record, err := s.archive.ReadRecord(original)
+	if err != nil {
+		return err
+	}
+	defer record.Content.Close()
+
+	mementoDatetime := ""
+	if date, err := time.Parse("20060102150405", entry.Timestamp); err == nil {
+		mementoDatetime = date.Format(http.TimeFormat)
+	}
+
+	base, err := url.Parse(entry.URL)
+	if err != nil {
+		return err
+	}
+	rewriter := newRewriter(base, entry.Timestamp)
+
+	// Resource records (e.g. headless screenshots) have no HTTP headers
+	if record.Header.Get("WARC-Type") == "resource" {
+		w.Header().Set("Content-Type", record.Header.Get("Content-Type"))
+		w.Header().Set("Memento-Datetime", mementoDatetime)
+		_, err := io.Copy(w, record.Content)
+		return err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(record.Content), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, encoded, err := decodeBody(resp)
+	if err != nil {
+		return err
+	}
+
+	for key, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	for _, key := range strippedHeaders {
+		w.Header().Del(key)
+	}
+
+	if location := resp.Header.Get("Location"); location != "" && !raw {
+		w.Header().Set("Location", rewriter.URL(location))
+	}
+
+	w.Header().Set("Memento-Datetime", mementoDatetime)
+
+	// Bodies we can't decode are replayed as is, without rewriting
+	if encoded {
+		w.Header().Set("Content-Encoding", resp.Header.Get("Content-Encoding"))
+		raw = true
+	}
+
+	w.WriteHeader(resp.StatusCode)
+
+	switch {
+	case raw:
+		_, err = io.Copy(w, body)
+	case original.MIME == "text/html" || original.MIME == "application/xhtml+xml":
+		_, err = w.Write(rewriter.HTML(body))
+	case original.MIME == "text/css":
+		var css []byte
+		css, err = io.ReadAll(body)
+		if err == nil {
+			_, err = io.WriteString(w, rewriter.CSS(string(css)))
+		}
+	default:
+		_, err = io.Copy(w, body)
+	}
+
+	return err
+}
+
+// decodeBody returns the decoded body of the response, encoded is true if the
+// content encoding isn't supported and the body is returned as is.
+func decodeBody(resp *http.Response) (body io.Reader, encoded bool, err error) {
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return resp.Body, false, nil
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, false, err
+		}
+		return reader, false, nil
+	case "deflate":
+		reader, err := zlib.NewReader(resp.Body)
+		if err != nil {
+			return nil, false, err
+		}
+		return reader, false, nil
+	default:
+		return resp.Body, true, nil
+	}
+}
+
+// parseReplayPath parses /web/<timestamp>[id_]/<url>, the timestamp is optional.
+// Clients and proxies tend to merge the slashes of the scheme, they are restored.
+func parseReplayPath(requestURI string) (timestamp string, raw bool, targetURL string) {
+	rest := strings.TrimPrefix(requestURI, "/web/")
+
+	if first, after, ok := strings.Cut(rest, "/"); ok && isTimestamp(strings.TrimSuffix(first, "id_")) {
+		raw = strings.HasSuffix(first, "id_")
+		timestamp = strings.TrimSuffix(first, "id_")
+		rest = after
+	}
+
+	for _, scheme := range []string{"http:", "https:"} {
+		if strings.HasPrefix(rest, scheme) && !strings.HasPrefix(rest, scheme+"//") {
+			rest = scheme + "//" + strings.TrimLeft(strings.TrimPrefix(rest, scheme), "/")
+		}
+	}
+
+	if !strings.HasPrefix(rest, "http://") && !strings.HasPrefix(rest, "https://") {
+		rest = "http://" + rest
+	}
+
+	return timestamp, raw, rest
+}
+
+func isTimestamp(value string) bool {
+	if len(value) == 0 || len(value) > 14 {
+		return false
+	}
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
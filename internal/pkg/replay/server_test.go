@@ -0,0 +1,249 @@
+package replay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	warc "github.com/internetarchive/gowarc"
+)
+
+type testRecord struct {
+	recordType string
+	uri        string
+	date       string
+	digest     string
+	content    string
+}
+
+func gzipString(t *testing.T, s string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	writer.Write([]byte(s))
+	writer.Close()
+
+	return buf.String()
+}
+
+func writeTestJob(t *testing.T, records []testRecord) string {
+	t.Helper()
+
+	warcsDir := filepath.Join(t.TempDir(), "warcs")
+	if err := os.MkdirAll(warcsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(warcsDir, "ZENO-00001.warc.gz")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	writer, err := warc.NewWriter(file, filepath.Base(path), warc.SHA1, warc.CompressionGzip, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range records {
+		record := warc.NewRecord("", false)
+		record.Header.Set("WARC-Type", r.recordType)
+		record.Header.Set("WARC-Target-URI", r.uri)
+		record.Header.Set("WARC-Date", r.date)
+		if r.digest != "" {
+			record.Header.Set("WARC-Payload-Digest", "sha1:"+r.digest)
+		}
+		if r.recordType == "metadata" {
+			record.Header.Set("Content-Type", "application/warc-fields")
+		}
+		record.Content.Write([]byte(r.content))
+
+		writer.Reset(file)
+		if _, err := writer.WriteRecord(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Files still being written must be ignored
+	if err := os.WriteFile(filepath.Join(warcsDir, "ZENO-00002.warc.gz.open"), []byte("partial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return warcsDir
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	page := `<html><head><link rel="stylesheet" href="/style.css"></head><body><a href="/about">about</a></body></html>`
+
+	warcsDir := writeTestJob(t, []testRecord{
+		{"response", "https://example.com/", "2025-01-02T03:04:05Z", "PAGE",
+			"HTTP/1.1 200 OK\r\nContent-Type: text/html; charset=utf-8\r\nContent-Encoding: gzip\r\nSet-Cookie: a=b\r\n\r\n" + gzipString(t, page)},
+		{"response", "https://example.com/style.css", "2025-01-02T03:04:06Z", "STYLE",
+			"HTTP/1.1 200 OK\r\nContent-Type: text/css\r\n\r\nbody{background:url(bg.png)}"},
+		{"response", "https://example.com/old", "2025-01-02T03:04:07Z", "",
+			"HTTP/1.1 301 Moved Permanently\r\nLocation: /\r\nContent-Length: 0\r\n\r\n"},
+		{"revisit", "https://example.com/", "2025-03-01T00:00:00Z", "PAGE",
+			"HTTP/1.1 200 OK\r\nContent-Type: text/html\r\n\r\n"},
+		{"metadata", "https://example.com/", "2025-01-02T03:04:08Z", "",
+			"outlink: https://example.com/style.css E\r\noutlink: https://example.com/about L\r\n"},
+		{"metadata", "https://example.com/style.css", "2025-01-02T03:04:08Z", "",
+			"via: https://example.com/\r\nhopsFromSeed: E\r\noutlink: https://example.com/bg.png E\r\n"},
+	})
+
+	archive, err := Load(warcsDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	server := httptest.NewServer(NewServer(archive))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestLoadNoWARCFiles(t *testing.T) {
+	if _, err := Load(t.TempDir()); err != ErrNoWARCFiles {
+		t.Fatalf("Load() error = %v, want %v", err, ErrNoWARCFiles)
+	}
+}
+
+func TestServer(t *testing.T) {
+	server := newTestServer(t)
+
+	client := server.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		headers     map[string]string
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:   "capture is decoded and rewritten",
+			path:   "/web/20250102030405/https://example.com/",
+			status: http.StatusOK,
+			headers: map[string]string{
+				"Memento-Datetime": "Thu, 02 Jan 2025 03:04:05 GMT",
+				"Set-Cookie":       "",
+				"Content-Encoding": "",
+			},
+			contains: []string{
+				`href="/web/20250102030405/https://example.com/style.css"`,
+				`href="/web/20250102030405/https://example.com/about"`,
+			},
+		},
+		{
+			name:     "raw capture",
+			path:     "/web/20250102030405id_/https://example.com/",
+			status:   http.StatusOK,
+			contains: []string{`href="/style.css"`},
+		},
+		{
+			name:     "merged scheme slashes",
+			path:     "/web/20250102030406/https:/example.com/style.css",
+			status:   http.StatusOK,
+			contains: []string{"url(/web/20250102030406/https://example.com/bg.png)"},
+		},
+		{
+			name:    "closest timestamp redirect",
+			path:    "/web/2025/https://example.com/style.css",
+			status:  http.StatusFound,
+			headers: map[string]string{"Location": "/web/20250102030406/https://example.com/style.css"},
+		},
+		{
+			name:     "revisit",
+			path:     "/web/20250301000000/https://example.com/",
+			status:   http.StatusOK,
+			headers:  map[string]string{"Memento-Datetime": "Sat, 01 Mar 2025 00:00:00 GMT"},
+			contains: []string{`href="/web/20250301000000/https://example.com/about"`},
+		},
+		{
+			name:    "redirection location is rewritten",
+			path:    "/web/20250102030407/https://example.com/old",
+			status:  http.StatusMovedPermanently,
+			headers: map[string]string{"Location": "/web/20250102030407/https://example.com/"},
+		},
+		{
+			name:     "not captured",
+			path:     "/web/20250102030405/https://example.com/missing",
+			status:   http.StatusNotFound,
+			contains: []string{"https://example.com/missing wasn't captured"},
+		},
+		{
+			name:     "index lists seeds",
+			path:     "/",
+			status:   http.StatusOK,
+			contains: []string{"<h2>Seeds</h2>", `<a href="/seed?url=https%3a%2f%2fexample.com%2f">https://example.com/</a>`},
+		},
+		{
+			name:   "seed page",
+			path:   "/seed?url=https://example.com/",
+			status: http.StatusOK,
+			contains: []string{
+				`<a href="/web/20250102030406/https://example.com/style.css">https://example.com/style.css</a>`,
+				"https://example.com/bg.png (not captured)",
+				"https://example.com/about (not captured)",
+				`<a href="/web/20250301000000/https://example.com/">20250301000000</a>`,
+			},
+			notContains: []string{"Via:"},
+		},
+		{
+			name:     "asset page has via",
+			path:     "/seed?url=https://example.com/style.css",
+			status:   http.StatusOK,
+			contains: []string{`Via: <a href="/seed?url=https%3a%2f%2fexample.com%2f">`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Get(server.URL + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d, body: %s", resp.StatusCode, tt.status, body)
+			}
+
+			for key, expected := range tt.headers {
+				if got := resp.Header.Get(key); got != expected {
+					t.Errorf("header %s = %q, want %q", key, got, expected)
+				}
+			}
+
+			for _, expected := range tt.contains {
+				if !strings.Contains(string(body), expected) {
+					t.Errorf("body doesn't contain %q: %s", expected, body)
+				}
+			}
+
+			for _, unexpected := range tt.notContains {
+				if strings.Contains(string(body), unexpected) {
+					t.Errorf("body contains %q: %s", unexpected, body)
+				}
+			}
+		})
+	}
+}
@@ -4,30 +4,46 @@ import (
 	"context"
 	"database/sql"
 	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"path"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	_ "github.com/ncruces/go-sqlite3/driver"
 
 	"github.com/google/uuid"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
 	"github.com/internetarchive/Zeno/v2/internal/pkg/source/lq/sqlc_model"
+	"github.com/internetarchive/Zeno/v2/pkg/models"
 )
 
 type lqClient struct {
 	dbWrite     *sql.DB
 	dbWriteSqlc *sqlc_model.Queries
+	scheduler   *scheduler
+	throttled   atomic.Bool // True if the last get returned nothing only because of the scheduler
 }
 
 //go:embed schema.sql
 var ddl string
 
 func initClient(job string) (*lqClient, error) {
-	dbWrite, err := sql.Open("sqlite3", "file:"+path.Join(config.Get().JobPath, "lq.db"))
+	// WAL with synchronous=FULL makes every committed status change survive a crash,
+	// the queue is the journal used to resume the crawl
+	dbWrite, err := sql.Open("sqlite3", "file:"+path.Join(config.Get().JobPath, "lq.db")+"?_pragma=journal_mode(WAL)&_pragma=synchronous(FULL)&_pragma=busy_timeout(10000)")
 	if err != nil {
 		return nil, err
 	}
 	dbWrite.SetMaxOpenConns(1)
 
+	if err := migrate(dbWrite); err != nil {
+		logger.Error("error migrating lq database schema", "err", err.Error(), "func", "lq.Init")
+		return nil, err
+	}
+
 	if _, err := dbWrite.Exec(ddl); err != nil {
 		logger.Error("error creating lq database schema", "err", err.Error(), "func", "lq.Init")
 		return nil, err
@@ -38,14 +54,171 @@ func initClient(job string) (*lqClient, error) {
 	return &lqClient{
 		dbWrite:     dbWrite,
 		dbWriteSqlc: dbWriteSqlc,
+		scheduler:   newScheduler(config.Get().LQHostMaxInFlight, config.Get().LQHostDelay),
 	}, nil
 }
 
+// migrate upgrades the queues created by previous versions: the host column is
+// added (and backfilled for the URLs still to crawl), then the request and scope
+// columns, and the deduplication index on value alone is replaced by the one on the request
+func migrate(db *sql.DB) error {
+	columns := make(map[string]bool)
+	rows, err := db.Query("SELECT name FROM pragma_table_info('urls')")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		columns[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(columns) == 0 {
+		return nil
+	}
+
+	if !columns["host"] {
+		if err := migrateHost(db); err != nil {
+			return err
+		}
+	}
+
+	for _, column := range []struct{ name, definition string }{
+		{"method", "TEXT NOT NULL DEFAULT ''"},
+		{"headers", "TEXT NOT NULL DEFAULT ''"},
+		{"body", "BLOB NOT NULL DEFAULT x''"},
+		{"scope", "TEXT NOT NULL DEFAULT ''"},
+	} {
+		if columns[column.name] {
+			continue
+		}
+		if _, err := db.Exec("ALTER TABLE urls ADD COLUMN " + column.name + " " + column.definition); err != nil {
+			return err
+		}
+	}
+
+	_, err = db.Exec("DROP INDEX IF EXISTS urls_value")
+	return err
+}
+
+// migrateHost adds the host column to queues created before the per-host scheduling
+func migrateHost(db *sql.DB) error {
+	if _, err := db.Exec("ALTER TABLE urls ADD COLUMN host TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+
+	rows, err := db.Query("SELECT id, value FROM urls WHERE status != 'DONE'")
+	if err != nil {
+		return err
+	}
+
+	hosts := make(map[string]string)
+	for rows.Next() {
+		var ID, value string
+		if err := rows.Scan(&ID, &value); err != nil {
+			rows.Close()
+			return err
+		}
+		hosts[ID] = hostOf(value)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for ID, host := range hosts {
+		if _, err := tx.Exec("UPDATE urls SET host = ? WHERE id = ?", host, ID); err != nil {
+			return err
+		}
+	}
+
+	logger.Info("lq database migrated to per-host scheduling", "urls", len(hosts))
+
+	return tx.Commit()
+}
+
+func (client *lqClient) close() error {
+	return client.dbWrite.Close()
+}
+
+// recover puts back in the queue the URLs that were claimed by a previous run
+// that didn't stop cleanly, and returns how many were recovered
+func (client *lqClient) recover(ctx context.Context) (int64, error) {
+	return client.dbWriteSqlc.ResetClaimedURLs(ctx)
+}
+
+// counts returns the number of URLs in the queue for each status
+func (client *lqClient) counts(ctx context.Context) (map[string]int64, error) {
+	rows, err := client.dbWriteSqlc.CountURLsByStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+
+	return counts, nil
+}
+
+// resume recovers the URLs claimed by a previous run and queues the input seeds,
+// seeds already in the queue (including the ones done) are not queued again.
+func (client *lqClient) resume(ctx context.Context, seeds []models.Seed) error {
+	recovered, err := client.recover(ctx)
+	if err != nil {
+		return err
+	}
+
+	URLs := make([]sqlc_model.Url, 0, len(seeds))
+	for _, seed := range seeds {
+		URL, err := seed.NewURL()
+		if err != nil {
+			return fmt.Errorf("invalid seed %q: %w", seed.URL, err)
+		}
+		URLs = append(URLs, fromURL(URL, seed.Via, seed.Scope()))
+	}
+
+	if err := client.add(ctx, URLs, false); err != nil {
+		return err
+	}
+
+	counts, err := client.counts(ctx)
+	if err != nil {
+		return err
+	}
+
+	if counts["DONE"] > 0 || recovered > 0 {
+		logger.Info("resuming crawl", "fresh", counts["FRESH"], "done", counts["DONE"], "recovered", recovered)
+	}
+
+	return nil
+}
+
 func (client *lqClient) resetURL(ctx context.Context, seed string) error {
-	return client.dbWriteSqlc.ResetURL(ctx, seed)
+	if err := client.dbWriteSqlc.ResetURL(ctx, seed); err != nil {
+		return err
+	}
+	client.scheduler.release(seed)
+	return nil
 }
 
-func (client *lqClient) get(ctx context.Context, limit int) ([]sqlc_model.Url, error) {
+// get claims up to limit URLs, taking them round-robin across the hosts that the
+// scheduler deems eligible, and by hops then timestamp within each host.
+func (client *lqClient) get(ctx context.Context, limit int) (freshUrls []sqlc_model.Url, err error) {
 	tx, err := client.dbWrite.Begin()
 	if err != nil {
 		return nil, err
@@ -54,22 +227,66 @@ func (client *lqClient) get(ctx context.Context, limit int) ([]sqlc_model.Url, e
 
 	qtx := client.dbWriteSqlc.WithTx(tx)
 
-	freshUrls, err := qtx.GetFreshURLs(ctx, int64(limit))
+	hosts, err := qtx.GetFreshHosts(ctx)
 	if err != nil {
 		return nil, err
 	}
+	hosts = client.scheduler.order(hosts)
+
+	// Claims are only recorded in the scheduler if the transaction is committed
+	defer func() {
+		if err != nil {
+			for _, record := range freshUrls {
+				client.scheduler.release(record.ID)
+			}
+			freshUrls = nil
+		}
+	}()
+
+	now := time.Now()
+	exhausted := make(map[string]bool)
+
+	for len(freshUrls) < limit {
+		claimed := 0
+
+		for _, host := range hosts {
+			if len(freshUrls) >= limit {
+				break
+			}
+
+			if exhausted[host] || !client.scheduler.eligible(host, now) {
+				continue
+			}
+
+			record, err := qtx.GetFreshURLByHost(ctx, host)
+			if errors.Is(err, sql.ErrNoRows) {
+				exhausted[host] = true
+				continue
+			} else if err != nil {
+				return freshUrls, err
+			}
+
+			if err = qtx.ClaimThisURL(ctx, record.ID); err != nil {
+				logger.Error("error claiming URL", "err", err.Error(), "func", "lq.getURLs", "id", record.ID)
+				return freshUrls, err
+			}
 
-	for _, record := range freshUrls {
-		if err = qtx.ClaimThisURL(ctx, record.ID); err != nil {
-			logger.Error("error claiming URL", "err", err.Error(), "func", "lq.getURLs", "id", record.ID)
-			return nil, err
+			client.scheduler.claim(record.ID, host, now)
+			freshUrls = append(freshUrls, record)
+			claimed++
+		}
+
+		if claimed == 0 {
+			break
 		}
 	}
 
 	if err = tx.Commit(); err != nil {
-		return nil, err
+		return freshUrls, err
 	}
 
+	client.throttled.Store(len(freshUrls) == 0 && len(exhausted) < len(hosts))
+
 	return freshUrls, nil
 }
 
@@ -86,14 +303,25 @@ func (client *lqClient) add(ctx context.Context, urls []sqlc_model.Url, bypassSe
 		if url.ID == "" {
 			url.ID = uuid.New().String()
 		}
+		if url.Host == "" {
+			url.Host = hostOf(url.Value)
+		}
+		if url.Body == nil {
+			url.Body = []byte{}
+		}
 		err = qtx.AddURL(ctx, sqlc_model.AddURLParams{
-			ID:    url.ID,
-			Value: url.Value,
-			Via:   url.Via,
-			Hops:  int64(url.Hops),
+			ID:      url.ID,
+			Value:   url.Value,
+			Via:     url.Via,
+			Hops:    int64(url.Hops),
+			Host:    url.Host,
+			Method:  url.Method,
+			Headers: url.Headers,
+			Body:    url.Body,
+			Scope:   url.Scope,
 		})
 		if err != nil {
-			if err.Error() == "sqlite3: constraint failed: UNIQUE constraint failed: urls.value" {
+			if strings.Contains(err.Error(), "UNIQUE constraint failed: urls.value") {
 				logger.Debug("URL.Value already exists in LQ", "value", url.Value, "via", url.Via)
 				continue
 			}
@@ -109,7 +337,8 @@ func (client *lqClient) add(ctx context.Context, urls []sqlc_model.Url, bypassSe
 	return nil
 }
 
-func (client *lqClient) delete(ctx context.Context, urls []sqlc_model.Url, bypassSeencheck bool) error {
+// done marks the URLs as done, they are kept so that they aren't queued again when the crawl is resumed
+func (client *lqClient) done(ctx context.Context, urls []sqlc_model.Url) error {
 	tx, err := client.dbWrite.Begin()
 	if err != nil {
 		return err
@@ -119,9 +348,9 @@ func (client *lqClient) delete(ctx context.Context, urls []sqlc_model.Url, bypas
 	qtx := client.dbWriteSqlc.WithTx(tx)
 
 	for _, url := range urls {
-		err = qtx.DeleteURL(ctx, url.ID)
+		err = qtx.DoneURL(ctx, url.ID)
 		if err != nil {
-			logger.Error("error deleting URL", "err", err.Error(), "func", "lq.Delete", "id", url.ID)
+			logger.Error("error marking URL as done", "err", err.Error(), "func", "lq.Done", "id", url.ID)
 			return err
 		}
 	}
@@ -129,5 +358,70 @@ func (client *lqClient) delete(ctx context.Context, urls []sqlc_model.Url, bypas
 	if err = tx.Commit(); err != nil {
 		return err
 	}
+
+	for _, url := range urls {
+		client.scheduler.release(url.ID)
+	}
+
 	return nil
 }
+
+// fromURL returns the queue record of a URL, its request and seed scope overrides included
+func fromURL(URL *models.URL, via string, scope *models.SeedScope) sqlc_model.Url {
+	record := sqlc_model.Url{
+		Value:  URL.Raw,
+		Via:    via,
+		Hops:   int64(URL.GetHops()),
+		Method: URL.Method,
+		Body:   URL.Payload,
+	}
+
+	if len(URL.Headers) > 0 {
+		// Map keys are sorted by encoding/json, equal headers give equal records
+		headers, err := json.Marshal(URL.Headers)
+		if err == nil {
+			record.Headers = string(headers)
+		}
+	}
+
+	if !scope.IsEmpty() {
+		if encoded, err := json.Marshal(scope); err == nil {
+			record.Scope = string(encoded)
+		}
+	}
+
+	return record
+}
+
+// toURL returns the URL of a queue record, its request overrides included
+func toURL(record *sqlc_model.Url) (*models.URL, error) {
+	URL := &models.URL{Raw: record.Value}
+	err := URL.Parse()
+	URL.SetHops(int(record.Hops))
+	URL.Method = record.Method
+	if len(record.Body) > 0 {
+		URL.Payload = record.Body
+	}
+
+	if record.Headers != "" {
+		if jsonErr := json.Unmarshal([]byte(record.Headers), &URL.Headers); jsonErr != nil && err == nil {
+			err = fmt.Errorf("invalid headers: %w", jsonErr)
+		}
+	}
+
+	return URL, err
+}
+
+// toScope returns the seed scope overrides of a queue record, nil if there are none
+func toScope(record *sqlc_model.Url) (*models.SeedScope, error) {
+	if record.Scope == "" {
+		return nil, nil
+	}
+
+	scope := new(models.SeedScope)
+	if err := json.Unmarshal([]byte(record.Scope), scope); err != nil {
+		return nil, fmt.Errorf("invalid scope: %w", err)
+	}
+
+	return scope, nil
+}
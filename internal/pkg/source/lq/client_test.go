@@ -0,0 +1,323 @@
+package lq
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/config"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/source/lq/sqlc_model"
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+)
+
+func newTestClient(t *testing.T, jobPath string) *lqClient {
+	t.Helper()
+
+	logger = log.NewFieldedLogger(&log.Fields{"component": "lq.test"})
+
+	previous := config.Get()
+	config.Set(&config.Config{JobPath: jobPath})
+	t.Cleanup(func() { config.Set(previous) })
+
+	client, err := initClient("test")
+	if err != nil {
+		t.Fatalf("initClient() error = %v", err)
+	}
+
+	return client
+}
+
+func TestResume(t *testing.T) {
+	ctx := context.Background()
+	jobPath := t.TempDir()
+	seeds := []models.Seed{{URL: "https://example.com/a"}, {URL: "https://example.com/b"}, {URL: "https://example.com/c"}}
+
+	// First run: a is done, b is claimed when the process gets killed, c was never claimed
+	client := newTestClient(t, jobPath)
+	if err := client.resume(ctx, seeds); err != nil {
+		t.Fatalf("resume() error = %v", err)
+	}
+
+	claimed, err := client.get(ctx, 2)
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	if len(claimed) != 2 {
+		t.Fatalf("expected 2 claimed URLs, got %d", len(claimed))
+	}
+
+	var done, inFlight sqlc_model.Url
+	for _, URL := range claimed {
+		switch URL.Value {
+		case seeds[0].URL:
+			done = URL
+		case seeds[1].URL:
+			inFlight = URL
+		}
+	}
+	if done.ID == "" || inFlight.ID == "" {
+		t.Fatalf("expected the first two seeds to be claimed, got %+v", claimed)
+	}
+
+	if err := client.done(ctx, []sqlc_model.Url{done}); err != nil {
+		t.Fatalf("done() error = %v", err)
+	}
+
+	// Simulate a crash: no reset of the claimed URLs
+	client.close()
+
+	// Second run with the same seeds
+	client = newTestClient(t, jobPath)
+	defer client.close()
+
+	if err := client.resume(ctx, seeds); err != nil {
+		t.Fatalf("resume() error = %v", err)
+	}
+
+	counts, err := client.counts(ctx)
+	if err != nil {
+		t.Fatalf("counts() error = %v", err)
+	}
+
+	expected := map[string]int64{"FRESH": 2, "DONE": 1}
+	for status, count := range expected {
+		if counts[status] != count {
+			t.Errorf("expected %d %s URLs, got %d (%v)", count, status, counts[status], counts)
+		}
+	}
+	if counts["CLAIMED"] != 0 {
+		t.Errorf("expected no claimed URLs, got %d", counts["CLAIMED"])
+	}
+
+	fresh, err := client.get(ctx, 10)
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, URL := range fresh {
+		got[URL.Value] = URL.ID
+	}
+
+	if len(got) != 2 || got[seeds[1].URL] != inFlight.ID || got[seeds[2].URL] == "" {
+		t.Errorf("expected the claimed and fresh seeds to be queued again, got %+v", fresh)
+	}
+}
+
+func TestResumeInvalidSeed(t *testing.T) {
+	client := newTestClient(t, t.TempDir())
+	defer client.close()
+
+	if err := client.resume(context.Background(), []models.Seed{{URL: "http://[::1"}}); err == nil {
+		t.Fatal("expected an error for an invalid seed")
+	}
+}
+
+func TestGetRoundRobin(t *testing.T) {
+	ctx := context.Background()
+
+	client := newTestClient(t, t.TempDir())
+	defer client.close()
+	client.scheduler = newScheduler(2, 0)
+
+	// a.com has many URLs queued before the others, b.com and c.com must still be served
+	var URLs []sqlc_model.Url
+	for i := range 6 {
+		URLs = append(URLs, sqlc_model.Url{Value: "https://a.com/" + string(rune('a'+i))})
+	}
+	URLs = append(URLs,
+		sqlc_model.Url{Value: "https://b.com/deep", Hops: 2},
+		sqlc_model.Url{Value: "https://b.com/", Hops: 0},
+		sqlc_model.Url{Value: "https://c.com/"},
+	)
+	if err := client.add(ctx, URLs, false); err != nil {
+		t.Fatalf("add() error = %v", err)
+	}
+
+	claimed, err := client.get(ctx, 10)
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+
+	perHost := make(map[string][]string)
+	for _, URL := range claimed {
+		perHost[URL.Host] = append(perHost[URL.Host], URL.Value)
+	}
+
+	if len(perHost["a.com"]) != 2 || len(perHost["b.com"]) != 2 || len(perHost["c.com"]) != 1 {
+		t.Fatalf("expected 2 a.com, 2 b.com and 1 c.com URLs, got %v", perHost)
+	}
+
+	if perHost["b.com"][0] != "https://b.com/" {
+		t.Errorf("expected the URLs of a host to be ordered by hops, got %v", perHost["b.com"])
+	}
+
+	// Every host is at its in-flight cap or exhausted
+	more, err := client.get(ctx, 10)
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	if len(more) != 0 || !client.throttled.Load() {
+		t.Fatalf("expected no URL and the queue to be throttled, got %d URLs", len(more))
+	}
+
+	// Finishing a.com URLs frees its slots
+	if err := client.done(ctx, claimed[:1]); err != nil {
+		t.Fatalf("done() error = %v", err)
+	}
+
+	more, err = client.get(ctx, 10)
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	if len(more) != 1 || more[0].Host != claimed[0].Host {
+		t.Fatalf("expected one more %s URL, got %+v", claimed[0].Host, more)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	jobPath := t.TempDir()
+
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(jobPath, "lq.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE urls (
+		id TEXT NOT NULL PRIMARY KEY,
+		value TEXT NOT NULL,
+		via TEXT DEFAULT '' NOT NULL,
+		hops INTEGER NOT NULL DEFAULT 0,
+		status TEXT NOT NULL DEFAULT 'FRESH' CHECK (status IN ('FRESH', 'CLAIMED', 'DONE')),
+		timestamp INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+	);
+	CREATE UNIQUE INDEX urls_value ON urls (value);
+	INSERT INTO urls (id, value) VALUES ('1', 'https://Example.com/a');`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := newTestClient(t, jobPath)
+	defer client.close()
+
+	claimed, err := client.get(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+
+	if len(claimed) != 1 || claimed[0].Host != "example.com" {
+		t.Fatalf("expected the existing URL with its host, got %+v", claimed)
+	}
+
+	// The same URL with another method is a different request
+	if err := client.add(context.Background(), []sqlc_model.Url{{Value: "https://Example.com/a", Method: "POST"}}, false); err != nil {
+		t.Fatalf("add() error = %v", err)
+	}
+
+	counts, err := client.counts(context.Background())
+	if err != nil {
+		t.Fatalf("counts() error = %v", err)
+	}
+	if counts["FRESH"] != 1 {
+		t.Errorf("expected the POST request to be queued, got %v", counts)
+	}
+}
+
+func TestRequestRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	client := newTestClient(t, t.TempDir())
+	defer client.close()
+
+	seeds := []models.Seed{
+		{URL: "https://example.com/graphql", Method: "POST", Headers: map[string]string{"content-type": "application/json"}, Body: `{"query":"{ a }"}`},
+		{URL: "https://example.com/graphql", Method: "POST", Headers: map[string]string{"content-type": "application/json"}, Body: `{"query":"{ b }"}`},
+		{URL: "https://example.com/graphql", Method: "POST", Headers: map[string]string{"Content-Type": "application/json"}, Body: `{"query":"{ a }"}`},
+		{URL: "https://example.com/graphql"},
+	}
+	if err := client.resume(ctx, seeds); err != nil {
+		t.Fatalf("resume() error = %v", err)
+	}
+
+	client.scheduler = newScheduler(0, 0)
+	claimed, err := client.get(ctx, 10)
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+
+	// The third seed is the first one once its headers are canonicalized
+	if len(claimed) != 3 {
+		t.Fatalf("expected 3 distinct requests, got %+v", claimed)
+	}
+
+	bodies := make(map[string]bool)
+	for i := range claimed {
+		URL, err := toURL(&claimed[i])
+		if err != nil {
+			t.Fatalf("toURL() error = %v", err)
+		}
+
+		if URL.GetMethod() == "GET" {
+			if URL.HasCustomRequest() {
+				t.Errorf("expected a plain GET, got %s %v %q", URL.Method, URL.Headers, URL.Payload)
+			}
+			continue
+		}
+
+		if URL.GetMethod() != "POST" || URL.Headers.Get("Content-Type") != "application/json" {
+			t.Errorf("expected a JSON POST, got %s %v", URL.GetMethod(), URL.Headers)
+		}
+		bodies[string(URL.Payload)] = true
+	}
+
+	if !bodies[`{"query":"{ a }"}`] || !bodies[`{"query":"{ b }"}`] {
+		t.Errorf("expected both bodies to be queued, got %v", bodies)
+	}
+}
+
+func TestScopeRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	client := newTestClient(t, t.TempDir())
+	defer client.close()
+
+	maxHops := 3
+	seeds := []models.Seed{
+		{URL: "https://example.com/", Via: "curator", Hops: 1, SeedScope: models.SeedScope{MaxHops: &maxHops, IncludeHosts: []string{"example.com"}}},
+		{URL: "https://example.org/"},
+	}
+	if err := client.resume(ctx, seeds); err != nil {
+		t.Fatalf("resume() error = %v", err)
+	}
+
+	claimed, err := client.get(ctx, 10)
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	if len(claimed) != 2 {
+		t.Fatalf("expected 2 claimed URLs, got %+v", claimed)
+	}
+
+	for i := range claimed {
+		scope, err := toScope(&claimed[i])
+		if err != nil {
+			t.Fatalf("toScope() error = %v", err)
+		}
+
+		switch claimed[i].Value {
+		case "https://example.com/":
+			if claimed[i].Via != "curator" || claimed[i].Hops != 1 {
+				t.Errorf("expected the seed via and hops to be kept, got %+v", claimed[i])
+			}
+			if scope.GetMaxHops(0) != maxHops || len(scope.GetIncludeHosts(nil)) != 1 {
+				t.Errorf("expected the seed scope to be kept, got %+v", scope)
+			}
+		case "https://example.org/":
+			if scope != nil {
+				t.Errorf("expected no scope, got %+v", scope)
+			}
+		}
+	}
+}
@@ -104,10 +104,15 @@ func (s *LQ) consumerFetcher(ctx context.Context, wg *sync.WaitGroup, urlBuffer
 		}
 
 		if len(URLs) == 0 {
-			emptyFetches++
 			time.Sleep(250 * time.Millisecond)
-			// Check if crawl is finished when queue is empty
-			checkIfCrawlFinished(logger, emptyFetches)
+			// URLs held back by the per-host scheduler mean the queue isn't empty
+			if s.client.throttled.Load() {
+				emptyFetches = 0
+			} else {
+				emptyFetches++
+				// Check if crawl is finished when queue is empty
+				checkIfCrawlFinished(logger, emptyFetches)
+			}
 		} else {
 			emptyFetches = 0 // Reset counter when URLs are found
 		}
@@ -133,6 +138,11 @@ func (s *LQ) consumerFetcher(ctx context.Context, wg *sync.WaitGroup, urlBuffer
 				Hops:      URLs[i].Hops,
 				Status:    URLs[i].Status,
 				Timestamp: URLs[i].Timestamp,
+				Host:      URLs[i].Host,
+				Method:    URLs[i].Method,
+				Headers:   URLs[i].Headers,
+				Body:      URLs[i].Body,
+				Scope:     URLs[i].Scope,
 			}: //Deep copy of the URL to ensure pointer alisaing does not cause issues
 			}
 		}
@@ -168,14 +178,19 @@ func (s *LQ) consumerSender(ctx context.Context, wg *sync.WaitGroup, urlBuffer <
 
 			var discard bool
 			// Process the URL and create a new Item
-			parsedURL, err := models.NewURL(URL.Value)
+			parsedURL, err := toURL(URL)
 			if err != nil {
 				discard = true
 			}
-			parsedURL.SetHops(int(URL.Hops))
-			newItem := models.NewItemWithID(URL.ID, &parsedURL, URL.Via)
+			newItem := models.NewItemWithID(URL.ID, parsedURL, URL.Via)
 			newItem.SetSource(models.ItemSourceQueue)
 
+			scope, err := toScope(URL)
+			if err != nil {
+				logger.Warn("ignoring seed scope", "url", URL.Value, "err", err.Error())
+			}
+			newItem.SetSeedScope(scope)
+
 			if discard {
 				logger.Debug("parsing failed, sending the item to finisher", "url", URL.Value)
 				s.finishCh <- newItem
@@ -87,6 +87,14 @@ func (s *LQ) finisherReceiver(ctx context.Context, wg *sync.WaitGroup, batchCh c
 	for {
 		select {
 		case <-ctx.Done():
+			// Mark the last finished URLs as done, or they would be crawled again on resume
+			if len(batch.URLs) > 0 {
+				flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				if err := s.client.done(flushCtx, batch.URLs); err != nil {
+					logger.Error("error flushing last batch to LQ", "err", err)
+				}
+				cancel()
+			}
 			logger.Debug("closed")
 			return
 		case item := <-s.finishCh:
@@ -185,7 +193,7 @@ func (s *LQ) finisherSender(ctx context.Context, batch *finishBatch, batchUUID s
 	logger.Debug("sending batch to LQ", "size", len(batch.URLs))
 
 	for {
-		err := s.client.delete(ctx, batch.URLs, false)
+		err := s.client.done(ctx, batch.URLs)
 		select {
 		case <-ctx.Done():
 			logger.Debug("closing")
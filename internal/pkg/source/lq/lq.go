@@ -48,6 +48,15 @@ func (s *LQ) Start(finishChan, produceChan chan *models.Item) error {
 			return
 		}
 
+		if err := LQclient.resume(ctx, config.Get().InputSeeds); err != nil {
+			logger.Error("error resuming crawl LQ", "err", err.Error(), "func", "lq.Start")
+			LQclient.close()
+			cancel()
+			done = true
+			startErr = err
+			return
+		}
+
 		s.wg = sync.WaitGroup{}
 		s.ctx = ctx
 		s.cancel = cancel
@@ -88,6 +97,9 @@ func (s *LQ) Stop() {
 		once = sync.Once{}
 		s.cancel()
 		s.wg.Wait()
+		if err := s.client.close(); err != nil {
+			logger.Error("error closing LQ database", "err", err)
+		}
 		logger.Info("stopped")
 	}
 }
@@ -85,11 +85,7 @@ func (s *LQ) producerReceiver(ctx context.Context, wg *sync.WaitGroup, batchCh c
 			logger.Debug("closing")
 			return
 		case item := <-s.produceCh:
-			URL := sqlc_model.Url{
-				Value: item.GetURL().Raw,
-				Via:   item.GetSeedVia(),
-				Hops:  int64(item.GetURL().GetHops()),
-			}
+			URL := fromURL(item.GetURL(), item.GetSeedVia(), item.GetSeedScope())
 			batch.URLs = append(batch.URLs, URL)
 			if len(batch.URLs) >= batchSize {
 				logger.Debug("sending batch to dispatcher", "size", len(batch.URLs))
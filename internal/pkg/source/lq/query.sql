@@ -1,8 +1,13 @@
--- name: GetFreshURLs :many
-SELECT * FROM urls
+-- name: GetFreshHosts :many
+SELECT DISTINCT host FROM urls
 WHERE status = 'FRESH'
+ORDER BY host;
+
+-- name: GetFreshURLByHost :one
+SELECT * FROM urls
+WHERE status = 'FRESH' AND host = ?
 ORDER BY hops ASC, timestamp ASC
-LIMIT ?;
+LIMIT 1;
 
 -- name: ClaimThisURL :exec
 UPDATE urls
@@ -15,8 +20,8 @@ SET status = 'FRESH', timestamp = strftime('%s', 'now')
 WHERE id = ?;
 
 -- name: AddURL :exec
-INSERT INTO urls (id, value, via, hops)
-VALUES (?, ?, ?, ?);
+INSERT INTO urls (id, value, via, hops, host, method, headers, body, scope)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
 
 -- name: DoneURL :exec
 UPDATE urls
@@ -26,3 +31,12 @@ WHERE id = ?;
 -- name: DeleteURL :exec
 DELETE FROM urls
 WHERE id = ?;
+
+-- name: ResetClaimedURLs :execrows
+UPDATE urls
+SET status = 'FRESH', timestamp = strftime('%s', 'now')
+WHERE status = 'CLAIMED';
+
+-- name: CountURLsByStatus :many
+SELECT status, COUNT(*) AS count FROM urls
+GROUP BY status;
@@ -0,0 +1,111 @@
+package lq
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// scheduler spreads the claimed URLs across hosts: hosts are served round-robin,
+// each with a cap on the number of URLs in flight and a minimum delay between
+// two claims, so that one large host can't monopolise every worker.
+type scheduler struct {
+	mu          sync.Mutex
+	maxInFlight int           // 0 means no cap
+	delay       time.Duration // Minimum time between two claims on the same host
+	hosts       map[string]*hostState
+	claimed     map[string]string // Host of each URL in flight, by ID
+	cursor      string            // Last host served, the next round starts after it
+}
+
+type hostState struct {
+	inFlight     int
+	nextEligible time.Time
+}
+
+func newScheduler(maxInFlight int, delay time.Duration) *scheduler {
+	return &scheduler{
+		maxInFlight: maxInFlight,
+		delay:       delay,
+		hosts:       make(map[string]*hostState),
+		claimed:     make(map[string]string),
+	}
+}
+
+// order returns the hosts (sorted) rotated so that the first one is the host after the cursor
+func (s *scheduler) order(hosts []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := sort.SearchStrings(hosts, s.cursor)
+	if i < len(hosts) && hosts[i] == s.cursor {
+		i++
+	}
+
+	return append(hosts[i:len(hosts):len(hosts)], hosts[:i]...)
+}
+
+// eligible returns true if a URL of host can be claimed at now
+func (s *scheduler) eligible(host string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.hosts[host]
+	if !ok {
+		return true
+	}
+
+	if s.maxInFlight > 0 && state.inFlight >= s.maxInFlight {
+		return false
+	}
+
+	return !now.Before(state.nextEligible)
+}
+
+// claim records that the URL of the given ID and host was claimed at now
+func (s *scheduler) claim(ID, host string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.hosts[host]
+	if !ok {
+		state = new(hostState)
+		s.hosts[host] = state
+	}
+
+	state.inFlight++
+	state.nextEligible = now.Add(s.delay)
+	s.claimed[ID] = host
+	s.cursor = host
+}
+
+// release records that the URL of the given ID isn't in flight anymore
+func (s *scheduler) release(ID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	host, ok := s.claimed[ID]
+	if !ok {
+		return
+	}
+	delete(s.claimed, ID)
+
+	state := s.hosts[host]
+	state.inFlight--
+
+	// Forget idle hosts to keep the map small on wide crawls
+	if state.inFlight <= 0 && !time.Now().Before(state.nextEligible) {
+		delete(s.hosts, host)
+	}
+}
+
+// hostOf returns the host used to schedule a URL, lowercased and without port
+func hostOf(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Hostname())
+}
@@ -0,0 +1,95 @@
+package lq
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSchedulerOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		cursor   string
+		hosts    []string
+		expected []string
+	}{
+		{"no cursor", "", []string{"a", "b", "c"}, []string{"a", "b", "c"}},
+		{"after cursor", "a", []string{"a", "b", "c"}, []string{"b", "c", "a"}},
+		{"last host", "c", []string{"a", "b", "c"}, []string{"a", "b", "c"}},
+		{"cursor gone", "b", []string{"a", "c", "d"}, []string{"c", "d", "a"}},
+		{"no hosts", "b", nil, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newScheduler(0, 0)
+			s.cursor = tt.cursor
+
+			got := s.order(slices.Clone(tt.hosts))
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("order() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSchedulerEligible(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		maxInFlight int
+		delay       time.Duration
+		claims      int
+		releases    int
+		at          time.Time
+		expected    bool
+	}{
+		{"unknown host", 1, time.Second, 0, 0, now, true},
+		{"in-flight cap reached", 2, 0, 2, 0, now, false},
+		{"in-flight cap freed", 2, 0, 2, 1, now, true},
+		{"no cap", 0, 0, 100, 0, now, true},
+		{"before next eligible time", 0, time.Second, 1, 1, now.Add(500 * time.Millisecond), false},
+		{"after next eligible time", 0, time.Second, 1, 1, now.Add(time.Second), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newScheduler(tt.maxInFlight, tt.delay)
+
+			IDs := make([]string, tt.claims)
+			for i := range IDs {
+				IDs[i] = strconv.Itoa(i)
+				s.claim(IDs[i], "example.com", now)
+			}
+			for _, ID := range IDs[:tt.releases] {
+				s.release(ID)
+			}
+
+			if got := s.eligible("example.com", tt.at); got != tt.expected {
+				t.Errorf("eligible() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHostOf(t *testing.T) {
+	tests := []struct {
+		rawURL   string
+		expected string
+	}{
+		{"https://Example.com:8080/a", "example.com"},
+		{"http://[::1]/", "::1"},
+		{"not a url", ""},
+		{"http://[::1", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rawURL, func(t *testing.T) {
+			if got := hostOf(tt.rawURL); got != tt.expected {
+				t.Errorf("hostOf(%q) = %q, want %q", tt.rawURL, got, tt.expected)
+			}
+		})
+	}
+}
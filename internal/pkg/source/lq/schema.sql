@@ -4,8 +4,14 @@ CREATE TABLE IF NOT EXISTS urls (
     via TEXT DEFAULT '' NOT NULL,
     hops INTEGER NOT NULL DEFAULT 0,
     status TEXT NOT NULL DEFAULT 'FRESH' CHECK (status IN ('FRESH', 'CLAIMED', 'DONE')),
-    timestamp INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+    timestamp INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    host TEXT NOT NULL DEFAULT '',
+    method TEXT NOT NULL DEFAULT '',
+    headers TEXT NOT NULL DEFAULT '',
+    body BLOB NOT NULL DEFAULT x'',
+    scope TEXT NOT NULL DEFAULT ''
 );
-CREATE UNIQUE INDEX IF NOT EXISTS urls_value ON urls (value); -- for deduplication
+CREATE UNIQUE INDEX IF NOT EXISTS urls_request ON urls (value, method, headers, body); -- for deduplication
 CREATE INDEX IF NOT EXISTS urls_status ON urls (status); -- for queueing
 CREATE INDEX IF NOT EXISTS urls_hops_timestamp ON urls (hops ASC, timestamp ASC); -- for sorting by crawl depth and time
+CREATE INDEX IF NOT EXISTS urls_status_host ON urls (status, host, hops ASC, timestamp ASC); -- for scheduling across hosts
@@ -11,4 +11,9 @@ type Url struct {
 	Hops      int64
 	Status    string
 	Timestamp int64
+	Host      string
+	Method    string
+	Headers   string
+	Body      []byte
+	Scope     string
 }
@@ -10,15 +10,20 @@ import (
 )
 
 const addURL = `-- name: AddURL :exec
-INSERT INTO urls (id, value, via, hops)
-VALUES (?, ?, ?, ?)
+INSERT INTO urls (id, value, via, hops, host, method, headers, body, scope)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 type AddURLParams struct {
-	ID    string
-	Value string
-	Via   string
-	Hops  int64
+	ID      string
+	Value   string
+	Via     string
+	Hops    int64
+	Host    string
+	Method  string
+	Headers string
+	Body    []byte
+	Scope   string
 }
 
 func (q *Queries) AddURL(ctx context.Context, arg AddURLParams) error {
@@ -27,6 +32,11 @@ func (q *Queries) AddURL(ctx context.Context, arg AddURLParams) error {
 		arg.Value,
 		arg.Via,
 		arg.Hops,
+		arg.Host,
+		arg.Method,
+		arg.Headers,
+		arg.Body,
+		arg.Scope,
 	)
 	return err
 }
@@ -42,6 +52,39 @@ func (q *Queries) ClaimThisURL(ctx context.Context, id string) error {
 	return err
 }
 
+const countURLsByStatus = `-- name: CountURLsByStatus :many
+SELECT status, COUNT(*) AS count FROM urls
+GROUP BY status
+`
+
+type CountURLsByStatusRow struct {
+	Status string
+	Count  int64
+}
+
+func (q *Queries) CountURLsByStatus(ctx context.Context) ([]CountURLsByStatusRow, error) {
+	rows, err := q.db.QueryContext(ctx, countURLsByStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountURLsByStatusRow
+	for rows.Next() {
+		var i CountURLsByStatusRow
+		if err := rows.Scan(&i.Status, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const deleteURL = `-- name: DeleteURL :exec
 DELETE FROM urls
 WHERE id = ?
@@ -63,32 +106,25 @@ func (q *Queries) DoneURL(ctx context.Context, id string) error {
 	return err
 }
 
-const getFreshURLs = `-- name: GetFreshURLs :many
-SELECT id, value, via, hops, status, timestamp FROM urls
+const getFreshHosts = `-- name: GetFreshHosts :many
+SELECT DISTINCT host FROM urls
 WHERE status = 'FRESH'
-LIMIT ?
+ORDER BY host
 `
 
-func (q *Queries) GetFreshURLs(ctx context.Context, limit int64) ([]Url, error) {
-	rows, err := q.db.QueryContext(ctx, getFreshURLs, limit)
+func (q *Queries) GetFreshHosts(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getFreshHosts)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Url
+	var items []string
 	for rows.Next() {
-		var i Url
-		if err := rows.Scan(
-			&i.ID,
-			&i.Value,
-			&i.Via,
-			&i.Hops,
-			&i.Status,
-			&i.Timestamp,
-		); err != nil {
+		var host string
+		if err := rows.Scan(&host); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, host)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -99,6 +135,46 @@ func (q *Queries) GetFreshURLs(ctx context.Context, limit int64) ([]Url, error)
 	return items, nil
 }
 
+const getFreshURLByHost = `-- name: GetFreshURLByHost :one
+SELECT id, value, via, hops, status, timestamp, host, method, headers, body, scope FROM urls
+WHERE status = 'FRESH' AND host = ?
+ORDER BY hops ASC, timestamp ASC
+LIMIT 1
+`
+
+func (q *Queries) GetFreshURLByHost(ctx context.Context, host string) (Url, error) {
+	row := q.db.QueryRowContext(ctx, getFreshURLByHost, host)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.Value,
+		&i.Via,
+		&i.Hops,
+		&i.Status,
+		&i.Timestamp,
+		&i.Host,
+		&i.Method,
+		&i.Headers,
+		&i.Body,
+		&i.Scope,
+	)
+	return i, err
+}
+
+const resetClaimedURLs = `-- name: ResetClaimedURLs :execrows
+UPDATE urls
+SET status = 'FRESH', timestamp = strftime('%s', 'now')
+WHERE status = 'CLAIMED'
+`
+
+func (q *Queries) ResetClaimedURLs(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, resetClaimedURLs)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const resetURL = `-- name: ResetURL :exec
 UPDATE urls
 SET status = 'FRESH', timestamp = strftime('%s', 'now')
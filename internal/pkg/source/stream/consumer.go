@@ -0,0 +1,78 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/reactor"
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+)
+
+// Maximum size of a single NDJSON line
+const maxLineSize = 1024 * 1024
+
+// consumer reads seeds from upstream and inserts them in the reactor
+func (s *Stream) consumer(ctx context.Context, reader io.Reader) {
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "stream.consumer",
+	})
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var message Message
+		if err := json.Unmarshal(line, &message); err != nil {
+			logger.Warn("invalid message received", "err", err.Error(), "line", string(line))
+			continue
+		}
+
+		if message.Type != "" && message.Type != MessageSeed {
+			logger.Warn("unexpected message type received", "type", message.Type)
+			continue
+		}
+
+		parsedURL, err := models.NewURL(message.URL)
+		parsedURL.SetHops(message.Hops)
+		newItem := models.NewItem(&parsedURL, message.Via)
+		newItem.SetSource(models.ItemSourceQueue)
+
+		if err != nil {
+			logger.Debug("parsing failed, sending the item to finisher", "url", message.URL)
+			newItem.SetStatus(models.ItemFailed)
+			select {
+			case <-ctx.Done():
+				return
+			case s.finishCh <- newItem:
+			}
+			continue
+		}
+
+		logger.Debug("sending new item to reactor", "item", newItem.GetShortID())
+
+		err = reactor.ReceiveInsert(newItem)
+		if err != nil {
+			if err == reactor.ErrReactorFrozen || err == reactor.ErrReactorShuttingDown {
+				<-ctx.Done()
+				logger.Debug("closed while sending to frozen reactor")
+				return
+			}
+			panic(err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		logger.Warn("error while reading from upstream", "err", err.Error())
+	}
+
+	logger.Debug("closed")
+}
@@ -0,0 +1,10 @@
+package stream
+
+import "errors"
+
+var (
+	// ErrStreamAlreadyInitialized is the error returned when the stream source is already initialized
+	ErrStreamAlreadyInitialized = errors.New("stream source already initialized")
+	// ErrUnsupportedAddress is the error returned when the stream address scheme is not supported
+	ErrUnsupportedAddress = errors.New("unsupported stream address, must be tcp://host:port, unix:///path or fifo:///path")
+)
@@ -0,0 +1,52 @@
+package stream
+
+import (
+	"context"
+	"io"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
+)
+
+// notifier sends finished, produced and reset notifications to upstream.
+// A notification that couldn't be written is kept and sent again on the next session.
+func (s *Stream) notifier(ctx context.Context, writer io.Writer, consumerDone <-chan struct{}) {
+	logger := log.NewFieldedLogger(&log.Fields{
+		"component": "stream.notifier",
+	})
+
+	for {
+		if s.pending != nil {
+			data, err := s.pending.encode()
+			if err != nil {
+				logger.Error("unable to encode notification", "err", err.Error(), "url", s.pending.URL)
+				s.pending = nil
+				continue
+			}
+
+			if _, err := writer.Write(data); err != nil {
+				if ctx.Err() == nil {
+					logger.Warn("unable to send notification to upstream", "err", err.Error())
+				}
+				return
+			}
+
+			s.pending = nil
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Debug("closed")
+			return
+		case <-consumerDone:
+			return
+		case item := <-s.finishCh:
+			logger.Debug("received finished item", "item", item.GetShortID())
+			s.pending = newMessage(MessageFinished, item)
+		case item := <-s.produceCh:
+			s.pending = newMessage(MessageProduced, item)
+		case message := <-s.resetCh:
+			// A nil message is only used by Stop to wait for the previous ones to be written
+			s.pending = message
+		}
+	}
+}
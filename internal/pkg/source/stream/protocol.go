@@ -0,0 +1,62 @@
+package stream
+
+import (
+	"encoding/json"
+
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+)
+
+const (
+	// MessageSeed is the (optional) type of the messages received from upstream
+	MessageSeed = "seed"
+	// MessageFinished is sent when a seed and all its assets have been crawled
+	MessageFinished = "finished"
+	// MessageProduced is sent for every outlink discovered, upstream decides whether to send it back as a seed
+	MessageProduced = "produced"
+	// MessageReset is sent on stop for the seeds that were still being crawled
+	MessageReset = "reset"
+)
+
+// Message is a line of the newline-delimited JSON protocol spoken by the stream source.
+// Upstream only has to send the url, via and hops fields, Zeno sends back messages
+// of type finished, produced and reset with the same fields. The via field is opaque
+// to Zeno and can be used by upstream to correlate notifications with its own records.
+type Message struct {
+	Type   string `json:"type,omitempty"`
+	ID     string `json:"id,omitempty"`
+	URL    string `json:"url"`
+	Via    string `json:"via,omitempty"`
+	Hops   int    `json:"hops"`
+	Status string `json:"status,omitempty"`
+}
+
+func newMessage(messageType string, item *models.Item) *Message {
+	message := &Message{
+		Type: messageType,
+		ID:   item.GetID(),
+		Via:  item.GetSeedVia(),
+	}
+
+	// If preprocessing failed, there will be nil values here
+	if item.GetURL() != nil {
+		message.URL = item.GetURL().Raw
+		message.Hops = item.GetURL().GetHops()
+	}
+
+	if messageType == MessageFinished {
+		message.Status = "completed"
+		if item.GetStatus() == models.ItemFailed {
+			message.Status = "failed"
+		}
+	}
+
+	return message
+}
+
+func (m *Message) encode() ([]byte, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
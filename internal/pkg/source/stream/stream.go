@@ -0,0 +1,179 @@
+// Package stream provides a source that consumes seeds as newline-delimited JSON from a
+// TCP or Unix socket, or a named pipe, and sends finish notifications back on the same protocol.
+// It lets upstream discovery systems push URLs into a long-running Zeno without Crawl HQ,
+// other systems (e.g. Kafka) can be bridged by piping their output into the socket or FIFO.
+package stream
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/reactor"
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+)
+
+const (
+	minReconnectBackoff = time.Second
+	maxReconnectBackoff = 30 * time.Second
+	// Time given to send the reset notifications on stop
+	resetTimeout = 10 * time.Second
+)
+
+type Stream struct {
+	wg         sync.WaitGroup
+	ctx        context.Context
+	cancel     context.CancelFunc
+	finishCh   chan *models.Item
+	produceCh  chan *models.Item
+	resetCh    chan *Message
+	pending    *Message // Message that failed to be sent, sent again after reconnecting
+	Address    string
+	FinishFIFO string
+}
+
+var (
+	once   sync.Once
+	logger *log.FieldedLogger
+)
+
+// New returns a stream source reading from address (tcp://host:port, unix:///path or fifo:///path).
+// finishFIFO is only used with fifo:// addresses, it is the named pipe where notifications are written.
+func New(address, finishFIFO string) *Stream {
+	return &Stream{
+		Address:    address,
+		FinishFIFO: finishFIFO,
+	}
+}
+
+// Start connects to upstream and starts consuming seeds, reconnecting if the connection is lost.
+func (s *Stream) Start(finishChan, produceChan chan *models.Item) error {
+	var done bool
+
+	logger = log.NewFieldedLogger(&log.Fields{
+		"component": "stream",
+	})
+
+	if _, _, err := parseAddress(s.Address); err != nil {
+		logger.Error("invalid stream address", "address", s.Address, "err", err.Error(), "func", "stream.Start")
+		return err
+	}
+
+	once.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.wg = sync.WaitGroup{}
+		s.ctx = ctx
+		s.cancel = cancel
+		s.finishCh = finishChan
+		s.produceCh = produceChan
+		s.resetCh = make(chan *Message)
+
+		s.wg.Add(1)
+		go s.run()
+
+		logger.Info("started", "address", s.Address)
+
+		done = true
+	})
+
+	if !done {
+		return ErrStreamAlreadyInitialized
+	}
+
+	return nil
+}
+
+// Stop notifies upstream of the seeds that are still in the reactor, then closes the connection.
+// Finisher must be stopped first and Reactor must be frozen before stopping the stream source.
+func (s *Stream) Stop() {
+	if s != nil && s.cancel != nil {
+		deadline := time.After(resetTimeout)
+
+	resetLoop:
+		for _, seed := range reactor.GetStateTableItems() {
+			select {
+			case s.resetCh <- newMessage(MessageReset, seed):
+				logger.Debug("reset seed", "id", seed.GetID())
+			case <-deadline:
+				logger.Warn("timeout while sending reset notifications to upstream")
+				break resetLoop
+			}
+		}
+
+		// Wait for the last notification to be written
+		select {
+		case s.resetCh <- nil:
+		case <-deadline:
+		}
+
+		s.cancel()
+		s.wg.Wait()
+		once = sync.Once{}
+		logger.Info("stopped")
+	}
+}
+
+// Name returns the name of the source, used for logging and identification.
+func (s *Stream) Name() string {
+	return "stream"
+}
+
+// run keeps a session opened with upstream until the source is stopped
+func (s *Stream) run() {
+	defer s.wg.Done()
+
+	backoff := minReconnectBackoff
+
+	for {
+		sess, err := s.open()
+		if err != nil {
+			logger.Error("unable to connect to upstream", "address", s.Address, "err", err.Error(), "retry_in", backoff)
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, maxReconnectBackoff)
+			continue
+		}
+
+		backoff = minReconnectBackoff
+		logger.Info("connected to upstream", "address", s.Address)
+
+		s.serve(sess)
+
+		select {
+		case <-s.ctx.Done():
+			logger.Debug("closed")
+			return
+		default:
+			logger.Warn("disconnected from upstream, reconnecting", "address", s.Address)
+		}
+	}
+}
+
+// serve runs the consumer and the notifier on the session until one of them fails or the source is stopped
+func (s *Stream) serve(sess *session) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+
+	// Closing the session unblocks any pending read or write
+	wg.Go(func() {
+		<-ctx.Done()
+		sess.close()
+	})
+
+	consumerDone := make(chan struct{})
+	wg.Go(func() {
+		defer close(consumerDone)
+		s.consumer(ctx, sess.reader)
+	})
+
+	s.notifier(ctx, sess.writer, consumerDone)
+
+	cancel()
+	wg.Wait()
+}
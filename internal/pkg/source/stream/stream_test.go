@@ -0,0 +1,191 @@
+package stream
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/reactor"
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		network string
+		target  string
+		wantErr bool
+	}{
+		{"127.0.0.1:7000", "tcp", "127.0.0.1:7000", false},
+		{"tcp://127.0.0.1:7000", "tcp", "127.0.0.1:7000", false},
+		{"unix:///tmp/zeno.sock", "unix", "/tmp/zeno.sock", false},
+		{"fifo:///tmp/zeno.fifo", "fifo", "/tmp/zeno.fifo", false},
+		{"http://127.0.0.1:7000", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			network, target, err := parseAddress(tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if network != tt.network || target != tt.target {
+				t.Errorf("parseAddress() = %q, %q, want %q, %q", network, target, tt.network, tt.target)
+			}
+		})
+	}
+}
+
+func readMessage(t *testing.T, reader *bufio.Reader) *Message {
+	t.Helper()
+
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("unable to read notification: %v", err)
+	}
+
+	var message Message
+	if err := json.Unmarshal(line, &message); err != nil {
+		t.Fatalf("invalid notification %q: %v", line, err)
+	}
+
+	return &message
+}
+
+func TestStreamTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	defer listener.Close()
+
+	reactorOutput := make(chan *models.Item, 10)
+	if err := reactor.Start(10, reactorOutput); err != nil {
+		t.Fatalf("unable to start reactor: %v", err)
+	}
+	defer reactor.Stop()
+
+	finishCh := make(chan *models.Item)
+	produceCh := make(chan *models.Item)
+
+	source := New("tcp://"+listener.Addr().String(), "")
+	if err := source.Start(finishCh, produceCh); err != nil {
+		t.Fatalf("unable to start stream source: %v", err)
+	}
+
+	listener.(*net.TCPListener).SetDeadline(time.Now().Add(5 * time.Second))
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("stream source did not connect: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	conn.Write([]byte(`{"url": "https://example.com/first", "via": "upstream-1", "hops": 2}` + "\n"))
+	conn.Write([]byte("not json\n\n"))
+	conn.Write([]byte(`{"url": "::invalid::", "via": "upstream-2"}` + "\n"))
+	conn.Write([]byte(`{"type": "seed", "url": "https://example.com/second", "via": "upstream-3"}` + "\n"))
+
+	// The invalid URL is sent back as failed without reaching the reactor
+	message := readMessage(t, reader)
+	if message.Type != MessageFinished || message.Status != "failed" || message.Via != "upstream-2" {
+		t.Errorf("unexpected notification for invalid URL: %+v", message)
+	}
+
+	var first, second *models.Item
+	for _, expected := range []string{"https://example.com/first", "https://example.com/second"} {
+		select {
+		case item := <-reactorOutput:
+			if item.GetURL().Raw != expected {
+				t.Fatalf("expected %s from reactor, got %s", expected, item.GetURL().Raw)
+			}
+			if first == nil {
+				first = item
+			} else {
+				second = item
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for %s", expected)
+		}
+	}
+
+	if first.GetURL().GetHops() != 2 || first.GetSeedVia() != "upstream-1" {
+		t.Errorf("unexpected seed: hops %d, via %q", first.GetURL().GetHops(), first.GetSeedVia())
+	}
+
+	// Finished seed
+	first.SetStatus(models.ItemCompleted)
+	if err := reactor.MarkAsFinished(first); err != nil {
+		t.Fatalf("unable to mark seed as finished: %v", err)
+	}
+	finishCh <- first
+
+	message = readMessage(t, reader)
+	if message.Type != MessageFinished || message.Status != "completed" || message.ID != first.GetID() || message.Hops != 2 {
+		t.Errorf("unexpected finished notification: %+v", message)
+	}
+
+	// Produced outlink
+	outlinkURL, _ := models.NewURL("https://example.com/outlink")
+	outlinkURL.SetHops(3)
+	produceCh <- models.NewItem(&outlinkURL, "upstream-1")
+
+	message = readMessage(t, reader)
+	if message.Type != MessageProduced || message.URL != "https://example.com/outlink" || message.Hops != 3 || message.Via != "upstream-1" {
+		t.Errorf("unexpected produced notification: %+v", message)
+	}
+
+	// The second seed is still in the reactor on stop
+	reactor.Freeze()
+	source.Stop()
+
+	message = readMessage(t, reader)
+	if message.Type != MessageReset || message.ID != second.GetID() || message.URL != "https://example.com/second" {
+		t.Errorf("unexpected reset notification: %+v", message)
+	}
+}
+
+func TestStreamReconnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	defer listener.Close()
+	listener.(*net.TCPListener).SetDeadline(time.Now().Add(10 * time.Second))
+
+	if err := reactor.Start(10, make(chan *models.Item, 10)); err != nil {
+		t.Fatalf("unable to start reactor: %v", err)
+	}
+	defer reactor.Stop()
+
+	finishCh := make(chan *models.Item)
+	produceCh := make(chan *models.Item)
+
+	source := New(listener.Addr().String(), "")
+	if err := source.Start(finishCh, produceCh); err != nil {
+		t.Fatalf("unable to start stream source: %v", err)
+	}
+	defer source.Stop()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("stream source did not connect: %v", err)
+	}
+	conn.Close()
+
+	conn, err = listener.Accept()
+	if err != nil {
+		t.Fatalf("stream source did not reconnect: %v", err)
+	}
+	defer conn.Close()
+}
+
+func TestStartInvalidAddress(t *testing.T) {
+	source := New("http://127.0.0.1:7000", "")
+	if err := source.Start(nil, nil); err == nil {
+		t.Fatal("expected an error for an unsupported address")
+	}
+}
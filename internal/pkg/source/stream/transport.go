@@ -0,0 +1,84 @@
+package stream
+
+import (
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// session is an opened connection to upstream, reader and writer can be the same connection
+type session struct {
+	reader    io.Reader
+	writer    io.Writer
+	closers   []io.Closer
+	closeOnce sync.Once
+}
+
+func (s *session) close() {
+	s.closeOnce.Do(func() {
+		for _, closer := range s.closers {
+			closer.Close()
+		}
+	})
+}
+
+// open connects to the configured address:
+//   - tcp://host:port and unix:///path dial a socket, notifications are sent back on the same socket
+//   - fifo:///path opens a named pipe for reading, notifications are written to the finish FIFO if set
+func (s *Stream) open() (*session, error) {
+	network, address, err := parseAddress(s.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	if network != "fifo" {
+		conn, err := net.Dial(network, address)
+		if err != nil {
+			return nil, err
+		}
+		return &session{reader: conn, writer: conn, closers: []io.Closer{conn}}, nil
+	}
+
+	// Opening the FIFOs read-write never blocks waiting for the other end and
+	// means we never get EOF when upstream closes its end.
+	in, err := os.OpenFile(address, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	sess := &session{reader: in, writer: io.Discard, closers: []io.Closer{in}}
+	if s.FinishFIFO != "" {
+		out, err := os.OpenFile(s.FinishFIFO, os.O_RDWR, 0)
+		if err != nil {
+			in.Close()
+			return nil, err
+		}
+		sess.writer = out
+		sess.closers = append(sess.closers, out)
+	}
+
+	return sess, nil
+}
+
+func parseAddress(address string) (network, target string, err error) {
+	if !strings.Contains(address, "://") {
+		return "tcp", address, nil
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch u.Scheme {
+	case "tcp":
+		return "tcp", u.Host, nil
+	case "unix", "fifo":
+		return u.Scheme, u.Host + u.Path, nil
+	default:
+		return "", "", ErrUnsupportedAddress
+	}
+}
@@ -0,0 +1,152 @@
+package stats
+
+import (
+	"container/heap"
+	"sync"
+)
+
+const (
+	// Host label of the hosts that aren't in the top N
+	otherHost = "other"
+	// Minimum number of hosts whose request count is tracked to find the top N
+	minTrackedHosts = 1000
+)
+
+// hostTracker counts the requests made to each host to find the top N hosts by
+// request count, that get their own series in the per-host metrics. The other
+// hosts are aggregated under the "other" host.
+//
+// The top hosts and the other tracked hosts are kept in two min-heaps by request
+// count, so that the least requested top host and the host to evict are found
+// without scanning them under the lock on every response.
+type hostTracker struct {
+	mu         sync.Mutex
+	n          int                     // Number of hosts that get their own series
+	counts     map[string]*trackedHost // Tracked hosts, least frequent ones outside of the top are evicted first
+	maxTracked int                     // Maximum number of hosts in counts
+	top        hostHeap                // Hosts that currently have their own series
+	rest       hostHeap                // Tracked hosts that aren't in the top
+	onEvict    func(host string)       // Called when a host leaves the top N
+}
+
+type trackedHost struct {
+	host  string
+	count int64
+	index int // Index in the heap holding the host
+	inTop bool
+}
+
+// hostHeap is a min-heap of hosts by request count
+type hostHeap []*trackedHost
+
+func (h hostHeap) Len() int           { return len(h) }
+func (h hostHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+
+func (h hostHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *hostHeap) Push(x any) {
+	th := x.(*trackedHost)
+	th.index = len(*h)
+	*h = append(*h, th)
+}
+
+func (h *hostHeap) Pop() any {
+	old := *h
+	th := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return th
+}
+
+func newHostTracker(n int, onEvict func(host string)) *hostTracker {
+	return &hostTracker{
+		n:          n,
+		counts:     make(map[string]*trackedHost),
+		maxTracked: max(10*n, minTrackedHosts),
+		onEvict:    onEvict,
+	}
+}
+
+// observe counts a request to host and returns its label
+func (t *hostTracker) observe(host string) string {
+	if t == nil || t.n <= 0 {
+		return otherHost
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	th, ok := t.counts[host]
+	if !ok {
+		if len(t.counts) >= t.maxTracked {
+			t.evictLFU()
+		}
+		th = &trackedHost{host: host}
+		t.counts[host] = th
+		heap.Push(&t.rest, th)
+	}
+
+	th.count++
+	if th.inTop {
+		heap.Fix(&t.top, th.index)
+		return host
+	}
+	heap.Fix(&t.rest, th.index)
+
+	if t.top.Len() < t.n {
+		t.promote(th)
+		return host
+	}
+
+	// The host takes the place of the least requested top host once it went over it
+	if th.count <= t.top[0].count {
+		return otherHost
+	}
+
+	demoted := heap.Pop(&t.top).(*trackedHost)
+	demoted.inTop = false
+	heap.Push(&t.rest, demoted)
+	t.promote(th)
+
+	if t.onEvict != nil {
+		t.onEvict(demoted.host)
+	}
+
+	return host
+}
+
+// promote moves a tracked host to the top
+func (t *hostTracker) promote(th *trackedHost) {
+	heap.Remove(&t.rest, th.index)
+	th.inTop = true
+	heap.Push(&t.top, th)
+}
+
+// label returns the label of host without counting a request
+func (t *hostTracker) label(host string) string {
+	if t == nil || t.n <= 0 {
+		return otherHost
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if th, ok := t.counts[host]; ok && th.inTop {
+		return host
+	}
+	return otherHost
+}
+
+// evictLFU stops tracking the least requested host that isn't in the top N
+func (t *hostTracker) evictLFU() {
+	if t.rest.Len() == 0 {
+		return
+	}
+
+	lfu := heap.Pop(&t.rest).(*trackedHost)
+	delete(t.counts, lfu.host)
+}
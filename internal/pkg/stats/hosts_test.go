@@ -0,0 +1,122 @@
+package stats
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestHostTracker_Observe(t *testing.T) {
+	var evicted []string
+	tracker := newHostTracker(2, func(host string) { evicted = append(evicted, host) })
+
+	steps := []struct {
+		host     string
+		expected string
+	}{
+		{"a.com", "a.com"},
+		{"a.com", "a.com"},
+		{"b.com", "b.com"},
+		{"c.com", otherHost}, // Top is full, c.com is on par with b.com
+		{"c.com", "c.com"},   // c.com went over b.com and takes its place
+		{"b.com", otherHost}, // b.com is on par with the top hosts
+	}
+
+	for i, step := range steps {
+		if got := tracker.observe(step.host); got != step.expected {
+			t.Fatalf("step %d: observe(%s) = %s, want %s", i, step.host, got, step.expected)
+		}
+	}
+
+	if !slices.Equal(evicted, []string{"b.com"}) {
+		t.Errorf("expected b.com to be evicted, got %v", evicted)
+	}
+
+	if got := tracker.label("c.com"); got != "c.com" {
+		t.Errorf("label(c.com) = %s, want c.com", got)
+	}
+	if got := tracker.label("b.com"); got != otherHost {
+		t.Errorf("label(b.com) = %s, want %s", got, otherHost)
+	}
+}
+
+func TestHostTracker_Disabled(t *testing.T) {
+	var tracker *hostTracker
+	if got := tracker.observe("a.com"); got != otherHost {
+		t.Errorf("observe() on a nil tracker = %s, want %s", got, otherHost)
+	}
+	if got := tracker.label("a.com"); got != otherHost {
+		t.Errorf("label() on a nil tracker = %s, want %s", got, otherHost)
+	}
+}
+
+func TestHostTracker_EvictLFU(t *testing.T) {
+	tracker := newHostTracker(1, nil)
+	tracker.maxTracked = 3
+
+	tracker.observe("top.com")
+	tracker.observe("top.com")
+	tracker.observe("a.com")
+	tracker.observe("b.com")
+	tracker.observe("b.com")
+	tracker.observe("c.com") // Evicts a.com, the least requested host outside of the top
+
+	if _, ok := tracker.counts["a.com"]; ok {
+		t.Error("expected a.com to be evicted")
+	}
+	if len(tracker.counts) != 3 {
+		t.Errorf("expected 3 tracked hosts, got %d", len(tracker.counts))
+	}
+	if _, ok := tracker.counts["top.com"]; !ok {
+		t.Error("expected top.com to never be evicted")
+	}
+}
+
+func TestHostTracker_ManyHosts(t *testing.T) {
+	tracker := newHostTracker(3, nil)
+	tracker.maxTracked = 20
+
+	// host-i.com gets i requests, interleaved with hosts seen once that get evicted
+	for round := range 50 {
+		for i := 1; i <= 10; i++ {
+			if round < i {
+				tracker.observe(fmt.Sprintf("host-%d.com", i))
+			}
+		}
+		tracker.observe(fmt.Sprintf("once-%d.com", round))
+	}
+
+	for i := 1; i <= 10; i++ {
+		host := fmt.Sprintf("host-%d.com", i)
+		want := otherHost
+		if i >= 8 {
+			want = host
+		}
+		if got := tracker.label(host); got != want {
+			t.Errorf("label(%s) = %s, want %s", host, got, want)
+		}
+	}
+
+	if len(tracker.counts) > tracker.maxTracked || tracker.top.Len()+tracker.rest.Len() != len(tracker.counts) {
+		t.Errorf("inconsistent tracker: %d tracked, %d top, %d rest", len(tracker.counts), tracker.top.Len(), tracker.rest.Len())
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		expected   string
+	}{
+		{200, "2xx"},
+		{301, "3xx"},
+		{404, "4xx"},
+		{503, "5xx"},
+		{0, "failed"},
+	}
+
+	for _, tt := range tests {
+		if got := statusClass(tt.statusCode); got != tt.expected {
+			t.Errorf("statusClass(%d) = %s, want %s", tt.statusCode, got, tt.expected)
+		}
+	}
+}
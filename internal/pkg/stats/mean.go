@@ -23,6 +23,10 @@ func (m *mean) get() float64 {
 	return float64(sum) / float64(count)
 }
 
+func (m *mean) totals() (count, sum uint64) {
+	return atomic.LoadUint64(&m.count), atomic.LoadUint64(&m.sum)
+}
+
 func (m *mean) reset() {
 	atomic.StoreUint64(&m.count, 0)
 	atomic.StoreUint64(&m.sum, 0)
@@ -1,6 +1,7 @@
 package stats
 
 import (
+	"strconv"
 	"strings"
 	"time"
 
@@ -183,6 +184,12 @@ func MeanHTTPRespTimeAdd(value time.Duration) {
 	}
 }
 
+// MeanHTTPRespTimeTotals returns the number of HTTP responses and the sum of their response times in ms,
+// the mean response time over a period is computed from the difference of the totals.
+func MeanHTTPRespTimeTotals() (count, sumMs uint64) {
+	return globalStats.MeanHTTPResponseTime.totals()
+}
+
 //////////////////////////
 // MeanProcessBodyTime  //
 //////////////////////////
@@ -276,6 +283,87 @@ func SeencheckFailuresIncr() {
 	}
 }
 
+// RobotsDisallowedIncr increments the RobotsDisallowed counter by 1.
+func RobotsDisallowedIncr() {
+	globalStats.RobotsDisallowed.Add(1)
+
+	if globalPromStats != nil {
+		globalPromStats.robotsDisallowed.WithLabelValues(config.Get().JobPrometheus, hostname, version).Inc()
+	}
+}
+
+// SeedBudgetOverrunsIncr increments the SeedBudgetOverruns counter by 1, reason is the budget that was exceeded.
+func SeedBudgetOverrunsIncr(reason string) {
+	globalStats.SeedBudgetOverruns.Add(1)
+
+	if globalPromStats != nil {
+		globalPromStats.seedBudgetOverruns.WithLabelValues(config.Get().JobPrometheus, hostname, version, reason).Inc()
+	}
+}
+
+// DiscardedResponsesIncr increments the DiscardedResponses counter by 1, reason is the reason code of the discard hook.
+func DiscardedResponsesIncr(reason string) {
+	globalStats.DiscardedResponses.Add(1)
+
+	if globalPromStats != nil {
+		globalPromStats.discardedResponses.WithLabelValues(config.Get().JobPrometheus, hostname, version, reason).Inc()
+	}
+}
+
+// QuarantinedResponsesIncr increments the QuarantinedResponses counter by 1, reason is the reason of the classifier.
+func QuarantinedResponsesIncr(reason string) {
+	globalStats.QuarantinedResponses.Add(1)
+
+	if globalPromStats != nil {
+		globalPromStats.quarantinedResponses.WithLabelValues(config.Get().JobPrometheus, hostname, version, reason).Inc()
+	}
+}
+
+// RateLimiterPenaltiesIncr increments the RateLimiterPenalties counter by 1 for the given crawled host.
+func RateLimiterPenaltiesIncr(host string) {
+	globalStats.RateLimiterPenalties.Add(1)
+
+	if globalPromStats != nil {
+		globalPromStats.rateLimiterPenalties.WithLabelValues(config.Get().JobPrometheus, hostname, version, globalHosts.label(host)).Inc()
+	}
+}
+
+//////////////////////////
+//    Host metrics      //
+//////////////////////////
+
+// HostResponseObserve records a request to the given crawled host, statusCode is 0 if
+// the request failed without a response. It is a no-op unless --prometheus-host-metrics is set.
+func HostResponseObserve(host string, statusCode int, respTime time.Duration) {
+	if globalPromStats == nil || globalHosts == nil {
+		return
+	}
+
+	label := globalHosts.observe(host)
+	globalPromStats.hostRequests.WithLabelValues(config.Get().JobPrometheus, hostname, version, label, statusClass(statusCode)).Inc()
+	if statusCode != 0 {
+		globalPromStats.hostRespTime.WithLabelValues(config.Get().JobPrometheus, hostname, version, label).Observe(respTime.Seconds())
+	}
+}
+
+// HostResponseBytesObserve records the size of a response body of the given crawled host.
+// It is a no-op unless --prometheus-host-metrics is set.
+func HostResponseBytesObserve(host string, size int64) {
+	if globalPromStats == nil || globalHosts == nil {
+		return
+	}
+
+	globalPromStats.hostRespBytes.WithLabelValues(config.Get().JobPrometheus, hostname, version, globalHosts.label(host)).Observe(float64(size))
+}
+
+// statusClass returns the class of an HTTP status code, such as 2xx, or "failed" if there is no status code
+func statusClass(statusCode int) string {
+	if statusCode < 100 || statusCode > 599 {
+		return "failed"
+	}
+	return strconv.Itoa(statusCode/100) + "xx"
+}
+
 // CFMitigatedIncr increments the CFMitigated counter by 1.
 func CFMitigatedIncr() {
 	globalStats.cfMitigated.Add(1)
@@ -28,6 +28,16 @@ type prometheusStats struct {
 	cfMitigated            *prometheus.GaugeVec
 	akamaiMitigated        *prometheus.GaugeVec
 	seencheckFailures      *prometheus.CounterVec
+	robotsDisallowed       *prometheus.CounterVec
+	seedBudgetOverruns     *prometheus.CounterVec
+	discardedResponses     *prometheus.CounterVec
+	quarantinedResponses   *prometheus.CounterVec
+	rateLimiterPenalties   *prometheus.CounterVec
+
+	// Per-host metrics, only registered if --prometheus-host-metrics is set
+	hostRequests  *prometheus.CounterVec
+	hostRespBytes *prometheus.HistogramVec
+	hostRespTime  *prometheus.HistogramVec
 
 	// Dedup WARC metrics
 	dataTotalBytes               *prometheus.GaugeVec
@@ -145,6 +155,38 @@ func newPrometheusStats() *prometheusStats {
 			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "seencheck_failures", Help: "Total number of seencheck failures"},
 			[]string{"project", "hostname", "version"},
 		),
+		robotsDisallowed: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "robots_disallowed", Help: "Total number of URLs disallowed by robots.txt"},
+			[]string{"project", "hostname", "version"},
+		),
+		seedBudgetOverruns: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "seed_budget_overruns", Help: "Total number of seeds that went over a crawl budget"},
+			[]string{"project", "hostname", "version", "reason"},
+		),
+		discardedResponses: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "discarded_responses", Help: "Total number of responses discarded by the discard hooks, by reason code"},
+			[]string{"project", "hostname", "version", "reason"},
+		),
+		quarantinedResponses: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "quarantined_responses", Help: "Total number of archived responses classified as soft-404 or login pages, by reason"},
+			[]string{"project", "hostname", "version", "reason"},
+		),
+		rateLimiterPenalties: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "rate_limiter_penalties", Help: "Total number of rate limiter penalties, by crawled host (top N hosts only)"},
+			[]string{"project", "hostname", "version", "host"},
+		),
+		hostRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: config.Get().PrometheusPrefix + "host_requests", Help: "Total number of requests, by crawled host (top N hosts only) and status class"},
+			[]string{"project", "hostname", "version", "host", "status_class"},
+		),
+		hostRespBytes: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{Name: config.Get().PrometheusPrefix + "host_response_bytes", Help: "Size in bytes of the response bodies, by crawled host (top N hosts only)", Buckets: prometheus.ExponentialBuckets(1024, 4, 10)},
+			[]string{"project", "hostname", "version", "host"},
+		),
+		hostRespTime: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{Name: config.Get().PrometheusPrefix + "host_response_time_seconds", Help: "Time in seconds to get the response headers, by crawled host (top N hosts only)", Buckets: prometheus.ExponentialBucketsRange(0.02, 10, 20)},
+			[]string{"project", "hostname", "version", "host"},
+		),
 	}
 }
 
@@ -167,6 +209,18 @@ func registerPrometheusMetrics() {
 	prometheus.MustRegister(globalPromStats.cfMitigated)
 	prometheus.MustRegister(globalPromStats.akamaiMitigated)
 	prometheus.MustRegister(globalPromStats.seencheckFailures)
+	prometheus.MustRegister(globalPromStats.robotsDisallowed)
+	prometheus.MustRegister(globalPromStats.seedBudgetOverruns)
+	prometheus.MustRegister(globalPromStats.discardedResponses)
+	prometheus.MustRegister(globalPromStats.quarantinedResponses)
+	prometheus.MustRegister(globalPromStats.rateLimiterPenalties)
+
+	// Register per-host metrics
+	if config.Get().PrometheusHostMetrics > 0 {
+		prometheus.MustRegister(globalPromStats.hostRequests)
+		prometheus.MustRegister(globalPromStats.hostRespBytes)
+		prometheus.MustRegister(globalPromStats.hostRespTime)
+	}
 
 	// Register dedup WARC metrics
 	prometheus.MustRegister(globalPromStats.dataTotalBytes)
@@ -181,3 +235,12 @@ func registerPrometheusMetrics() {
 func PrometheusHandler() http.Handler {
 	return promhttp.Handler()
 }
+
+// deleteHostSeries deletes the per-host series of a host that left the top N
+func (p *prometheusStats) deleteHostSeries(host string) {
+	labels := prometheus.Labels{"host": host}
+	p.rateLimiterPenalties.DeletePartialMatch(labels)
+	p.hostRequests.DeletePartialMatch(labels)
+	p.hostRespBytes.DeletePartialMatch(labels)
+	p.hostRespTime.DeletePartialMatch(labels)
+}
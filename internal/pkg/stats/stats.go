@@ -20,6 +20,11 @@ type stats struct {
 	Paused                 atomic.Bool
 	HTTPReturnCodes        *rateBucket
 	SeencheckFailures      atomic.Int64
+	RobotsDisallowed       atomic.Int64
+	SeedBudgetOverruns     atomic.Int64
+	DiscardedResponses     atomic.Int64
+	QuarantinedResponses   atomic.Int64
+	RateLimiterPenalties   atomic.Int64
 	MeanHTTPResponseTime   *mean // in ms
 	MeanProcessBodyTime    *mean // in ms
 	MeanWaitOnFeedbackTime *mean // in ms
@@ -39,6 +44,7 @@ type stats struct {
 var (
 	globalStats     *stats
 	globalPromStats *prometheusStats
+	globalHosts     *hostTracker
 	doOnce          sync.Once
 	hostname        string
 	version         string
@@ -76,6 +82,10 @@ func Init() error {
 			version = versionStruct.Version
 
 			registerPrometheusMetrics()
+
+			if config.Get().PrometheusHostMetrics > 0 {
+				globalHosts = newHostTracker(config.Get().PrometheusHostMetrics, globalPromStats.deleteHostSeries)
+			}
 		}
 
 		done = true
@@ -124,6 +134,11 @@ func GetMapTUI() map[string]any {
 		"CF Challenge pages seen":     globalStats.cfMitigated.Load(),
 		"Akamai Challenge pages seen": globalStats.akamaiMitigated.Load(),
 		"Seencheck failures":          globalStats.SeencheckFailures.Load(),
+		"Robots.txt disallowed URLs":  globalStats.RobotsDisallowed.Load(),
+		"Seed budget overruns":        globalStats.SeedBudgetOverruns.Load(),
+		"Discarded responses":         globalStats.DiscardedResponses.Load(),
+		"Quarantined responses":       globalStats.QuarantinedResponses.Load(),
+		"Rate limiter penalties":      globalStats.RateLimiterPenalties.Load(),
 		"Mean HTTP response time":     globalStats.MeanHTTPResponseTime.get(),
 		"Mean wait on feedback time":  globalStats.MeanWaitOnFeedbackTime.get(),
 		"Mean process body time":      globalStats.MeanProcessBodyTime.get(),
@@ -0,0 +1,8 @@
+package tracing
+
+import "errors"
+
+var (
+	// ErrTracingAlreadyInitialized is the error returned when tracing is already initialized
+	ErrTracingAlreadyInitialized = errors.New("tracing already initialized")
+)
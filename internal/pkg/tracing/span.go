@@ -0,0 +1,172 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Names of the pipeline stages
+const (
+	StageReactor       = "reactor"
+	StagePreprocessor  = "preprocessor"
+	StageArchiver      = "archiver"
+	StagePostprocessor = "postprocessor"
+	StageFinisher      = "finisher"
+)
+
+// seedSpans holds the spans of a seed being crawled
+type seedSpans struct {
+	mu        sync.Mutex
+	ctx       context.Context // Context of the seed span
+	span      trace.Span
+	stage     string // Name of the current stage, empty if none
+	stageCtx  context.Context
+	stageSpan trace.Span
+}
+
+// Spans of the seeds being crawled, by seed ID
+var seeds sync.Map
+
+// StartSeed starts the span of a seed entering the reactor
+func StartSeed(seed *models.Item) {
+	if !Enabled() {
+		return
+	}
+
+	ctx, span := tracer.Start(context.Background(), "seed", trace.WithAttributes(itemAttributes(seed)...))
+	seeds.Store(seed.GetID(), &seedSpans{ctx: ctx, span: span})
+}
+
+// EndSeed ends the span of a finished seed, along with its current stage span
+func EndSeed(seed *models.Item) {
+	if !Enabled() {
+		return
+	}
+
+	if value, ok := seeds.LoadAndDelete(seed.GetID()); ok {
+		value.(*seedSpans).end()
+	}
+}
+
+// StartStage starts the span of the seed going through the given stage,
+// the span of the stage it was going through before is ended
+func StartStage(seed *models.Item, stage string) {
+	spans := getSeedSpans(seed)
+	if spans == nil {
+		return
+	}
+
+	spans.mu.Lock()
+	defer spans.mu.Unlock()
+
+	if spans.stageSpan != nil {
+		spans.stageSpan.End()
+	}
+
+	spans.stage = stage
+	spans.stageCtx, spans.stageSpan = tracer.Start(spans.ctx, stage, trace.WithAttributes(
+		attribute.Int64("zeno.seed.depth", seed.GetDepth()),
+		attribute.String("zeno.seed.status", seed.GetStatus().String()),
+	))
+}
+
+// EndStage ends the span of the seed going through the given stage,
+// it is a no-op if the seed already went to another stage
+func EndStage(seed *models.Item, stage string) {
+	spans := getSeedSpans(seed)
+	if spans == nil {
+		return
+	}
+
+	spans.mu.Lock()
+	defer spans.mu.Unlock()
+
+	if spans.stage != stage || spans.stageSpan == nil {
+		return
+	}
+
+	spans.stageSpan.End()
+	spans.stage, spans.stageCtx, spans.stageSpan = "", nil, nil
+}
+
+// StartItemSpan starts a span for the item, child of the span of the stage its seed
+// is going through, or of the seed span if none. The span is non-recording if the
+// seed isn't traced.
+func StartItemSpan(item *models.Item, name string) (context.Context, trace.Span) {
+	ctx := context.Background()
+
+	// Don't start orphan root spans for the items of the seeds that aren't traced
+	spans := getSeedSpans(item.GetSeed())
+	if spans == nil {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+
+	spans.mu.Lock()
+	parent := spans.ctx
+	if spans.stageCtx != nil {
+		parent = spans.stageCtx
+	}
+	spans.mu.Unlock()
+
+	return tracer.Start(parent, name, trace.WithAttributes(itemAttributes(item)...))
+}
+
+// StartSpan starts a child span of the span in ctx
+func StartSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return tracer.Start(ctx, name)
+}
+
+// SetError records err on the span and marks it as failed
+func SetError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+func getSeedSpans(seed *models.Item) *seedSpans {
+	if !Enabled() || seed == nil {
+		return nil
+	}
+
+	value, ok := seeds.Load(seed.GetID())
+	if !ok {
+		return nil
+	}
+	return value.(*seedSpans)
+}
+
+func (s *seedSpans) end() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stageSpan != nil {
+		s.stageSpan.End()
+		s.stage, s.stageCtx, s.stageSpan = "", nil, nil
+	}
+	s.span.End()
+}
+
+// endAllSeeds ends the spans of the seeds that are still being crawled
+func endAllSeeds() {
+	seeds.Range(func(key, value any) bool {
+		value.(*seedSpans).end()
+		seeds.Delete(key)
+		return true
+	})
+}
+
+func itemAttributes(item *models.Item) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("zeno.item.id", item.GetID()),
+		attribute.String("zeno.seed.id", item.GetSeed().GetID()),
+		attribute.String("url.full", item.GetURL().String()),
+		attribute.Int("zeno.item.hops", item.GetURL().GetHops()),
+	}
+}
@@ -0,0 +1,221 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/internetarchive/Zeno/v2/pkg/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestItem(t *testing.T, raw string) *models.Item {
+	t.Helper()
+
+	URL, err := models.NewURL(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return models.NewItem(&URL, "")
+}
+
+func startTestTracing(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	if err := start(sdktrace.WithSyncer(exporter)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(Stop)
+
+	return exporter
+}
+
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("span %q not found in %d spans", name, len(spans))
+	return tracetest.SpanStub{}
+}
+
+func getAttribute(span tracetest.SpanStub, key string) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if string(kv.Key) == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestSeedStages(t *testing.T) {
+	exporter := startTestTracing(t)
+
+	seed := newTestItem(t, "https://example.com/")
+	child := newTestItem(t, "https://example.com/style.css")
+	if err := seed.AddChild(child, models.ItemGotChildren); err != nil {
+		t.Fatal(err)
+	}
+
+	StartSeed(seed)
+	for _, stage := range []string{StageReactor, StagePreprocessor, StageArchiver} {
+		StartStage(seed, stage)
+	}
+
+	ctx, fetchSpan := StartItemSpan(child, "archiver.fetch")
+	_, feedbackSpan := StartSpan(ctx, "archiver.warc_feedback")
+	feedbackSpan.End()
+	fetchSpan.End()
+
+	EndStage(seed, StageArchiver)
+	StartStage(seed, StagePostprocessor)
+	StartStage(seed, StageFinisher)
+	EndSeed(seed)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 8 {
+		t.Fatalf("expected 8 spans, got %d", len(spans))
+	}
+
+	seedSpan := findSpan(t, spans, "seed")
+	if seedSpan.Parent.IsValid() {
+		t.Error("expected the seed span to be a root span")
+	}
+
+	for _, stage := range []string{StageReactor, StagePreprocessor, StageArchiver, StagePostprocessor, StageFinisher} {
+		stageSpan := findSpan(t, spans, stage)
+		if stageSpan.Parent.SpanID() != seedSpan.SpanContext.SpanID() {
+			t.Errorf("expected the %s span to be a child of the seed span", stage)
+		}
+		if stageSpan.EndTime.IsZero() {
+			t.Errorf("expected the %s span to be ended", stage)
+		}
+	}
+
+	archiverSpan := findSpan(t, spans, StageArchiver)
+	fetch := findSpan(t, spans, "archiver.fetch")
+	if fetch.Parent.SpanID() != archiverSpan.SpanContext.SpanID() {
+		t.Error("expected the fetch span to be a child of the archiver span")
+	}
+
+	feedback := findSpan(t, spans, "archiver.warc_feedback")
+	if feedback.Parent.SpanID() != fetch.SpanContext.SpanID() {
+		t.Error("expected the WARC feedback span to be a child of the fetch span")
+	}
+
+	for key, want := range map[string]string{
+		"zeno.item.id": child.GetID(),
+		"zeno.seed.id": seed.GetID(),
+		"url.full":     "https://example.com/style.css",
+	} {
+		got, ok := getAttribute(fetch, key)
+		if !ok || got.AsString() != want {
+			t.Errorf("expected attribute %s to be %q, got %q", key, want, got.AsString())
+		}
+	}
+}
+
+func TestEndStage(t *testing.T) {
+	tests := []struct {
+		name       string
+		started    string
+		ended      string
+		wantActive bool
+	}{
+		{name: "same stage", started: StageArchiver, ended: StageArchiver, wantActive: false},
+		{name: "other stage", started: StageFinisher, ended: StageArchiver, wantActive: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := startTestTracing(t)
+
+			seed := newTestItem(t, "https://example.com/")
+			StartSeed(seed)
+			StartStage(seed, tt.started)
+			EndStage(seed, tt.ended)
+
+			ended := len(exporter.GetSpans()) == 1
+			if ended == tt.wantActive {
+				t.Errorf("expected the %s span to be active: %v", tt.started, tt.wantActive)
+			}
+
+			EndSeed(seed)
+			if len(exporter.GetSpans()) != 2 {
+				t.Errorf("expected 2 spans after the seed ended, got %d", len(exporter.GetSpans()))
+			}
+		})
+	}
+}
+
+func TestUntracedSeed(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+	}{
+		{name: "tracing disabled", enabled: false},
+		{name: "seed not started", enabled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var exporter *tracetest.InMemoryExporter
+			if tt.enabled {
+				exporter = startTestTracing(t)
+			}
+
+			seed := newTestItem(t, "https://example.com/")
+			StartStage(seed, StageArchiver)
+
+			ctx, span := StartItemSpan(seed, "archiver.fetch")
+			if span.IsRecording() {
+				t.Error("expected the item span of an untraced seed to be non-recording")
+			}
+			_, child := StartSpan(ctx, "archiver.warc_feedback")
+			if child.IsRecording() {
+				t.Error("expected the child span of a non-recording span to be non-recording")
+			}
+			span.End()
+			EndSeed(seed)
+
+			if exporter != nil && len(exporter.GetSpans()) != 0 {
+				t.Errorf("expected no spans, got %d", len(exporter.GetSpans()))
+			}
+		})
+	}
+}
+
+func TestSetError(t *testing.T) {
+	exporter := startTestTracing(t)
+
+	_, span := tracer.Start(context.Background(), "test")
+	SetError(span, errors.New("connection refused"))
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Status.Code != codes.Error || spans[0].Status.Description != "connection refused" {
+		t.Errorf("unexpected span status %+v", spans[0].Status)
+	}
+	if len(spans[0].Events) != 1 {
+		t.Errorf("expected the error to be recorded as an event, got %d events", len(spans[0].Events))
+	}
+}
+
+func TestStartTwice(t *testing.T) {
+	startTestTracing(t)
+
+	exporter := tracetest.NewInMemoryExporter()
+	if err := start(sdktrace.WithSyncer(exporter)); !errors.Is(err, ErrTracingAlreadyInitialized) {
+		t.Errorf("expected ErrTracingAlreadyInitialized, got %v", err)
+	}
+}
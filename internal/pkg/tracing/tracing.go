@@ -0,0 +1,97 @@
+// Package tracing traces the seeds through the pipeline with OpenTelemetry
+// (--otel-endpoint). A seed gets a span from the moment it enters the reactor
+// to the moment it is finished, with a child span per stage it goes through
+// (reactor, preprocessor, archiver, postprocessor, finisher). The archiver adds
+// child spans for the fetch of each of the seed's items and for the WARC
+// writing feedback waits. Spans are exported with OTLP over HTTP.
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"github.com/internetarchive/Zeno/v2/internal/pkg/log"
+	"github.com/internetarchive/Zeno/v2/internal/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const tracerName = "github.com/internetarchive/Zeno"
+
+var (
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer = noop.NewTracerProvider().Tracer(tracerName)
+	once     sync.Once
+	logger   = log.NewFieldedLogger(&log.Fields{
+		"component": "tracing",
+	})
+)
+
+// Start exports the spans to the OTLP/HTTP endpoint (e.g. http://localhost:4318),
+// sampleRatio is the ratio of seeds that are traced
+func Start(endpoint string, sampleRatio float64) error {
+	exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return err
+	}
+
+	err = start(sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))))
+	if err != nil {
+		return err
+	}
+
+	logger.Info("started", "endpoint", endpoint, "sample_ratio", sampleRatio)
+	return nil
+}
+
+// start starts tracing with a tracer provider built with the options, which register
+// its exporter. It is used by Start and by the tests, with an in-memory exporter
+// registered with sdktrace.WithSyncer.
+func start(opts ...sdktrace.TracerProviderOption) error {
+	var done bool
+
+	once.Do(func() {
+		res := resource.NewSchemaless(
+			attribute.String("service.name", "zeno"),
+			attribute.String("service.version", utils.GetVersion().Version),
+		)
+
+		provider = sdktrace.NewTracerProvider(append([]sdktrace.TracerProviderOption{sdktrace.WithResource(res)}, opts...)...)
+		tracer = provider.Tracer(tracerName)
+		done = true
+	})
+
+	if !done {
+		return ErrTracingAlreadyInitialized
+	}
+
+	return nil
+}
+
+// Stop ends the spans of the seeds that aren't finished and flushes the spans to the exporter
+func Stop() {
+	if provider == nil {
+		return
+	}
+
+	endAllSeeds()
+
+	if err := provider.Shutdown(context.Background()); err != nil {
+		logger.Error("unable to flush spans", "err", err.Error())
+	}
+
+	provider = nil
+	tracer = noop.NewTracerProvider().Tracer(tracerName)
+	once = sync.Once{}
+	logger.Info("stopped")
+}
+
+// Enabled returns true if tracing is started
+func Enabled() bool {
+	return provider != nil
+}
@@ -16,12 +16,19 @@ type Item struct {
 	id         string       // ID is the unique identifier of the item
 	url        *URL         // URL is a struct that contains the URL, the parsed URL, and its hop
 	seedVia    string       // SeedVia is the source of the seed (shoud not be used for non-seeds)
+	seedScope  *SeedScope   // SeedScope holds the scope overrides of the seed (shoud not be used for non-seeds)
 	status     ItemState    // Status is the state of the item in the pipeline
 	source     ItemSource   // Source is the source of the item in the pipeline
 	childrenMu sync.RWMutex // Mutex to protect the children slice
 	children   []*Item      // Children is a slice of Item created from this item
 	parent     *Item        // Parent is the parent of the item (will be nil if the item is a seed)
 	err        error        // Error message of the seed
+	discard    string       // Discard is the reason why the response was discarded, if it was
+	quarantine string       // Quarantine is the reason why the archived response was classified as a soft-404 or login page, if it was
+	proxy      string       // Proxy is the name of the proxy of the pool the item was fetched through, empty if it wasn't
+	recordID   string       // RecordID is the WARC-Record-ID of the response or revisit record of the item, empty if unknown
+	robots     bool         // Robots is true if robots.txt disallows the item, archived anyway with --robots-policy archive-only
+	budget     budget       // Budget tracks what the seed tree consumed (shoud not be used for non-seeds)
 }
 
 // ItemState qualifies the state of a item in the pipeline
@@ -164,6 +171,14 @@ func (i *Item) GetURL() *URL { return i.url }
 // GetSeedVia returns the seedVia of the item
 func (i *Item) GetSeedVia() string { return i.seedVia }
 
+// GetSeedScope returns the scope overrides of the item's seed, nil if there are none
+func (i *Item) GetSeedScope() *SeedScope {
+	if seed := i.GetSeed(); seed != nil {
+		return seed.seedScope
+	}
+	return nil
+}
+
 // GetStatus returns the status of the item
 func (i *Item) GetStatus() ItemState { return i.status }
 
@@ -230,6 +245,21 @@ func (i *Item) GetParent() *Item { return i.parent }
 // GetError returns the error of the item
 func (i *Item) GetError() error { return i.err }
 
+// GetDiscardReason returns the reason why the response of the item was discarded, empty if it wasn't
+func (i *Item) GetDiscardReason() string { return i.discard }
+
+// GetQuarantineReason returns the reason why the archived response of the item was quarantined, empty if it wasn't
+func (i *Item) GetQuarantineReason() string { return i.quarantine }
+
+// GetProxy returns the name of the proxy of the pool the item was fetched through, empty if it wasn't
+func (i *Item) GetProxy() string { return i.proxy }
+
+// GetRecordID returns the WARC-Record-ID of the response or revisit record of the item, empty if it is unknown
+func (i *Item) GetRecordID() string { return i.recordID }
+
+// IsRobotsDisallowed returns true if robots.txt disallows the item
+func (i *Item) IsRobotsDisallowed() bool { return i.robots }
+
 // GetSeed returns the seed (topmost parent) of any given item
 func (i *Item) GetSeed() *Item {
 	if i.IsSeed() {
@@ -286,9 +316,33 @@ func (i *Item) SetSource(source ItemSource) error {
 	return nil
 }
 
+// SetSeedScope sets the scope overrides of the item, it is only kept on seeds
+func (i *Item) SetSeedScope(scope *SeedScope) error {
+	if !i.IsSeed() {
+		return ErrNotASeed
+	}
+	i.seedScope = scope
+	return nil
+}
+
 // SetError sets the error of the item
 func (i *Item) SetError(err error) { i.err = err }
 
+// SetDiscardReason sets the reason why the response of the item was discarded
+func (i *Item) SetDiscardReason(reason string) { i.discard = reason }
+
+// SetQuarantineReason sets the reason why the archived response of the item was quarantined
+func (i *Item) SetQuarantineReason(reason string) { i.quarantine = reason }
+
+// SetProxy sets the name of the proxy of the pool the item was fetched through
+func (i *Item) SetProxy(name string) { i.proxy = name }
+
+// SetRecordID sets the WARC-Record-ID of the response or revisit record of the item
+func (i *Item) SetRecordID(ID string) { i.recordID = ID }
+
+// SetRobotsDisallowed tags the item as disallowed by robots.txt
+func (i *Item) SetRobotsDisallowed(disallowed bool) { i.robots = disallowed }
+
 // NewItem creates a new item with the given ID, URL and seedVia
 func NewItemWithID(ID string, URL *URL, seedVia string) *Item {
 	if ID == "" || URL == nil {
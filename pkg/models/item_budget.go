@@ -0,0 +1,103 @@
+package models
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// budget tracks what a seed tree consumed, it is only kept on seeds
+type budget struct {
+	mu       sync.Mutex
+	start    time.Time    // When the seed was first preprocessed
+	exceeded string       // The first budget the seed went over
+	bytes    atomic.Int64 // Bytes downloaded by the seed and its children
+}
+
+// StartBudget starts the clock of the seed's budget, it is a no-op if already started
+func (i *Item) StartBudget() {
+	seed := i.GetSeed()
+	if seed == nil {
+		return
+	}
+
+	seed.budget.mu.Lock()
+	defer seed.budget.mu.Unlock()
+	if seed.budget.start.IsZero() {
+		seed.budget.start = time.Now()
+	}
+}
+
+// GetBudgetElapsed returns the time elapsed since the seed's budget was started, 0 if it wasn't
+func (i *Item) GetBudgetElapsed() time.Duration {
+	seed := i.GetSeed()
+	if seed == nil {
+		return 0
+	}
+
+	seed.budget.mu.Lock()
+	defer seed.budget.mu.Unlock()
+	if seed.budget.start.IsZero() {
+		return 0
+	}
+	return time.Since(seed.budget.start)
+}
+
+// AddBudgetBytes adds n downloaded bytes to the seed's budget
+func (i *Item) AddBudgetBytes(n int64) {
+	if seed := i.GetSeed(); seed != nil {
+		seed.budget.bytes.Add(n)
+	}
+}
+
+// GetBudgetBytes returns the bytes downloaded by the seed and its children
+func (i *Item) GetBudgetBytes() int64 {
+	if seed := i.GetSeed(); seed != nil {
+		return seed.budget.bytes.Load()
+	}
+	return 0
+}
+
+// GetBudgetItems returns the number of items in the seed tree, the seed excluded
+func (i *Item) GetBudgetItems() (count int) {
+	seed := i.GetSeed()
+	if seed == nil {
+		return 0
+	}
+
+	seed.Traverse(func(item *Item) {
+		if item != seed {
+			count++
+		}
+	})
+	return count
+}
+
+// SetBudgetExceeded records that the seed went over the given budget,
+// it returns true only the first time a budget is exceeded for the seed
+func (i *Item) SetBudgetExceeded(reason string) bool {
+	seed := i.GetSeed()
+	if seed == nil {
+		return false
+	}
+
+	seed.budget.mu.Lock()
+	defer seed.budget.mu.Unlock()
+	if seed.budget.exceeded != "" {
+		return false
+	}
+	seed.budget.exceeded = reason
+	return true
+}
+
+// GetBudgetExceeded returns the first budget the seed went over, empty if none
+func (i *Item) GetBudgetExceeded() string {
+	seed := i.GetSeed()
+	if seed == nil {
+		return ""
+	}
+
+	seed.budget.mu.Lock()
+	defer seed.budget.mu.Unlock()
+	return seed.budget.exceeded
+}
@@ -0,0 +1,38 @@
+package models
+
+import "testing"
+
+func TestItem_Budget(t *testing.T) {
+	seed := createTestItem("seed", nil)
+	child := createTestItem("child", seed)
+	grandchild := createTestItem("grandchild", child)
+
+	if elapsed := grandchild.GetBudgetElapsed(); elapsed != 0 {
+		t.Errorf("expected no elapsed time before the budget is started, got %s", elapsed)
+	}
+
+	grandchild.StartBudget()
+	if seed.budget.start.IsZero() {
+		t.Error("expected the budget to be started on the seed")
+	}
+
+	child.AddBudgetBytes(10)
+	grandchild.AddBudgetBytes(5)
+	if bytes := seed.GetBudgetBytes(); bytes != 15 {
+		t.Errorf("expected 15 bytes, got %d", bytes)
+	}
+
+	if items := grandchild.GetBudgetItems(); items != 2 {
+		t.Errorf("expected 2 items, got %d", items)
+	}
+
+	if !grandchild.SetBudgetExceeded("max-size") {
+		t.Error("expected the first overrun to be reported")
+	}
+	if child.SetBudgetExceeded("max-items") {
+		t.Error("expected the second overrun to not be reported")
+	}
+	if reason := seed.GetBudgetExceeded(); reason != "max-size" {
+		t.Errorf("expected the first overrun to be kept, got %q", reason)
+	}
+}
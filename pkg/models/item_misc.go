@@ -1,6 +1,31 @@
 package models
 
-import "strings"
+import (
+	"slices"
+	"strings"
+)
+
+// GetHopPath returns the Heritrix-style hop path of the item: one L per hop
+// (outlink) from the original seed, then one E per embed and one R per
+// redirection inside the item's tree, e.g. "LLRE".
+func (i *Item) GetHopPath() string {
+	var path []byte
+	for item := i; !item.IsSeed(); item = item.parent {
+		if item.IsRedirection() {
+			path = append(path, 'R')
+		} else {
+			path = append(path, 'E')
+		}
+	}
+	slices.Reverse(path)
+
+	var hops int
+	if seed := i.GetSeed(); seed != nil && seed.url != nil {
+		hops = seed.url.GetHops()
+	}
+
+	return strings.Repeat("L", hops) + string(path)
+}
 
 // DrawTree generates the ASCII representation of the tree
 func (i *Item) DrawTree() string {
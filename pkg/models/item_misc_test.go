@@ -70,3 +70,30 @@ func TestDrawTree(t *testing.T) {
 		})
 	}
 }
+
+func TestGetHopPath(t *testing.T) {
+	seed := &Item{id: "seed", url: &URL{Raw: "https://example.com/", Hops: 2}, status: ItemGotRedirected}
+	redirection := &Item{id: "redirection", parent: seed, status: ItemGotChildren}
+	seed.children = append(seed.children, redirection)
+	asset := &Item{id: "asset", parent: redirection}
+	redirection.children = append(redirection.children, asset)
+
+	tests := []struct {
+		name     string
+		item     *Item
+		expected string
+	}{
+		{"Seed", seed, "LL"},
+		{"Redirection", redirection, "LLR"},
+		{"Asset of a redirection", asset, "LLRE"},
+		{"Seed without hops", &Item{id: "other", url: &URL{Raw: "https://example.org/"}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.item.GetHopPath(); got != tt.expected {
+				t.Errorf("GetHopPath() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
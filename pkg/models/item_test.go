@@ -143,6 +143,40 @@ func TestItem_GetSeedVia(t *testing.T) {
 	}
 }
 
+func TestItem_GetSeedScope(t *testing.T) {
+	maxHops := 2
+	scope := &SeedScope{MaxHops: &maxHops, IncludeHosts: []string{"example.com"}}
+
+	seed := createTestItem("seedID", nil)
+	if err := seed.SetSeedScope(scope); err != nil {
+		t.Fatalf("SetSeedScope() error = %v", err)
+	}
+	child := createTestItem("childID", seed)
+	grandchild := createTestItem("grandchildID", child)
+
+	for _, item := range []*Item{seed, child, grandchild} {
+		if got := item.GetSeedScope(); got != scope {
+			t.Errorf("GetSeedScope() of %s = %v, want %v", item.GetID(), got, scope)
+		}
+	}
+
+	if err := child.SetSeedScope(scope); err != ErrNotASeed {
+		t.Errorf("SetSeedScope() on a child error = %v, want %v", err, ErrNotASeed)
+	}
+
+	if got := grandchild.GetSeedScope().GetMaxHops(0); got != maxHops {
+		t.Errorf("GetMaxHops() = %d, want %d", got, maxHops)
+	}
+
+	var none *SeedScope
+	if got := none.GetMaxHops(5); got != 5 {
+		t.Errorf("GetMaxHops() without scope = %d, want the global 5", got)
+	}
+	if got := none.GetIncludeHosts([]string{"global.com"}); len(got) != 1 || got[0] != "global.com" {
+		t.Errorf("GetIncludeHosts() without scope = %v, want the global hosts", got)
+	}
+}
+
 func TestItem_GetStatus(t *testing.T) {
 	status := ItemArchived
 	item := createTestItem("testID", nil)
@@ -18,5 +18,8 @@ func extendMimetype() {
 		// gabriel-vasile/mimetype does not support CSS detection yet, so
 		// we have to extend a placeholder for our Content-Type lookup.
 		mimetype.Lookup("text/plain").Extend(mimePlaceholderFunc, "text/css", ".css")
+
+		// MPEG-DASH manifests are XML documents, detected as text/xml from their body
+		mimetype.Lookup("text/xml").Extend(mimePlaceholderFunc, "application/dash+xml", ".mpd")
 	})
 }
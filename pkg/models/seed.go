@@ -0,0 +1,141 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Seed is a seed as given by the user, either a plain URL or a JSON object such as:
+//
+//	{"url": "https://example.com/search", "method": "POST", "headers": {"Content-Type": "application/x-www-form-urlencoded"}, "body": "q=zeno"}
+//	{"url": "https://example.com/", "via": "curator", "hops": 1, "max_hops": 3, "include_hosts": ["example.com"]}
+type Seed struct {
+	URL     string            `json:"url"`
+	Via     string            `json:"via,omitempty"`
+	Hops    int               `json:"hops,omitempty"`
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	SeedScope
+}
+
+// SeedScope holds the scope settings of a seed that override the global ones,
+// they apply to every item crawled from the seed, outlinks included
+type SeedScope struct {
+	MaxHops      *int     `json:"max_hops,omitempty"`      // Overrides --max-hops
+	IncludeHosts []string `json:"include_hosts,omitempty"` // Overrides --include-host
+}
+
+// GetMaxHops returns the seed's max hops, or the given global one if not overridden
+func (s *SeedScope) GetMaxHops(global int) int {
+	if s == nil || s.MaxHops == nil {
+		return global
+	}
+	return *s.MaxHops
+}
+
+// GetIncludeHosts returns the seed's included hosts, or the given global ones if not overridden
+func (s *SeedScope) GetIncludeHosts(global []string) []string {
+	if s == nil || len(s.IncludeHosts) == 0 {
+		return global
+	}
+	return s.IncludeHosts
+}
+
+// IsEmpty returns true if the scope overrides nothing
+func (s *SeedScope) IsEmpty() bool {
+	return s == nil || (s.MaxHops == nil && len(s.IncludeHosts) == 0)
+}
+
+var (
+	// ErrSeedMissingURL is returned when a JSON seed has no url
+	ErrSeedMissingURL = errors.New("seed has no url")
+	// ErrSeedInvalidMethod is returned when a JSON seed method isn't a valid HTTP method
+	ErrSeedInvalidMethod = errors.New("seed has an invalid method")
+	// ErrSeedNegativeHops is returned when a JSON seed hops or max_hops is negative
+	ErrSeedNegativeHops = errors.New("seed has negative hops")
+)
+
+// ParseSeed parses a seed line, lines starting with { are read as JSON seeds
+// and anything else as a plain URL
+func ParseSeed(line string) (Seed, error) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return Seed{URL: line}, nil
+	}
+
+	var seed Seed
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&seed); err != nil {
+		return Seed{}, fmt.Errorf("invalid JSON seed: %w", err)
+	}
+
+	if seed.URL == "" {
+		return Seed{}, ErrSeedMissingURL
+	}
+
+	seed.Method = strings.ToUpper(seed.Method)
+	if seed.Method == http.MethodGet {
+		seed.Method = ""
+	}
+	if !isToken(seed.Method) {
+		return Seed{}, ErrSeedInvalidMethod
+	}
+
+	if seed.Hops < 0 || (seed.MaxHops != nil && *seed.MaxHops < 0) {
+		return Seed{}, ErrSeedNegativeHops
+	}
+
+	return seed, nil
+}
+
+// Scope returns the scope overrides of the seed, nil if there are none
+func (s Seed) Scope() *SeedScope {
+	if s.SeedScope.IsEmpty() {
+		return nil
+	}
+	scope := s.SeedScope
+	return &scope
+}
+
+// NewURL returns the URL to crawl for the seed, carrying its hops, method, headers and body
+func (s Seed) NewURL() (*URL, error) {
+	parsed, err := url.ParseRequestURI(s.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	URL := &URL{
+		Raw:    s.URL,
+		parsed: parsed,
+		Hops:   s.Hops,
+		Method: s.Method,
+	}
+
+	if len(s.Headers) > 0 {
+		URL.Headers = make(http.Header, len(s.Headers))
+		for key, value := range s.Headers {
+			URL.Headers.Set(key, value)
+		}
+	}
+	if s.Body != "" {
+		URL.Payload = []byte(s.Body)
+	}
+
+	return URL, nil
+}
+
+// isToken reports whether s only contains RFC 7230 token characters, the empty string is accepted
+func isToken(s string) bool {
+	for _, r := range s {
+		if r > 0x7e || r <= 0x20 || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r) {
+			return false
+		}
+	}
+	return true
+}
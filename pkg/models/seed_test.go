@@ -0,0 +1,135 @@
+package models
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestParseSeed(t *testing.T) {
+	tests := []struct {
+		name            string
+		line            string
+		expectedErr     bool
+		expectedURL     string
+		expectedMethod  string
+		expectedHeaders http.Header
+		expectedPayload string
+		expectedVia     string
+		expectedHops    int
+		expectedScope   *SeedScope
+	}{
+		{
+			name:           "plain URL",
+			line:           "  https://example.com/  ",
+			expectedURL:    "https://example.com/",
+			expectedMethod: http.MethodGet,
+		},
+		{
+			name:            "POST with headers and body",
+			line:            `{"url": "https://example.com/search", "method": "post", "headers": {"content-type": "application/x-www-form-urlencoded"}, "body": "q=zeno"}`,
+			expectedURL:     "https://example.com/search",
+			expectedMethod:  http.MethodPost,
+			expectedHeaders: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+			expectedPayload: "q=zeno",
+		},
+		{
+			name:           "explicit GET",
+			line:           `{"url": "https://example.com/", "method": "GET"}`,
+			expectedURL:    "https://example.com/",
+			expectedMethod: http.MethodGet,
+		},
+		{
+			name:           "via, hops and scope",
+			line:           `{"url": "https://example.com/", "via": "curator", "hops": 1, "max_hops": 0, "include_hosts": ["example.com", "cdn.example.com"]}`,
+			expectedURL:    "https://example.com/",
+			expectedMethod: http.MethodGet,
+			expectedVia:    "curator",
+			expectedHops:   1,
+			expectedScope:  &SeedScope{MaxHops: new(int), IncludeHosts: []string{"example.com", "cdn.example.com"}},
+		},
+		{
+			name:        "negative max hops",
+			line:        `{"url": "https://example.com/", "max_hops": -1}`,
+			expectedErr: true,
+		},
+		{
+			name:        "missing url",
+			line:        `{"method": "POST"}`,
+			expectedErr: true,
+		},
+		{
+			name:        "invalid method",
+			line:        `{"url": "https://example.com/", "method": "PO ST"}`,
+			expectedErr: true,
+		},
+		{
+			name:        "unknown field",
+			line:        `{"url": "https://example.com/", "payload": "a"}`,
+			expectedErr: true,
+		},
+		{
+			name:        "broken JSON",
+			line:        `{"url": "https://example.com/"`,
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seed, err := ParseSeed(tt.line)
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("ParseSeed() error = %v, expected error: %v", err, tt.expectedErr)
+			}
+			if tt.expectedErr {
+				return
+			}
+
+			URL, err := seed.NewURL()
+			if err != nil {
+				t.Fatalf("NewURL() error = %v", err)
+			}
+
+			if URL.Raw != tt.expectedURL {
+				t.Errorf("URL = %s, want %s", URL.Raw, tt.expectedURL)
+			}
+
+			if URL.GetMethod() != tt.expectedMethod {
+				t.Errorf("method = %s, want %s", URL.GetMethod(), tt.expectedMethod)
+			}
+
+			for key := range tt.expectedHeaders {
+				if URL.Headers.Get(key) != tt.expectedHeaders.Get(key) {
+					t.Errorf("header %s = %q, want %q", key, URL.Headers.Get(key), tt.expectedHeaders.Get(key))
+				}
+			}
+
+			if string(URL.Payload) != tt.expectedPayload {
+				t.Errorf("payload = %q, want %q", URL.Payload, tt.expectedPayload)
+			}
+
+			if seed.Via != tt.expectedVia || URL.GetHops() != tt.expectedHops {
+				t.Errorf("via, hops = %q, %d, want %q, %d", seed.Via, URL.GetHops(), tt.expectedVia, tt.expectedHops)
+			}
+
+			scope := seed.Scope()
+			if (scope == nil) != (tt.expectedScope == nil) {
+				t.Fatalf("Scope() = %+v, want %+v", scope, tt.expectedScope)
+			}
+			if scope != nil && (scope.GetMaxHops(-1) != tt.expectedScope.GetMaxHops(-1) ||
+				!slices.Equal(scope.GetIncludeHosts(nil), tt.expectedScope.GetIncludeHosts(nil))) {
+				t.Errorf("Scope() = %+v, want %+v", scope, tt.expectedScope)
+			}
+
+			if URL.HasCustomRequest() != (tt.expectedMethod != http.MethodGet || len(tt.expectedHeaders) > 0 || tt.expectedPayload != "") {
+				t.Errorf("HasCustomRequest() = %v", URL.HasCustomRequest())
+			}
+		})
+	}
+}
+
+func TestSeedNewURLInvalid(t *testing.T) {
+	if _, err := (Seed{URL: "http://[::1"}).NewURL(); err == nil {
+		t.Fatal("expected an error for an invalid URL")
+	}
+}
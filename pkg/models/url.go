@@ -28,6 +28,11 @@ type URL struct {
 	Hops      int // This determines the number of hops this item is the result of, a hop is a "jump" from 1 page to another page
 	Redirects int
 
+	// Request overrides, only set on seeds that need something else than a plain GET (forms, APIs...)
+	Method  string      // HTTP method of the request, GET if empty
+	Headers http.Header // Headers set on top of the default ones (User-Agent, cookies...)
+	Payload []byte      // Body of the request
+
 	stringCache string
 	once        sync.Once
 
@@ -135,6 +140,19 @@ func (u *URL) GetResponse() *http.Response {
 	return u.response
 }
 
+// GetMethod returns the HTTP method of the request, GET if none was set
+func (u *URL) GetMethod() string {
+	if u.Method == "" {
+		return http.MethodGet
+	}
+	return u.Method
+}
+
+// HasCustomRequest returns true if the request isn't a plain GET without extra headers
+func (u *URL) HasCustomRequest() bool {
+	return u.GetMethod() != http.MethodGet || len(u.Headers) > 0 || len(u.Payload) > 0
+}
+
 func (u *URL) GetRedirects() int {
 	return u.Redirects
 }